@@ -1,33 +1,78 @@
 package main
 
 import (
+    "context"
+    "embed"
+    "errors"
+    "flag"
     "fmt"
+    "io/fs"
+    "log/slog"
     "os"
+    "os/signal"
+    "syscall"
 
+    "go1f/pkg/config"
     "go1f/pkg/db"
+    "go1f/pkg/httplog"
     "go1f/pkg/server"
 )
 
+// webFiles веб-интерфейс, встроенный в исполняемый файл
+//
+//go:embed web
+var webFiles embed.FS
+
 func main() {
-    // Определяем путь к БД
-    dbFile := "scheduler.db"
-    if envDBFile := os.Getenv("TODO_DBFILE"); envDBFile != "" {
-        dbFile = envDBFile
+    // Загружаем настройки из флагов, файла и окружения
+    cfg, err := config.Load(os.Args[1:])
+    if errors.Is(err, flag.ErrHelp) {
+        return
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(2)
+    }
+
+    // Журнал в выбранном формате используется всеми пакетами через slog.Default
+    logger, err := httplog.NewLogger(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(2)
     }
+    slog.SetDefault(logger)
 
+    if err := run(cfg); err != nil {
+        slog.Error("server failed", "err", err)
+        os.Exit(1)
+    }
+    slog.Info("server stopped")
+}
+
+// run открывает базу и обслуживает запросы до сигнала остановки.
+// Ошибки инициализации возвращаются, чтобы main записал их в журнал и завершился с ненулевым кодом
+func run(cfg *config.Config) error {
     // Инициализируем БД
-    if err := db.Init(dbFile); err != nil {
-        panic(fmt.Sprintf("Failed to initialize database: %v", err))
+    if err := db.Init(cfg.DBFile); err != nil {
+        return fmt.Errorf("failed to initialize database: %w", err)
     }
     defer db.Close()
 
     // Проверяем что БД инициализирована
     if db.GetDB() == nil {
-        panic("Database is still nil after initialization!")
+        return errors.New("database is still nil after initialization")
     }
 
+    // SIGINT и SIGTERM завершают сервер после обработки начатых запросов,
+    // после возврата из Run отложенный вызов закрывает базу
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
     // Запускаем сервер
-    if err := server.Run(); err != nil {
-        panic(err)
+    webFS, err := fs.Sub(webFiles, "web")
+    if err != nil {
+        return err
     }
-}
\ No newline at end of file
+
+    return server.Run(ctx, cfg, webFS)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+	"go1f/pkg/apperr"
 	"go1f/pkg/db"
 )
 
@@ -19,23 +20,47 @@ func taskHandler(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete: 
 		deleteTaskHandler(w, r)
 	default:
-		writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
 	}
 }
 
 // getTaskHandler обрабатывает получение задачи по ID
 func getTaskHandler(w http.ResponseWriter, r *http.Request) {
+	owner := currentList(r)
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	
-	id := r.URL.Query().Get("id")
+	id := param(r, "id")
 	if id == "" {
-		writeJSONError(w, "ID not specified", http.StatusBadRequest)
+		writeJSONError(w, apperr.IDRequired, "ID not specified")
+		return
+	}
+
+	task, err := db.GetTask(owner, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Добавляем пункты чек-листа
+	task.Checklist, err = db.ChecklistItems(owner, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Добавляем незавершённые блокирующие задачи
+	task.BlockedBy, err = db.Blockers(owner, id)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
+	task.Blocked = len(task.BlockedBy) > 0
 
-	task, err := db.GetTask(id)
+	// Добавляем список вложений
+	task.Attachments, err = db.Attachments(owner, id)
 	if err != nil {
-		writeJSONError(w, err.Error(), http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
@@ -48,31 +73,46 @@ func updateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	
 	var task db.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		writeJSONError(w, "JSON decoding error: "+err.Error(), http.StatusBadRequest)
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
 		return
 	}
 
 	// Проверяем обязательное поле title
 	if task.Title == "" {
-		writeJSONError(w, "Task title not specified", http.StatusBadRequest)
+		writeJSONError(w, apperr.TitleRequired, "Task title not specified")
 		return
 	}
 
+	// В /api/v1 ID задачи передаётся в пути
+	if id := r.PathValue("id"); id != "" {
+		task.ID = id
+	}
+
 	// Проверяем наличие ID
 	if task.ID == "" {
-		writeJSONError(w, "Task ID not specified", http.StatusBadRequest)
+		writeJSONError(w, apperr.IDRequired, "Task ID not specified")
 		return
 	}
 
+	// Проверяем пользовательские поля, если они переданы
+	if task.Fields != nil {
+		fields, err := normalizeFieldValues(task.Fields, true)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		task.Fields = fields
+	}
+
 	// Обрабатываем дату
 	if err := processTaskDate(&task); err != nil {
-		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	// Обновляем задачу в базе
-	if err := db.UpdateTask(&task); err != nil {
-		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+	if err := db.UpdateTask(currentList(r), &task); err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -86,28 +126,37 @@ func addTaskHandler(w http.ResponseWriter, r *http.Request) {
 	
 	var task db.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		writeJSONError(w, "JSON decoding error: "+err.Error(), http.StatusBadRequest)
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
 		return
 	}
 
 	// Проверяем обязательное поле title
 	if task.Title == "" {
-		writeJSONError(w, "Task title not specified", http.StatusBadRequest)
+		writeJSONError(w, apperr.TitleRequired, "Task title not specified")
+		return
+	}
+
+	// Проверяем пользовательские поля
+	fields, err := normalizeFieldValues(task.Fields, true)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
+	task.Fields = fields
 
 	// Обрабатываем дату
 	if err := processTaskDate(&task); err != nil {
-		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
 	// Добавляем задачу в базу
-	id, err := db.AddTask(&task)
+	id, err := db.AddTask(currentList(r), &task)
 	if err != nil {
-		writeJSONError(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
+	tasksCreated.Inc()
 
 	writeJSONSuccess(w, map[string]interface{}{"id": id}, http.StatusOK)
 }
@@ -124,7 +173,7 @@ func processTaskDate(task *db.Task) error {
 	// Проверяем формат даты
 	t, err := time.Parse(DateFormat, task.Date)
 	if err != nil {
-		return err
+		return apperr.Errorf(apperr.InvalidDate, "invalid date format: %s", task.Date)
 	}
 
 	// Если дата в прошлом
@@ -147,33 +196,45 @@ func processTaskDate(task *db.Task) error {
 
 // taskDoneHandler обрабатывает отметку о выполнении задачи
 func taskDoneHandler(w http.ResponseWriter, r *http.Request) {
+	owner := currentList(r)
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	
 	if r.Method != http.MethodPost {
-		writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
 		return
 	}
 	
-	id := r.URL.Query().Get("id")
+	id := param(r, "id")
 	if id == "" {
-		writeJSONError(w, "ID parameter missing", http.StatusBadRequest)
+		writeJSONError(w, apperr.IDRequired, "ID parameter missing")
 		return
 	}
 	
 	// Получаем задачу
-	task, err := db.GetTask(id)
+	task, err := db.GetTask(owner, id)
 	if err != nil {
-		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
+		return
+	}
+
+	if !requireUnblocked(w, r, owner, id) {
 		return
 	}
+
+	// Задача, уже переведённая в done через статус, учтена в метрике при переходе
+	completed := task.Status != db.StatusDone
 	
 	// Если задача без повтора - удаляем
 	if task.Repeat == "" {
-		err = db.DeleteTask(id)
+		err = db.DeleteTask(owner, id)
 		if err != nil {
-			writeJSONError(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
+		if completed {
+			tasksCompleted.Inc()
+		}
 		writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
 		return
 	}
@@ -182,16 +243,35 @@ func taskDoneHandler(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	nextDate, err := NextDate(now, task.Date, task.Repeat)
 	if err != nil {
-		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 	
 	// Обновляем дату
-	err = db.UpdateDate(nextDate, id)
+	err = db.UpdateDate(owner, nextDate, id)
 	if err != nil {
-		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
+
+	// Следующее повторение начинается с чистого чек-листа
+	err = db.ResetChecklist(owner, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Следующее повторение снова начинается в статусе todo
+	if task.Status != db.StatusTodo {
+		err = db.SetStatus(owner, id, db.StatusTodo)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	if completed {
+		tasksCompleted.Inc()
+	}
 	
 	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
 }
@@ -200,16 +280,35 @@ func taskDoneHandler(w http.ResponseWriter, r *http.Request) {
 func deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	
-	id := r.URL.Query().Get("id")
+	id := param(r, "id")
 	if id == "" {
-		writeJSONError(w, "ID not specified", http.StatusBadRequest)
+		writeJSONError(w, apperr.IDRequired, "ID not specified")
 		return
 	}
 
-	if err := db.DeleteTask(id); err != nil {
-		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+	if err := db.DeleteTask(currentList(r), id); err != nil {
+		writeError(w, err)
 		return
 	}
 
 	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
-}
\ No newline at end of file
+}
+
+// requireUnblocked проверяет, что у задачи нет незавершённых блокирующих задач, иначе отвечает 409.
+// Задачу с блокирующими задачами можно выполнить только принудительно, с параметром force=true
+func requireUnblocked(w http.ResponseWriter, r *http.Request, owner, id string) bool {
+	if r.URL.Query().Get("force") == "true" {
+		return true
+	}
+
+	blockers, err := db.Blockers(owner, id)
+	if err != nil {
+		writeError(w, err)
+		return false
+	}
+	if len(blockers) > 0 {
+		writeJSONError(w, apperr.TaskBlocked, "Task is blocked by open tasks")
+		return false
+	}
+	return true
+}
@@ -1,43 +1,100 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"os"
 	"time"
 
+	"go1f/pkg/apperr"
 	"go1f/pkg/auth"
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+	"go1f/pkg/httplog"
+)
+
+// cfg настройки приложения, переданные в Init
+var cfg *config.Config
+
+// ctxKey тип ключей для значений в контексте запроса
+type ctxKey int
+
+// Ключи значений в контексте запроса
+const (
+	userKey    ctxKey = iota // ID аутентифицированного пользователя
+	sessionKey               // ID сессии пользователя
+	scopeKey                 // Область действия API-токена
+	listKey                  // ID списка задач, с которым работает запрос
+	roleKey                  // Роль пользователя в этом списке
 )
 
 // SignInRequest структура для запроса входа
+// Если логин не указан, вход выполняется под встроенным администратором
 type SignInRequest struct {
+	Login    string `json:"login"`
 	Password string `json:"password"`
 }
 
 // SignInResponse структура для ответа входа
 type SignInResponse struct {
-	Token string `json:"token,omitempty"`
-	Error string `json:"error,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"` // Нужен второй шаг через /api/signin/totp
+	MFAToken     string `json:"mfa_token,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Init подготавливает ключ подписи, пароль администратора и вход через провайдера
+// Возвращает обработчик всех маршрутов /api/, который можно смонтировать в любой ServeMux
+func Init(c *config.Config) (http.Handler, error) {
+	cfg = c
+	auth.Configure(cfg.Password != "", cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	db.SetAttachmentsDir(cfg.AttachmentsDir)
+
+	if err := initSigningKeys(); err != nil {
+		return nil, err
+	}
+	if err := initAdminPassword(); err != nil {
+		return nil, err
+	}
+	if err := initOIDC(); err != nil {
+		return nil, err
+	}
+
+	return localize(routes()), nil
 }
 
-// Init регистрирует обработчики HTTP-запросов
-func Init() {
-	// Публичные маршруты (без аутентификации)
-	http.HandleFunc("/api/signin", signinHandler)
-	http.HandleFunc("/api/nextdate", nextDayHandler)
+// initAdminPassword сохраняет хеш пароля из настроек для администратора без пароля
+// После этого пароль меняется только через /api/password.
+// С отключённой аутентификацией пароль не сохраняется, чтобы после её включения сработал TODO_PASSWORD
+func initAdminPassword() error {
+	if cfg.Password == "" || !auth.IsAuthEnabled() {
+		return nil
+	}
+
+	admin, err := db.GetUser(db.AdminID)
+	if err != nil {
+		return err
+	}
+	// Хеш пустого пароля считается отсутствующим
+	if admin.PasswordHash != "" && !auth.IsEmptyPasswordHash(admin.PasswordHash) {
+		return nil
+	}
 
-	// Защищенные маршруты (требуют аутентификации)
-	http.HandleFunc("/api/task", authMiddleware(taskHandler))
-	http.HandleFunc("/api/tasks", authMiddleware(tasksHandler))
-	http.HandleFunc("/api/task/done", authMiddleware(taskDoneHandler))
+	hash, err := auth.HashPassword(cfg.Password)
+	if err != nil {
+		return err
+	}
+	return db.SetPasswordHash(db.AdminID, hash)
 }
 
 // authMiddleware проверяет аутентификацию
 func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Если аутентификация отключена, пропускаем
+		// Если аутентификация отключена, все запросы выполняются от имени администратора
 		if !auth.IsAuthEnabled() {
-			next.ServeHTTP(w, r)
+			httplog.SetUser(r.Context(), db.AdminID)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey, db.AdminID)))
 			return
 		}
 
@@ -47,6 +104,12 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		if err == nil {
 			tokenString = cookie.Value
 		}
+		fromCookie := tokenString != ""
+
+		// Браузер отправляет куку и на запросы с чужих сайтов, поэтому они дополнительно проверяются
+		if fromCookie && !checkCSRF(w, r) {
+			return
+		}
 
 		// Если токена нет в куках, проверяем заголовок Authorization
 		if tokenString == "" {
@@ -56,67 +119,135 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			}
 		}
 
-		// Проверяем токен
-		valid, err := auth.ValidateToken(tokenString)
-		if err != nil || !valid {
-			writeJSONError(w, "Authentication required", http.StatusUnauthorized)
+		// Персональные API-токены принимаются только из заголовка Authorization
+		if !fromCookie && auth.IsAPIToken(tokenString) {
+			token, err := apiTokenUser(tokenString)
+			if err != nil {
+				writeJSONError(w, apperr.Unauthorized, "Authentication required")
+				return
+			}
+			if token.Scope == db.ScopeRead && !readOnlyMethod(r.Method) {
+				writeJSONError(w, apperr.ReadOnlyToken, "API token is read-only")
+				return
+			}
+
+			httplog.SetUser(r.Context(), token.UserID)
+			ctx := context.WithValue(r.Context(), userKey, token.UserID)
+			ctx = context.WithValue(ctx, scopeKey, token.Scope)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		// Проверяем токен и что его сессия не отозвана
+		var userID, sessionID string
+		claims, err := auth.ValidateToken(tokenString)
+		if err == nil && activeSession(claims) {
+			userID, sessionID = claims.UserID, claims.SessionID
+		} else if refresh, cerr := r.Cookie(refreshCookie); fromCookie && cerr == nil {
+			// Веб-интерфейс не умеет обновлять токен сам, поэтому продлеваем сессию по куке
+			if session, _, rerr := refreshSession(w, refresh.Value); rerr == nil {
+				userID, sessionID = session.UserID, session.ID
+			}
+		}
+		if userID == "" {
+			writeJSONError(w, apperr.Unauthorized, "Authentication required")
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		httplog.SetUser(r.Context(), userID)
+		ctx := context.WithValue(r.Context(), userKey, userID)
+		ctx = context.WithValue(ctx, sessionKey, sessionID)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// currentUser возвращает ID пользователя, выполняющего запрос
+func currentUser(r *http.Request) string {
+	id, _ := r.Context().Value(userKey).(string)
+	return id
+}
+
+// currentSession возвращает ID сессии, в которой выполняется запрос
+func currentSession(r *http.Request) string {
+	id, _ := r.Context().Value(sessionKey).(string)
+	return id
+}
+
 // signinHandler обрабатывает аутентификацию
 func signinHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	
 	if r.Method != http.MethodPost {
-		writeJSONError(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	var req SignInRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, "Неверный формат запроса", http.StatusBadRequest)
+		writeJSONError(w, apperr.InvalidJSON, "Invalid request format")
 		return
 	}
 
-	// Проверяем пароль
-	expectedPassword := getPassword()
-	
-	if req.Password != expectedPassword {
-		writeJSONError(w, "Неверный пароль", http.StatusUnauthorized)
+	if req.Login == "" {
+		req.Login = "admin"
+	}
+
+	account := loginAccount(req.Login)
+	if wait := signinThrottle.blocked(clientIP(r), account, time.Now()); wait > 0 {
+		writeThrottled(w, wait)
 		return
 	}
 
-	// Генерируем токен
-	token, err := auth.GenerateToken()
+	user, err := db.GetUserByLogin(req.Login)
 	if err != nil {
-		writeJSONError(w, "Ошибка генерации токена", http.StatusInternalServerError)
+		// Неизвестный логин проверяется так же долго, как существующий
+		auth.CheckPassword("", req.Password)
+		countSignIn("password", false)
+		signinFailed(w, r, account, apperr.InvalidCredentials, "Invalid login or password")
 		return
 	}
 
-	response := SignInResponse{Token: token}
-	json.NewEncoder(w).Encode(response)
-}
+	// Проверяем пароль по сохранённому хешу
+	if !auth.CheckPassword(user.PasswordHash, req.Password) {
+		countSignIn("password", false)
+		signinFailed(w, r, account, apperr.InvalidCredentials, "Invalid login or password")
+		return
+	}
+
+	// С включённым TOTP выдаём только токен для второго шага входа
+	totpEnabled, err := db.TOTPEnabled(user.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if totpEnabled {
+		mfaToken, err := auth.GenerateMFAToken(user.ID)
+		if err != nil {
+			writeJSONError(w, apperr.Internal, "Token generation failed")
+			return
+		}
+		signinThrottle.pass()
+		json.NewEncoder(w).Encode(SignInResponse{MFARequired: true, MFAToken: mfaToken})
+		return
+	}
 
-// Добавьте эту функцию
-func getPassword() string {
-	password := os.Getenv("TODO_PASSWORD")
-	if password == "" {
-		return "qwerty123" // тот же пароль по умолчанию
+	// Создаём сессию и генерируем токены
+	response, err := startSession(w, r, user)
+	if err != nil {
+		writeJSONError(w, apperr.Internal, "Token generation failed")
+		return
 	}
-	return password
+	countSignIn("password", true)
+	signinThrottle.succeed(clientIP(r), account)
+
+	json.NewEncoder(w).Encode(response)
 }
 
 // nextDayHandler обрабатывает запросы для вычисления следующей даты
 func nextDayHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-
 	// Проверяем метод запроса
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -130,27 +261,28 @@ func nextDayHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		parsedNow, err := time.Parse(DateFormat, nowParam)
 		if err != nil {
-			http.Error(w, "Invalid now parameter format", http.StatusBadRequest)
+			writeJSONError(w, apperr.InvalidDate, "Invalid now parameter format")
 			return
 		}
 		now = parsedNow
 	}
 
 	if dateParam == "" {
-		http.Error(w, "Missing date parameter", http.StatusBadRequest)
+		writeJSONError(w, apperr.InvalidDate, "Missing date parameter")
 		return
 	}
 	if repeatParam == "" {
-		http.Error(w, "Missing repeat parameter", http.StatusBadRequest)
+		writeJSONError(w, apperr.InvalidRepeat, "Missing repeat parameter")
 		return
 	}
 
 	nextDate, err := NextDate(now, dateParam, repeatParam)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(nextDate))
 }
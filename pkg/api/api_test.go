@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+)
+
+// testPassword пароль входа в тестах
+const testPassword = "qwerty123"
+
+// newTestAPI поднимает API на чистой базе во временном каталоге
+// configure может поменять настройки до вызова Init
+func newTestAPI(t *testing.T, configure func(c *config.Config)) http.Handler {
+	t.Helper()
+
+	dir := t.TempDir()
+	c := config.Default()
+	c.DBFile = filepath.Join(dir, "scheduler.db")
+	c.AttachmentsDir = filepath.Join(dir, "attachments")
+	c.Password = testPassword
+	if configure != nil {
+		configure(c)
+	}
+
+	require.NoError(t, db.Init(c.DBFile))
+	t.Cleanup(func() { db.Close() })
+
+	// Состояние пакета не должно переходить из теста в тест
+	oidcProvider = nil
+	signinThrottle = NewThrottle()
+
+	h, err := Init(c)
+	require.NoError(t, err)
+	return h
+}
+
+// request выполняет запрос к API, body кодируется в JSON, если это не nil
+func request(t *testing.T, h http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return requestFrom(t, h, "192.0.2.1", method, path, token, body)
+}
+
+// requestFrom выполняет запрос к API с IP-адреса ip
+func requestFrom(t *testing.T, h http.Handler, ip, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.RemoteAddr = ip + ":1234"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode разбирает JSON-ответ
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &m), rec.Body.String())
+	return m
+}
+
+// signIn входит по паролю и возвращает токен, пустой логин означает администратора
+func signIn(t *testing.T, h http.Handler, login, password string) string {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/signin", "", SignInRequest{Login: login, Password: password})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	token, _ := decode(t, rec)["token"].(string)
+	require.NotEmpty(t, token)
+	return token
+}
+
+// addTask создаёт задачу и возвращает её ID
+func addTask(t *testing.T, h http.Handler, token, title string) string {
+	t.Helper()
+
+	return addRepeatingTask(t, h, token, title, "")
+}
+
+// addRepeatingTask создаёт задачу с правилом повторения repeat и возвращает её ID
+func addRepeatingTask(t *testing.T, h http.Handler, token, title, repeat string) string {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/task", token,
+		map[string]string{"date": "20300101", "title": title, "repeat": repeat})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return fmt.Sprint(decode(t, rec)["id"])
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/db"
+)
+
+// uploadAttachmentHandler прикрепляет файл к задаче
+func uploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	owner := currentList(r)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	taskID := param(r, "id")
+	if taskID == "" {
+		writeJSONError(w, apperr.IDRequired, "ID parameter missing")
+		return
+	}
+
+	if _, err := db.GetTask(owner, taskID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	maxSize := cfg.AttachmentMaxSize
+	quota := cfg.AttachmentQuota
+
+	// Оставляем запас на заголовки multipart
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeJSONError(w, apperr.FileTooLarge, "File is too large")
+			return
+		}
+		writeJSONError(w, apperr.BadRequest, "File not specified: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxSize {
+		writeJSONError(w, apperr.FileTooLarge, "File is too large")
+		return
+	}
+
+	// Предварительная проверка, чтобы не сохранять файл зря; окончательно квота проверяется при записи в базу
+	used, err := db.AttachmentsSize(owner)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if used+header.Size > quota {
+		writeJSONError(w, apperr.QuotaExceeded, "Attachment quota exceeded")
+		return
+	}
+
+	// Определяем тип содержимого по первым байтам файла, а не по заявленному клиентом
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		writeError(w, apperr.Wrap(apperr.BadRequest, "failed to read file", err))
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	path, err := saveAttachmentFile(taskID, file)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := db.AddAttachment(owner, &db.Attachment{
+		TaskID:      taskID,
+		Name:        filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		Path:        path,
+	}, quota)
+	if err != nil {
+		os.Remove(db.AttachmentFile(path))
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"id": id}, http.StatusOK)
+}
+
+// saveAttachmentFile сохраняет содержимое файла в каталог задачи под случайным именем
+// Возвращает путь к сохранённому файлу относительно каталога вложений
+func saveAttachmentFile(taskID string, src io.Reader) (string, error) {
+	if err := os.MkdirAll(db.AttachmentFile(taskID), 0o750); err != nil {
+		return "", err
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+	rel := filepath.Join(taskID, hex.EncodeToString(name))
+	path := db.AttachmentFile(rel)
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return rel, nil
+}
+
+// attachmentHandler обрабатывает скачивание и удаление вложения
+func attachmentHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		downloadAttachmentHandler(w, r)
+	case http.MethodDelete:
+		deleteAttachmentHandler(w, r)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// downloadAttachmentHandler отдаёт содержимое вложения
+func downloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		writeJSONError(w, apperr.IDRequired, "ID not specified")
+		return
+	}
+
+	a, err := db.GetAttachment(currentList(r), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	f, err := os.Open(db.AttachmentFile(a.Path))
+	if err != nil {
+		writeJSONError(w, apperr.AttachmentNotFound, "Attachment file not found")
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	http.ServeContent(w, r, a.Name, info.ModTime(), f)
+}
+
+// deleteAttachmentHandler удаляет вложение вместе с файлом
+func deleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		writeJSONError(w, apperr.IDRequired, "ID not specified")
+		return
+	}
+
+	if err := db.DeleteAttachment(currentList(r), id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
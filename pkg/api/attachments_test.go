@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+)
+
+// upload прикрепляет к задаче файл с содержимым data
+func upload(t *testing.T, h http.Handler, token, taskID string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "../notes.txt")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/task/attachments?id="+taskID, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAttachments(t *testing.T) {
+	var dir string
+	h := newTestAPI(t, func(c *config.Config) { dir = c.AttachmentsDir })
+	token := signIn(t, h, "", testPassword)
+	taskID := addTask(t, h, token, "task")
+
+	rec := upload(t, h, token, taskID, []byte("hello"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	id := fmt.Sprint(decode(t, rec)["id"])
+
+	rec = request(t, h, http.MethodGet, "/api/task/attachment?id="+id, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "hello", rec.Body.String())
+	// Тип определяется по содержимому, имя файла без каталогов клиента
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, `attachment; filename=notes.txt`, rec.Header().Get("Content-Disposition"))
+
+	a, err := db.GetAttachment(db.AdminID, id)
+	require.NoError(t, err)
+	rec = request(t, h, http.MethodDelete, "/api/task/attachment?id="+id, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NoFileExists(t, filepath.Join(dir, a.Path))
+
+	rec = request(t, h, http.MethodGet, "/api/task/attachment?id="+id, token, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, http.StatusNotFound, upload(t, h, token, "999", []byte("hello")).Code)
+}
+
+func TestAttachmentLimits(t *testing.T) {
+	h := newTestAPI(t, func(c *config.Config) {
+		c.AttachmentMaxSize = 100
+		c.AttachmentQuota = 150
+	})
+	token := signIn(t, h, "", testPassword)
+	taskID := addTask(t, h, token, "task")
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload(t, h, token, taskID, make([]byte, 101)).Code)
+	require.Equal(t, http.StatusOK, upload(t, h, token, taskID, make([]byte, 100)).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload(t, h, token, taskID, make([]byte, 51)).Code)
+	assert.Equal(t, http.StatusOK, upload(t, h, token, taskID, make([]byte, 50)).Code)
+}
+
+func TestAttachmentsDeletedWithTask(t *testing.T) {
+	var dir string
+	h := newTestAPI(t, func(c *config.Config) { dir = c.AttachmentsDir })
+	token := signIn(t, h, "", testPassword)
+	taskID := addTask(t, h, token, "task")
+
+	rec := upload(t, h, token, taskID, []byte("hello"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	a, err := db.GetAttachment(db.AdminID, fmt.Sprint(decode(t, rec)["id"]))
+	require.NoError(t, err)
+
+	rec = request(t, h, http.MethodDelete, "/api/task?id="+taskID, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NoFileExists(t, filepath.Join(dir, a.Path))
+}
+
+func TestAttachmentQuotaPerOwner(t *testing.T) {
+	var dir string
+	h := newTestAPI(t, func(c *config.Config) {
+		c.AttachmentMaxSize = 100
+		c.AttachmentQuota = 150
+		dir = c.AttachmentsDir
+	})
+	token := signIn(t, h, "", testPassword)
+
+	first := addTask(t, h, token, "first")
+	second := addTask(t, h, token, "second")
+
+	rec := upload(t, h, token, first, make([]byte, 100))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Квота общая для всех задач владельца
+	rec = upload(t, h, token, second, make([]byte, 100))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+	assert.Equal(t, "quota_exceeded", decode(t, rec)["code"])
+
+	rec = upload(t, h, token, second, make([]byte, 50))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// В базе хранится путь относительно каталога вложений
+	list, err := db.Attachments(db.AdminID, second)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.False(t, filepath.IsAbs(list[0].Path))
+	_, err = os.Stat(filepath.Join(dir, list[0].Path))
+	assert.NoError(t, err)
+}
+
+func TestAddAttachmentQuota(t *testing.T) {
+	h := newTestAPI(t, nil)
+	taskID := addTask(t, h, signIn(t, h, "", testPassword), "task")
+
+	_, err := db.AddAttachment(db.AdminID, &db.Attachment{TaskID: taskID, Name: "a", Size: 60, Path: "a"}, 100)
+	require.NoError(t, err)
+	_, err = db.AddAttachment(db.AdminID, &db.Attachment{TaskID: taskID, Name: "b", Size: 60, Path: "b"}, 100)
+	require.Error(t, err)
+
+	// Отклонённое вложение не остаётся в базе
+	size, err := db.AttachmentsSize(db.AdminID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 60, size)
+}
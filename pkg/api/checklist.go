@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/db"
+)
+
+// ReorderChecklistReq структура для запроса изменения порядка чек-листа
+type ReorderChecklistReq struct {
+	TaskID string   `json:"task_id"`
+	IDs    []string `json:"ids"`
+}
+
+// checklistHandler обрабатывает добавление и удаление пунктов чек-листа
+func checklistHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		addChecklistItemHandler(w, r)
+	case http.MethodDelete:
+		deleteChecklistItemHandler(w, r)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// addChecklistItemHandler добавляет пункт в чек-лист задачи
+func addChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	owner := currentList(r)
+
+	var item db.ChecklistItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	if id := r.PathValue("task_id"); id != "" {
+		item.TaskID = id
+	}
+	if item.TaskID == "" {
+		writeJSONError(w, apperr.IDRequired, "Task ID not specified")
+		return
+	}
+	if item.Text == "" {
+		writeJSONError(w, apperr.BadRequest, "Checklist item text not specified")
+		return
+	}
+
+	// Проверяем, что задача существует
+	if _, err := db.GetTask(owner, item.TaskID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := db.AddChecklistItem(owner, &item)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"id": id}, http.StatusOK)
+}
+
+// deleteChecklistItemHandler удаляет пункт чек-листа
+func deleteChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		writeJSONError(w, apperr.IDRequired, "ID not specified")
+		return
+	}
+
+	if err := db.DeleteChecklistItem(currentList(r), id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
+
+// checklistToggleHandler переключает отметку о выполнении пункта чек-листа
+func checklistToggleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id := param(r, "id")
+	if id == "" {
+		writeJSONError(w, apperr.IDRequired, "ID parameter missing")
+		return
+	}
+
+	if err := db.ToggleChecklistItem(currentList(r), id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
+
+// checklistReorderHandler задаёт новый порядок пунктов чек-листа
+func checklistReorderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req ReorderChecklistReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	if id := r.PathValue("task_id"); id != "" {
+		req.TaskID = id
+	}
+	if req.TaskID == "" {
+		writeJSONError(w, apperr.IDRequired, "Task ID not specified")
+		return
+	}
+
+	if err := db.ReorderChecklist(currentList(r), req.TaskID, req.IDs); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/db"
+)
+
+// addChecklistItem добавляет пункт в чек-лист задачи и возвращает его ID
+func addChecklistItem(t *testing.T, h http.Handler, token, taskID, text string) string {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/task/checklist", token, map[string]string{"task_id": taskID, "text": text})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return fmt.Sprint(decode(t, rec)["id"])
+}
+
+// checklist возвращает пункты чек-листа задачи
+func checklist(t *testing.T, h http.Handler, token, taskID string) []interface{} {
+	t.Helper()
+
+	rec := request(t, h, http.MethodGet, "/api/task?id="+taskID, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	items, _ := decode(t, rec)["checklist"].([]interface{})
+	return items
+}
+
+func TestChecklistResetOnRepeat(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	taskID := addRepeatingTask(t, h, token, "Уборка", "d 7")
+
+	first := addChecklistItem(t, h, token, taskID, "Пропылесосить")
+	addChecklistItem(t, h, token, taskID, "Вынести мусор")
+
+	rec := request(t, h, http.MethodPost, "/api/task/checklist/toggle?id="+first, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	items := checklist(t, h, token, taskID)
+	require.Len(t, items, 2)
+	assert.Equal(t, true, items[0].(map[string]interface{})["done"])
+
+	// Следующее повторение задачи начинается с неотмеченных пунктов
+	rec = request(t, h, http.MethodPost, "/api/task/done?id="+taskID, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	items = checklist(t, h, token, taskID)
+	require.Len(t, items, 2)
+	for _, item := range items {
+		assert.Equal(t, false, item.(map[string]interface{})["done"])
+	}
+}
+
+func TestChecklistReorder(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	taskID := addTask(t, h, token, "Отпуск")
+	first := addChecklistItem(t, h, token, taskID, "Билеты")
+	second := addChecklistItem(t, h, token, taskID, "Гостиница")
+
+	rec := request(t, h, http.MethodPost, "/api/task/checklist/reorder", token,
+		ReorderChecklistReq{TaskID: taskID, IDs: []string{second, first}})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	items := checklist(t, h, token, taskID)
+	require.Len(t, items, 2)
+	assert.Equal(t, "Гостиница", items[0].(map[string]interface{})["text"])
+	assert.Equal(t, "Билеты", items[1].(map[string]interface{})["text"])
+
+	// Порядок должен перечислять все пункты задачи
+	rec = request(t, h, http.MethodPost, "/api/task/checklist/reorder", token,
+		ReorderChecklistReq{TaskID: taskID, IDs: []string{first}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestChecklistDeletedWithTask(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	taskID := addTask(t, h, token, "Разовая задача")
+	addChecklistItem(t, h, token, taskID, "Пункт")
+
+	// Выполненная задача без повторения удаляется вместе с чек-листом
+	rec := request(t, h, http.MethodPost, "/api/task/done?id="+taskID, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	items, err := db.ChecklistItems(db.AdminID, taskID)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
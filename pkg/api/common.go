@@ -2,7 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/httplog"
+	"go1f/pkg/i18n"
 )
 
 const (
@@ -10,6 +16,15 @@ const (
 	MaxDayInterval = 400        // Максимальный интервал в днях
 )
 
+// param возвращает параметр из пути маршрута /api/v1/...,
+// а для прежних адресов без параметров в пути — из строки запроса
+func param(r *http.Request, name string) string {
+	if v := r.PathValue(name); v != "" {
+		return v
+	}
+	return r.URL.Query().Get(name)
+}
+
 // writeJSONSuccess отправляет успешный JSON ответ
 func writeJSONSuccess(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -17,10 +32,117 @@ func writeJSONSuccess(w http.ResponseWriter, data interface{}, statusCode int) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// Problem описание ошибки в формате RFC 7807 (application/problem+json)
+// Необязательное поле status не передаётся: статус есть в ответе, а прежние клиенты
+// разбирают ошибку как объект со строковыми значениями. Для них же оставлено поле error с текстом для пользователя.
+// request_id совпадает с заголовком X-Request-ID и журналом, его сообщают при обращении в поддержку
+type Problem struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Detail    string      `json:"detail,omitempty"`
+	Code      apperr.Code `json:"code"`
+	Error     string      `json:"error"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// problemStatus HTTP-статусы кодов ошибок, коды не из таблицы отдаются как 500
+var problemStatus = map[apperr.Code]int{
+	apperr.BadRequest:       http.StatusBadRequest,
+	apperr.InvalidJSON:      http.StatusBadRequest,
+	apperr.MethodNotAllowed: http.StatusMethodNotAllowed,
+	apperr.Unauthorized:     http.StatusUnauthorized,
+	apperr.Forbidden:        http.StatusForbidden,
+	apperr.NotFound:         http.StatusNotFound,
+	apperr.Conflict:         http.StatusConflict,
+	apperr.TooManyRequests:  http.StatusTooManyRequests,
 
-// writeJSONError отправляет JSON ответ с ошибкой
-func writeJSONError(w http.ResponseWriter, error string, code int) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(map[string]string{"error": error})
-}
\ No newline at end of file
+	apperr.InvalidCredentials: http.StatusUnauthorized,
+	apperr.WrongPassword:      http.StatusForbidden,
+	apperr.InvalidToken:       http.StatusUnauthorized,
+	apperr.CSRFFailed:         http.StatusForbidden,
+	apperr.ReadOnlyToken:      http.StatusForbidden,
+	apperr.AdminRequired:      http.StatusForbidden,
+	apperr.SignupDisabled:     http.StatusForbidden,
+	apperr.InvalidTOTPCode:    http.StatusUnauthorized,
+	apperr.TOTPConfirmFailed:  http.StatusBadRequest,
+	apperr.MFAExpired:         http.StatusUnauthorized,
+	apperr.TOTPNotConfigured:  http.StatusBadRequest,
+	apperr.TOTPAlreadyEnabled: http.StatusConflict,
+	apperr.OIDCDisabled:       http.StatusNotFound,
+	apperr.OIDCFailed:         http.StatusUnauthorized,
+	apperr.OIDCInvalidState:   http.StatusBadRequest,
+	apperr.OIDCUserDenied:     http.StatusForbidden,
+	apperr.OIDCAlreadyLinked:  http.StatusConflict,
+
+	apperr.IDRequired:            http.StatusBadRequest,
+	apperr.TaskNotFound:          http.StatusNotFound,
+	apperr.TitleRequired:         http.StatusBadRequest,
+	apperr.InvalidDate:           http.StatusBadRequest,
+	apperr.InvalidRepeat:         http.StatusBadRequest,
+	apperr.InvalidStatus:         http.StatusBadRequest,
+	apperr.InvalidTransition:     http.StatusConflict,
+	apperr.TaskBlocked:           http.StatusConflict,
+	apperr.ChecklistItemNotFound: http.StatusNotFound,
+	apperr.InvalidChecklistOrder: http.StatusBadRequest,
+	apperr.DependencyNotFound:    http.StatusNotFound,
+	apperr.InvalidDependency:     http.StatusBadRequest,
+	apperr.AttachmentNotFound:    http.StatusNotFound,
+	apperr.FileTooLarge:          http.StatusRequestEntityTooLarge,
+	apperr.QuotaExceeded:         http.StatusRequestEntityTooLarge,
+	apperr.FieldNotFound:         http.StatusNotFound,
+	apperr.FieldExists:           http.StatusConflict,
+	apperr.InvalidField:          http.StatusBadRequest,
+	apperr.InvalidFieldValue:     http.StatusBadRequest,
+
+	apperr.UserNotFound:    http.StatusNotFound,
+	apperr.UserExists:      http.StatusConflict,
+	apperr.InvalidLogin:    http.StatusBadRequest,
+	apperr.WeakPassword:    http.StatusBadRequest,
+	apperr.ListNotFound:    http.StatusNotFound,
+	apperr.MemberNotFound:  http.StatusNotFound,
+	apperr.InvalidRole:     http.StatusBadRequest,
+	apperr.SessionNotFound: http.StatusNotFound,
+	apperr.TokenNotFound:   http.StatusNotFound,
+	apperr.InvalidScope:    http.StatusBadRequest,
+}
+
+// writeJSONError отправляет ошибку с кодом в формате problem+json, статус определяется по коду
+// Заголовок и поле error переводятся на язык ответа по каталогу сообщений, detail остаётся как есть
+func writeJSONError(w http.ResponseWriter, code apperr.Code, detail string) {
+	status, ok := problemStatus[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	title, message := http.StatusText(status), detail
+	if msg, ok := i18n.Error(responseLang(w), code); ok {
+		title, message = msg, msg
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:      "about:blank",
+		Title:     title,
+		Detail:    detail,
+		Code:      code,
+		Error:     message,
+		RequestID: w.Header().Get(httplog.Header),
+	})
+}
+
+// writeError отправляет ошибку приложения
+// Текст ошибок без кода (ошибки базы, файловой системы) клиенту не показывается, а пишется в журнал.
+// У ошибок с кодом клиент получает только их сообщение, причина пишется в журнал
+func writeError(w http.ResponseWriter, err error) {
+	var e *apperr.Error
+	if !errors.As(err, &e) {
+		slog.Error("internal error", "request_id", w.Header().Get(httplog.Header), "err", err)
+		writeJSONError(w, apperr.Internal, "Internal server error")
+		return
+	}
+	if e.Err != nil {
+		slog.Warn("request failed", "request_id", w.Header().Get(httplog.Header), "code", e.Code, "err", err)
+	}
+	writeJSONError(w, e.Code, e.Message)
+}
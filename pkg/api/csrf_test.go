@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signInCookies входит по паролю и возвращает куки сессии веб-интерфейса
+func signInCookies(t *testing.T, h http.Handler) []*http.Cookie {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return rec.Result().Cookies()
+}
+
+// cookieValue возвращает значение куки по имени
+func cookieValue(cookies []*http.Cookie, name string) string {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestCSRF(t *testing.T) {
+	h := newTestAPI(t, nil)
+	cookies := signInCookies(t, h)
+	csrf := cookieValue(cookies, csrfCookie)
+	require.NotEmpty(t, csrf)
+
+	post := func(headers map[string]string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"date": "20300101", "title": "task"})
+		req := httptest.NewRequest(http.MethodPost, "/api/task", bytes.NewReader(body))
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for name, headers := range map[string]map[string]string{
+		"foreign origin": {"Origin": "https://evil.example"},
+		"cross-site":     {"Sec-Fetch-Site": "cross-site"},
+		"wrong token":    {csrfHeader: "forged"},
+	} {
+		rec := post(headers, cookies)
+		assert.Equal(t, http.StatusForbidden, rec.Code, name)
+		assert.Equal(t, "csrf_failed", decode(t, rec)["code"], name)
+	}
+
+	// Заголовок без куки с CSRF-токеном не принимается
+	var session []*http.Cookie
+	for _, c := range cookies {
+		if c.Name != csrfCookie {
+			session = append(session, c)
+		}
+	}
+	assert.Equal(t, http.StatusForbidden, post(map[string]string{csrfHeader: csrf}, session).Code)
+
+	for name, headers := range map[string]map[string]string{
+		"matching token": {csrfHeader: csrf, "Origin": "https://evil.example"},
+		"same origin":    {"Origin": "http://example.com"},
+		"no browser":     {},
+	} {
+		rec := post(headers, cookies)
+		assert.Equal(t, http.StatusOK, rec.Code, name)
+	}
+
+	// Запросы с заголовком Authorization не зависят от кук и не проверяются
+	token := signIn(t, h, "", testPassword)
+	rec := post(map[string]string{"Authorization": "Bearer " + token, "Origin": "https://evil.example"}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
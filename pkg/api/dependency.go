@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/db"
+)
+
+// DependencyReq структура для запроса добавления зависимости
+type DependencyReq struct {
+	TaskID    string `json:"task_id"`
+	BlockerID string `json:"blocker_id"`
+}
+
+// dependencyHandler обрабатывает добавление и удаление зависимостей между задачами
+func dependencyHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		addDependencyHandler(w, r)
+	case http.MethodDelete:
+		deleteDependencyHandler(w, r)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// addDependencyHandler отмечает задачу как заблокированную другой задачей
+func addDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	var req DependencyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	if id := r.PathValue("task_id"); id != "" {
+		req.TaskID = id
+	}
+	if req.TaskID == "" || req.BlockerID == "" {
+		writeJSONError(w, apperr.IDRequired, "Task ID or blocker ID not specified")
+		return
+	}
+
+	if err := db.AddDependency(currentList(r), req.TaskID, req.BlockerID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
+
+// deleteDependencyHandler снимает блокировку задачи
+func deleteDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := param(r, "task_id")
+	blockerID := param(r, "blocker_id")
+	if taskID == "" || blockerID == "" {
+		writeJSONError(w, apperr.IDRequired, "Task ID or blocker ID not specified")
+		return
+	}
+
+	if err := db.DeleteDependency(currentList(r), taskID, blockerID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/db"
+)
+
+// addDependency отмечает, что задача taskID заблокирована задачей blockerID
+func addDependency(t *testing.T, h http.Handler, token, taskID, blockerID string) *httptest.ResponseRecorder {
+	t.Helper()
+	return request(t, h, http.MethodPost, "/api/task/dependencies", token, DependencyReq{TaskID: taskID, BlockerID: blockerID})
+}
+
+func TestDependencyCycles(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	a := addTask(t, h, token, "a")
+	b := addTask(t, h, token, "b")
+	c := addTask(t, h, token, "c")
+
+	require.Equal(t, http.StatusOK, addDependency(t, h, token, a, b).Code)
+	require.Equal(t, http.StatusOK, addDependency(t, h, token, b, c).Code)
+
+	for _, pair := range [][2]string{{c, a}, {b, a}, {a, a}} {
+		rec := addDependency(t, h, token, pair[0], pair[1])
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "%s blocked by %s", pair[0], pair[1])
+		assert.Equal(t, "invalid_dependency", decode(t, rec)["code"])
+	}
+
+	rec := addDependency(t, h, token, a, "999")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// После снятия зависимости обратная становится допустимой
+	rec = request(t, h, http.MethodDelete, "/api/task/dependencies?task_id="+b+"&blocker_id="+c, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, http.StatusOK, addDependency(t, h, token, c, a).Code)
+}
+
+func TestDoneBlockedTask(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	a := addTask(t, h, token, "a")
+	b := addTask(t, h, token, "b")
+	require.Equal(t, http.StatusOK, addDependency(t, h, token, a, b).Code)
+
+	rec := request(t, h, http.MethodGet, "/api/task?id="+a, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, true, decode(t, rec)["blocked"])
+
+	rec = request(t, h, http.MethodPost, "/api/task/done?id="+a, token, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// Готовые к работе задачи не включают заблокированные
+	rec = request(t, h, http.MethodGet, "/api/tasks?ready=true", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	tasks, _ := decode(t, rec)["tasks"].([]interface{})
+	require.Len(t, tasks, 1)
+	assert.Equal(t, b, tasks[0].(map[string]interface{})["id"])
+
+	rec = request(t, h, http.MethodPost, "/api/task/done?id="+a+"&force=true", token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestDoneBlockerDoesNotBlock(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	a := addTask(t, h, token, "a")
+	b := addRepeatingTask(t, h, token, "b", "d 7")
+	require.Equal(t, http.StatusOK, addDependency(t, h, token, a, b).Code)
+
+	// Перевод в done через статус тоже проверяет блокирующие задачи
+	assert.Equal(t, http.StatusConflict, setStatus(t, h, token, a, db.StatusDone))
+
+	// Блокирующая задача в статусе done больше не мешает, хотя ещё не удалена
+	require.Equal(t, http.StatusOK, setStatus(t, h, token, b, db.StatusDone))
+	rec := request(t, h, http.MethodGet, "/api/task?id="+a, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, decode(t, rec)["blocked"])
+	assert.Equal(t, http.StatusOK, setStatus(t, h, token, a, db.StatusDone))
+}
+
+func TestConcurrentDependenciesNoCycle(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	// Встречные зависимости, добавленные одновременно, не должны образовать цикл
+	for i := 0; i < 10; i++ {
+		a := addTask(t, h, token, "a")
+		b := addTask(t, h, token, "b")
+
+		var wg sync.WaitGroup
+		codes := make([]int, 2)
+		for j, pair := range [][2]string{{a, b}, {b, a}} {
+			wg.Add(1)
+			go func(j int, pair [2]string) {
+				defer wg.Done()
+				codes[j] = addDependency(t, h, token, pair[0], pair[1]).Code
+			}(j, pair)
+		}
+		wg.Wait()
+		assert.False(t, codes[0] == http.StatusOK && codes[1] == http.StatusOK, "both %s and %s blocked", a, b)
+	}
+}
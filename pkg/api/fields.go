@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/db"
+)
+
+// fieldNameRe ограничивает имена полей, чтобы их можно было передать в query string
+var fieldNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// fieldsHandler обрабатывает работу с определениями пользовательских полей.
+// Поля общие для всех пользователей, поэтому читать их может любой, а менять только администратор
+func fieldsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listFieldsHandler(w, r)
+	case http.MethodPost:
+		sessionOnly(adminOnly(addFieldHandler))(w, r)
+	case http.MethodDelete:
+		sessionOnly(adminOnly(deleteFieldHandler))(w, r)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// listFieldsHandler возвращает все пользовательские поля
+func listFieldsHandler(w http.ResponseWriter, r *http.Request) {
+	fields, err := db.Fields()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"fields": fields}, http.StatusOK)
+}
+
+// addFieldHandler создаёт новое пользовательское поле
+func addFieldHandler(w http.ResponseWriter, r *http.Request) {
+	var field db.Field
+	if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	if !fieldNameRe.MatchString(field.Name) {
+		writeJSONError(w, apperr.InvalidField, "Invalid field name: "+field.Name)
+		return
+	}
+
+	switch field.Type {
+	case db.FieldText, db.FieldNumber, db.FieldDate:
+		field.Options = nil
+	case db.FieldSelect:
+		if len(field.Options) == 0 {
+			writeJSONError(w, apperr.InvalidField, "Select field requires options")
+			return
+		}
+	default:
+		writeJSONError(w, apperr.InvalidField, "Unsupported field type: "+field.Type)
+		return
+	}
+
+	id, err := db.AddField(&field)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"id": id}, http.StatusOK)
+}
+
+// deleteFieldHandler удаляет пользовательское поле и его значения
+func deleteFieldHandler(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		writeJSONError(w, apperr.IDRequired, "ID not specified")
+		return
+	}
+
+	if err := db.DeleteField(id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
+
+// normalizeFieldValues проверяет значения полей по их определениям и приводит к каноническому виду
+// Если checkRequired равен true, все обязательные поля должны быть заполнены
+func normalizeFieldValues(values map[string]string, checkRequired bool) (map[string]string, error) {
+	fields, err := db.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make(map[string]*db.Field, len(fields))
+	for _, field := range fields {
+		defs[field.Name] = field
+	}
+
+	result := make(map[string]string, len(values))
+	for name, value := range values {
+		field, ok := defs[name]
+		if !ok {
+			return nil, apperr.Errorf(apperr.InvalidField, "unknown field: %s", name)
+		}
+		if value == "" {
+			continue
+		}
+
+		switch field.Type {
+		case db.FieldNumber:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, apperr.Errorf(apperr.InvalidFieldValue, "field %s must be a number", name)
+			}
+			value = strconv.FormatFloat(n, 'f', -1, 64)
+		case db.FieldDate:
+			if _, err := time.Parse(DateFormat, value); err != nil {
+				return nil, apperr.Errorf(apperr.InvalidFieldValue, "field %s must be a date in format %s", name, DateFormat)
+			}
+		case db.FieldSelect:
+			if !containsString(field.Options, value) {
+				return nil, apperr.Errorf(apperr.InvalidFieldValue, "field %s has invalid option: %s", name, value)
+			}
+		}
+		result[name] = value
+	}
+
+	if checkRequired {
+		for _, field := range fields {
+			if _, ok := result[field.Name]; field.Required && !ok {
+				return nil, apperr.Errorf(apperr.InvalidFieldValue, "field %s is required", field.Name)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// containsString проверяет, есть ли строка в списке
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/db"
+)
+
+// addField создаёт пользовательское поле и возвращает его ID
+func addField(t *testing.T, h http.Handler, token string, field db.Field) string {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/fields", token, field)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return fmt.Sprint(decode(t, rec)["id"])
+}
+
+// addTaskWithFields создаёт задачу со значениями пользовательских полей
+func addTaskWithFields(t *testing.T, h http.Handler, token, title string, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return request(t, h, http.MethodPost, "/api/task", token,
+		map[string]interface{}{"date": "20300101", "title": title, "fields": fields})
+}
+
+func TestFieldDefinitions(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	for _, field := range []db.Field{
+		{Name: "Estimate", Type: db.FieldNumber},
+		{Name: "estimate", Type: "money"},
+		{Name: "priority", Type: db.FieldSelect},
+	} {
+		rec := request(t, h, http.MethodPost, "/api/fields", token, field)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, field.Name)
+	}
+
+	id := addField(t, h, token, db.Field{Name: "estimate", Type: db.FieldNumber})
+	rec := request(t, h, http.MethodPost, "/api/fields", token, db.Field{Name: "estimate", Type: db.FieldText})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "field_exists", decode(t, rec)["code"])
+
+	rec = request(t, h, http.MethodGet, "/api/fields", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Len(t, decode(t, rec)["fields"], 1)
+
+	rec = request(t, h, http.MethodDelete, "/api/fields?id="+id, token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = request(t, h, http.MethodDelete, "/api/fields?id="+id, token, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestFieldValues(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	addField(t, h, token, db.Field{Name: "estimate", Type: db.FieldNumber})
+	addField(t, h, token, db.Field{Name: "priority", Type: db.FieldSelect, Options: []string{"high", "low"}, Required: true})
+
+	for _, fields := range []map[string]string{
+		{"estimate": "1"},
+		{"priority": "urgent"},
+		{"priority": "high", "estimate": "soon"},
+		{"priority": "high", "owner": "me"},
+	} {
+		rec := addTaskWithFields(t, h, token, "invalid", fields)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, fields)
+	}
+
+	rec := addTaskWithFields(t, h, token, "high", map[string]string{"priority": "high", "estimate": "1.50"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	id := fmt.Sprint(decode(t, rec)["id"])
+	rec = addTaskWithFields(t, h, token, "low", map[string]string{"priority": "low"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Числа хранятся в каноническом виде
+	rec = request(t, h, http.MethodGet, "/api/task?id="+id, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, map[string]interface{}{"priority": "high", "estimate": "1.5"}, decode(t, rec)["fields"])
+
+	rec = request(t, h, http.MethodGet, "/api/tasks?field.priority=high", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	tasks := decode(t, rec)["tasks"].([]interface{})
+	require.Len(t, tasks, 1)
+	assert.Equal(t, id, fmt.Sprint(tasks[0].(map[string]interface{})["id"]))
+
+	rec = request(t, h, http.MethodGet, "/api/tasks?field.owner=me", token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestFieldsAdminOnly(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "bob", "secret123")
+	bob := signIn(t, h, "bob", "secret123")
+
+	field := db.Field{Name: "priority", Type: db.FieldText}
+	rec := request(t, h, http.MethodPost, "/api/fields", bob, field)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "admin_required", decode(t, rec)["code"])
+
+	rec = request(t, h, http.MethodPost, "/api/fields", admin, field)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	id := fmt.Sprint(decode(t, rec)["id"])
+
+	// Читать определения полей может любой пользователь
+	rec = request(t, h, http.MethodGet, "/api/fields", bob, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, decode(t, rec)["fields"], 1)
+
+	assert.Equal(t, http.StatusForbidden, request(t, h, http.MethodDelete, "/api/v1/fields/"+id, bob, nil).Code)
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodDelete, "/api/v1/fields/"+id, admin, nil).Code)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/i18n"
+)
+
+// localize выбирает язык ответа по заголовку Accept-Language
+// Выбранный язык передаётся в заголовке Content-Language, по нему writeJSONError переводит ошибки
+func localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Language", string(i18n.Negotiate(r.Header.Get("Accept-Language"))))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// responseLang возвращает язык, выбранный для ответа
+func responseLang(w http.ResponseWriter) i18n.Lang {
+	if lang := w.Header().Get("Content-Language"); lang != "" {
+		return i18n.Lang(lang)
+	}
+	return i18n.Default
+}
+
+// messagesHandler отдаёт строки веб-интерфейса на языке пользователя
+func messagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	lang := responseLang(w)
+	writeJSONSuccess(w, map[string]interface{}{
+		"lang":     lang,
+		"messages": i18n.UI(lang),
+	}, http.StatusOK)
+}
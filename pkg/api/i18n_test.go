@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/i18n"
+)
+
+// requestLang выполняет запрос без авторизации с заголовком Accept-Language
+func requestLang(h http.Handler, method, path, acceptLanguage string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMessagesLanguage(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	for header, lang := range map[string]i18n.Lang{"": i18n.RU, "en-US,en;q=0.9": i18n.EN, "de": i18n.RU} {
+		rec := requestLang(h, http.MethodGet, "/api/v1/messages", header)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, string(lang), rec.Header().Get("Content-Language"), header)
+		assert.Contains(t, rec.Header().Values("Vary"), "Accept-Language")
+
+		resp := decode(t, rec)
+		assert.Equal(t, string(lang), resp["lang"])
+		messages, _ := resp["messages"].(map[string]interface{})
+		assert.Equal(t, i18n.UI(lang)["title"], messages["title"])
+	}
+}
+
+func TestErrorLanguage(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	for _, lang := range []i18n.Lang{i18n.RU, i18n.EN} {
+		rec := requestLang(h, http.MethodGet, "/api/v1/tasks", string(lang))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, string(lang), rec.Header().Get("Content-Language"))
+
+		resp := decode(t, rec)
+		msg, _ := i18n.Error(lang, apperr.Code(resp["code"].(string)))
+		assert.NotEmpty(t, msg)
+		assert.Equal(t, msg, resp["error"])
+	}
+}
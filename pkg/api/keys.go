@@ -0,0 +1,112 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/auth"
+	"go1f/pkg/db"
+)
+
+// initSigningKeys загружает ключи подписи токенов, при первом запуске создаёт ключ
+func initSigningKeys() error {
+	keys, err := db.SigningKeys()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return rotateSigningKey()
+	}
+	return loadSigningKeys(keys)
+}
+
+// loadSigningKeys передаёт ключи из базы в пакет auth
+func loadSigningKeys(keys []*db.SigningKey) error {
+	list := make([]auth.SigningKey, 0, len(keys))
+	for _, k := range keys {
+		secret, err := hex.DecodeString(k.Secret)
+		if err != nil {
+			return fmt.Errorf("invalid signing key %s: %w", k.ID, err)
+		}
+
+		key := auth.SigningKey{ID: k.ID, Secret: secret}
+		if k.RetiredAt != "" {
+			if key.RetiredAt, err = time.Parse(time.RFC3339, k.RetiredAt); err != nil {
+				return fmt.Errorf("invalid signing key %s: %w", k.ID, err)
+			}
+		}
+		list = append(list, key)
+	}
+
+	auth.SetSigningKeys(list)
+	return nil
+}
+
+// rotateSigningKey создаёт новый ключ подписи, прежние остаются для проверки до истечения токенов
+func rotateSigningKey() error {
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	key := &db.SigningKey{
+		ID:        hex.EncodeToString(id),
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	if err := db.RotateSigningKey(key, now.Add(-auth.AccessTokenTTL).Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	keys, err := db.SigningKeys()
+	if err != nil {
+		return err
+	}
+	return loadSigningKeys(keys)
+}
+
+// keysHandler возвращает список ключей подписи без секретов
+func keysHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	keys, err := db.SigningKeys()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"keys": keys}, http.StatusOK)
+}
+
+// rotateKeysHandler выполняет ротацию ключей подписи
+func rotateKeysHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	if err := rotateSigningKey(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/auth"
+)
+
+// tokenKeyID возвращает идентификатор ключа, которым подписан access-токен
+func tokenKeyID(t *testing.T, token string) string {
+	t.Helper()
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+	require.NoError(t, err)
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	h := newTestAPI(t, nil)
+	before := signIn(t, h, "", testPassword)
+
+	rec := request(t, h, http.MethodPost, "/api/keys/rotate", before, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = request(t, h, http.MethodGet, "/api/keys", before, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	keys := decode(t, rec)["keys"].([]interface{})
+	require.Len(t, keys, 2)
+	current := keys[0].(map[string]interface{})
+	retired := keys[1].(map[string]interface{})
+	assert.Nil(t, current["retired_at"])
+	assert.NotEmpty(t, retired["retired_at"])
+
+	// Токены, подписанные прежним ключом, действуют до истечения, новые подписываются новым ключом
+	assert.Equal(t, retired["kid"], tokenKeyID(t, before))
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", before, nil).Code)
+
+	after := signIn(t, h, "", testPassword)
+	assert.Equal(t, current["kid"], tokenKeyID(t, after))
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", after, nil).Code)
+}
+
+func TestSigningKeysAdminOnly(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "alice", "alice-password")
+	alice := signIn(t, h, "alice", "alice-password")
+
+	assert.Equal(t, http.StatusForbidden, request(t, h, http.MethodGet, "/api/keys", alice, nil).Code)
+	assert.Equal(t, http.StatusForbidden, request(t, h, http.MethodPost, "/api/keys/rotate", alice, nil).Code)
+}
+
+func TestTokenWithUnknownKey(t *testing.T) {
+	h := newTestAPI(t, nil)
+	valid := signIn(t, h, "", testPassword)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(valid, &auth.Claims{})
+	require.NoError(t, err)
+	claims := parsed.Claims.(*auth.Claims)
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = tokenKeyID(t, valid)
+	token, err := forged.SignedString([]byte("not the server key"))
+	require.NoError(t, err)
+
+	rec := request(t, h, http.MethodGet, "/api/tasks", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
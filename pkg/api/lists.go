@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/db"
+)
+
+// ListMemberReq структура для запроса добавления участника в список задач
+type ListMemberReq struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// listAccess определяет список задач запроса по параметру list (по умолчанию собственный)
+// и роль пользователя в нём. Зрителям разрешены только запросы на чтение
+func listAccess(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := currentUser(r)
+		list := param(r, "list")
+		if list == "" {
+			list = user
+		}
+
+		// Роль читается из базы при каждом запросе, чтобы изменение или отзыв доступа действовали сразу
+		role := db.RoleOwner
+		if list != user {
+			var err error
+			if role, err = db.ListRole(list, user); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+
+		switch role {
+		case db.RoleOwner, db.RoleEditor:
+		case db.RoleViewer:
+			if !readOnlyMethod(r.Method) {
+				writeJSONError(w, apperr.Forbidden, "Viewers cannot modify tasks")
+				return
+			}
+		default:
+			writeJSONError(w, apperr.ListNotFound, "Task list not found")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), listKey, list)
+		ctx = context.WithValue(ctx, roleKey, role)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// currentList возвращает ID списка задач, с которым работает запрос
+func currentList(r *http.Request) string {
+	id, _ := r.Context().Value(listKey).(string)
+	return id
+}
+
+// currentRole возвращает роль пользователя в списке задач запроса
+func currentRole(r *http.Request) string {
+	role, _ := r.Context().Value(roleKey).(string)
+	return role
+}
+
+// listsHandler возвращает списки задач, доступные пользователю
+func listsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	lists, err := db.TaskLists(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"lists": lists}, http.StatusOK)
+}
+
+// listMembersHandler показывает участников списка задач, владелец может их добавлять и удалять
+func listMembersHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		members, err := db.ListMembers(currentList(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSONSuccess(w, map[string]interface{}{"members": members}, http.StatusOK)
+	case http.MethodPost:
+		if currentRole(r) != db.RoleOwner {
+			writeJSONError(w, apperr.Forbidden, "Only the list owner can manage members")
+			return
+		}
+		addListMemberHandler(w, r)
+	case http.MethodDelete:
+		if currentRole(r) != db.RoleOwner {
+			writeJSONError(w, apperr.Forbidden, "Only the list owner can manage members")
+			return
+		}
+		user := param(r, "user")
+		if user == "" {
+			writeJSONError(w, apperr.BadRequest, "User not specified")
+			return
+		}
+		if err := db.DeleteListMember(currentList(r), user); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// addListMemberHandler открывает список задач пользователю с ролью editor или viewer
+func addListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	var req ListMemberReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	if req.Role != db.RoleEditor && req.Role != db.RoleViewer {
+		writeJSONError(w, apperr.InvalidRole, "Role must be editor or viewer")
+		return
+	}
+
+	user, err := db.GetUserByLogin(req.Login)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if user.ID == currentList(r) {
+		writeJSONError(w, apperr.BadRequest, "The owner is already a member of the list")
+		return
+	}
+
+	if err := db.SetListMember(currentList(r), user.ID, req.Role); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/db"
+)
+
+func TestSharedListRoles(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "bob", "bob-password")
+	addUser(t, h, admin, "carol", "carol-password")
+	addUser(t, h, admin, "dave", "dave-password")
+
+	for login, role := range map[string]string{"bob": db.RoleEditor, "carol": db.RoleViewer} {
+		rec := request(t, h, http.MethodPost, "/api/lists/members", admin, ListMemberReq{Login: login, Role: role})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	rec := request(t, h, http.MethodPost, "/api/lists/members", admin, ListMemberReq{Login: "dave", Role: db.RoleOwner})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	bob := signIn(t, h, "bob", "bob-password")
+	carol := signIn(t, h, "carol", "carol-password")
+	dave := signIn(t, h, "dave", "dave-password")
+	task := map[string]string{"date": "20300101", "title": "shared"}
+
+	// Редактор добавляет задачи в чужой список
+	rec = request(t, h, http.MethodPost, "/api/task?list="+db.AdminID, bob, task)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Зритель только читает
+	rec = request(t, h, http.MethodGet, "/api/tasks?list="+db.AdminID, carol, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Len(t, decode(t, rec)["tasks"], 1)
+	rec = request(t, h, http.MethodPost, "/api/task?list="+db.AdminID, carol, task)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Участниками управляет только владелец, для остальных список не существует
+	rec = request(t, h, http.MethodPost, "/api/lists/members?list="+db.AdminID, bob, ListMemberReq{Login: "dave", Role: db.RoleViewer})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = request(t, h, http.MethodGet, "/api/tasks?list="+db.AdminID, dave, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request(t, h, http.MethodGet, "/api/lists", bob, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Len(t, decode(t, rec)["lists"], 2)
+}
+
+func TestListRoleChangesApplyImmediately(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "bob", "secret123")
+	bob := signIn(t, h, "bob", "secret123")
+
+	share := func(role string) {
+		rec := request(t, h, http.MethodPost, "/api/lists/members", admin, ListMemberReq{Login: "bob", Role: role})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	addToList := func() int {
+		body := map[string]string{"date": "20300101", "title": "shared"}
+		return request(t, h, http.MethodPost, "/api/task?list="+db.AdminID, bob, body).Code
+	}
+
+	share(db.RoleEditor)
+	assert.Equal(t, http.StatusOK, addToList())
+
+	// Тот же access-токен сразу теряет право на изменение
+	share(db.RoleViewer)
+	assert.Equal(t, http.StatusForbidden, addToList())
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks?list="+db.AdminID, bob, nil).Code)
+
+	user, err := db.GetUserByLogin("bob")
+	require.NoError(t, err)
+	rec := request(t, h, http.MethodDelete, "/api/v1/lists/"+db.AdminID+"/members/"+user.ID, admin, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, request(t, h, http.MethodGet, "/api/tasks?list="+db.AdminID, bob, nil).Code)
+}
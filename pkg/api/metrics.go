@@ -0,0 +1,20 @@
+package api
+
+import "go1f/pkg/metrics"
+
+// Метрики приложения, запросы и пул соединений учитываются в пакете metrics
+var (
+	signIns = metrics.NewCounterVec("todo_signins_total",
+		"Sign-in attempts by method (password, totp, oidc) and result (success, failure).", "method", "result")
+	tasksCreated   = metrics.NewCounterVec("todo_tasks_created_total", "Tasks created.")
+	tasksCompleted = metrics.NewCounterVec("todo_tasks_completed_total", "Tasks marked as done.")
+)
+
+// countSignIn учитывает попытку входа
+func countSignIn(method string, ok bool) {
+	result := "failure"
+	if ok {
+		result = "success"
+	}
+	signIns.Inc(method, result)
+}
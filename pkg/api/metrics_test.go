@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/db"
+	"go1f/pkg/metrics"
+)
+
+var completedRe = regexp.MustCompile(`(?m)^todo_tasks_completed_total (\S+)$`)
+
+// completedTasks возвращает значение счётчика выполненных задач
+func completedTasks(t *testing.T) float64 {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	m := completedRe.FindStringSubmatch(rec.Body.String())
+	if m == nil {
+		return 0
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	require.NoError(t, err)
+	return v
+}
+
+func TestTasksCompletedCountedOnce(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	before := completedTasks(t)
+
+	// Переход в done через статус и последующая отметка выполнения учитываются один раз
+	id := addTask(t, h, token, "status")
+	require.Equal(t, http.StatusOK, setStatus(t, h, token, id, db.StatusDone))
+	assert.Equal(t, before+1, completedTasks(t))
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/api/task/done?id="+id, token, nil).Code)
+	assert.Equal(t, before+1, completedTasks(t))
+
+	id = addRepeatingTask(t, h, token, "repeat", "d 7")
+	require.Equal(t, http.StatusOK, setStatus(t, h, token, id, db.StatusDone))
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/api/task/done?id="+id, token, nil).Code)
+	assert.Equal(t, before+2, completedTasks(t))
+
+	// Повторение начинается в todo, и его выполнение учитывается снова
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/api/task/done?id="+id, token, nil).Code)
+	assert.Equal(t, before+3, completedTasks(t))
+
+	// Отметка выполнения задачи не в статусе done учитывается сразу
+	id = addTask(t, h, token, "done")
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/api/task/done?id="+id, token, nil).Code)
+	assert.Equal(t, before+4, completedTasks(t))
+}
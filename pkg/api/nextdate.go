@@ -1,11 +1,11 @@
 package api
 
 import (
-	"errors"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
+
+	"go1f/pkg/apperr"
 )
 
 // afterNow сравнивает только по дате, игнорируя время
@@ -16,17 +16,17 @@ func afterNow(date, now time.Time) bool {
 // NextDate вычисляет следующую дату задачи по правилу repeat
 func NextDate(now time.Time, dstart string, repeat string) (string, error) {
 	if repeat == "" {
-		return "", errors.New("empty repeat rule")
+		return "", apperr.New(apperr.InvalidRepeat, "empty repeat rule")
 	}
 
 	startDate, err := time.Parse(DateFormat, dstart)
 	if err != nil {
-		return "", fmt.Errorf("invalid date format: %s", dstart)
+		return "", apperr.Errorf(apperr.InvalidDate, "invalid date format: %s", dstart)
 	}
 
 	parts := strings.Split(repeat, " ")
 	if len(parts) == 0 {
-		return "", errors.New("invalid rule format")
+		return "", apperr.New(apperr.InvalidRepeat, "invalid rule format")
 	}
 
 	ruleType := parts[0]
@@ -39,14 +39,14 @@ func NextDate(now time.Time, dstart string, repeat string) (string, error) {
 	switch ruleType {
 	case "d":
 		if len(parts) != 2 {
-			return "", errors.New("invalid d rule format: expected d <number>")
+			return "", apperr.New(apperr.InvalidRepeat, "invalid d rule format: expected d <number>")
 		}
 		interval, err := strconv.Atoi(parts[1])
 		if err != nil {
-			return "", errors.New("interval must be a number")
+			return "", apperr.New(apperr.InvalidRepeat, "interval must be a number")
 		}
 		if interval <= 0 || interval > MaxDayInterval {
-			return "", errors.New("interval out of range")
+			return "", apperr.New(apperr.InvalidRepeat, "interval out of range")
 		}
 
 		date := startDate
@@ -67,6 +67,6 @@ func NextDate(now time.Time, dstart string, repeat string) (string, error) {
 		}
 
 	default:
-		return "", errors.New("unsupported rule format")
+		return "", apperr.New(apperr.InvalidRepeat, "unsupported rule format")
 	}
 }
\ No newline at end of file
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/auth"
+	"go1f/pkg/db"
+)
+
+// oidcStateCookie имя куки, привязывающей вход через провайдера к браузеру
+const oidcStateCookie = "oidc_state"
+
+// oidcLoginTTL время, за которое нужно вернуться от провайдера
+const oidcLoginTTL = 10 * time.Minute
+
+var (
+	oidcProvider *auth.OIDCProvider // nil, если вход через провайдера не настроен
+
+	oidcPending   = make(map[string]pendingOIDC) // Начатые входы по значению state
+	oidcPendingMu sync.Mutex
+)
+
+// pendingOIDC вход через провайдера, ожидающий возврата на callback
+type pendingOIDC struct {
+	state   auth.OIDCState
+	linkTo  string // ID пользователя, к которому привязывается учётная запись; пусто при обычном входе
+	expires time.Time
+}
+
+// initOIDC подключается к провайдеру, если он указан в настройках
+func initOIDC() error {
+	if cfg.OIDC.Issuer == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := auth.NewOIDCProvider(ctx, auth.OIDCConfig{
+		Issuer:       cfg.OIDC.Issuer,
+		ClientID:     cfg.OIDC.ClientID,
+		ClientSecret: cfg.OIDC.ClientSecret,
+		RedirectURL:  cfg.OIDC.RedirectURL,
+	})
+	if err != nil {
+		return err
+	}
+	oidcProvider = provider
+	return nil
+}
+
+// savePendingOIDC запоминает начатый вход и забывает просроченные
+func savePendingOIDC(s auth.OIDCState, linkTo string) {
+	oidcPendingMu.Lock()
+	defer oidcPendingMu.Unlock()
+
+	now := time.Now()
+	for key, p := range oidcPending {
+		if now.After(p.expires) {
+			delete(oidcPending, key)
+		}
+	}
+	oidcPending[s.State] = pendingOIDC{state: s, linkTo: linkTo, expires: now.Add(oidcLoginTTL)}
+}
+
+// takePendingOIDC возвращает начатый вход по state, каждый state принимается один раз
+func takePendingOIDC(state string) (pendingOIDC, bool) {
+	oidcPendingMu.Lock()
+	defer oidcPendingMu.Unlock()
+
+	p, ok := oidcPending[state]
+	delete(oidcPending, state)
+	if !ok || time.Now().After(p.expires) {
+		return pendingOIDC{}, false
+	}
+	return p, true
+}
+
+// oidcUser находит или создаёт локального пользователя для учётной записи провайдера.
+// Существующий локальный пользователь по email не связывается: иначе учётная запись провайдера
+// с чужим адресом открывала бы вход без пароля и второго фактора
+func oidcUser(identity *auth.OIDCIdentity) (*db.User, error) {
+	if user, err := db.GetUserByOIDC(identity.Issuer, identity.Subject); err == nil {
+		return user, nil
+	}
+
+	if !cfg.OIDC.Signup {
+		return nil, apperr.New(apperr.OIDCUserDenied, "no local user is linked to this provider account")
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, apperr.New(apperr.OIDCUserDenied, "provider did not return a verified email")
+	}
+	if !loginRe.MatchString(identity.Email) {
+		return nil, apperr.Errorf(apperr.OIDCUserDenied, "email cannot be used as login: %s", identity.Email)
+	}
+	if _, err := db.GetUserByLogin(identity.Email); err == nil {
+		return nil, apperr.Errorf(apperr.OIDCUserDenied, "local user %s already exists", identity.Email)
+	}
+
+	// Пользователь без пароля входит только через провайдера
+	id, err := db.AddUser(&db.User{Login: identity.Email})
+	if err != nil {
+		return nil, err
+	}
+	user, err := db.GetUser(strconv.FormatInt(id, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.LinkOIDC(identity.Issuer, identity.Subject, user.ID, identity.Email); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// linkOIDCUser привязывает учётную запись провайдера к пользователю, начавшему привязку из своей сессии.
+// Так существующие локальные пользователи получают вход через провайдера без TODO_OIDC_SIGNUP
+func linkOIDCUser(identity *auth.OIDCIdentity, userID string) (*db.User, error) {
+	if linked, err := db.GetUserByOIDC(identity.Issuer, identity.Subject); err == nil {
+		if linked.ID == userID {
+			return linked, nil
+		}
+		return nil, apperr.New(apperr.OIDCAlreadyLinked, "provider account is already linked to another user")
+	}
+
+	user, err := db.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.LinkOIDC(identity.Issuer, identity.Subject, user.ID, identity.Email); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// oidcHandler сообщает веб-интерфейсу, доступен ли вход через провайдера
+func oidcHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+	writeJSONSuccess(w, map[string]interface{}{"enabled": oidcProvider != nil}, http.StatusOK)
+}
+
+// oidcLoginHandler перенаправляет на страницу входа провайдера
+func oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+	if oidcProvider == nil {
+		writeJSONError(w, apperr.OIDCDisabled, "OIDC login is not configured")
+		return
+	}
+	redirectToOIDC(w, r, "")
+}
+
+// oidcLinkHandler начинает привязку учётной записи провайдера к пользователю текущей сессии.
+// После возврата от провайдера callback связывает учётные записи и продолжает ту же сессию
+func oidcLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+	if oidcProvider == nil {
+		writeJSONError(w, apperr.OIDCDisabled, "OIDC login is not configured")
+		return
+	}
+	redirectToOIDC(w, r, currentUser(r))
+}
+
+// redirectToOIDC запоминает начатый вход или привязку и перенаправляет на страницу провайдера
+func redirectToOIDC(w http.ResponseWriter, r *http.Request, linkTo string) {
+	state, err := auth.NewOIDCState()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	savePendingOIDC(state, linkTo)
+
+	// Lax, чтобы кука пришла при возврате от провайдера; путь /api покрывает и callback в /api/v1
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state.State,
+		Path:     "/api",
+		MaxAge:   int(oidcLoginTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   cfg.TLS.Enabled(),
+	})
+	http.Redirect(w, r, oidcProvider.AuthCodeURL(state), http.StatusFound)
+}
+
+// oidcCallbackHandler завершает вход через провайдера и выдаёт собственные токены приложения
+func oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+	if oidcProvider == nil {
+		writeJSONError(w, apperr.OIDCDisabled, "OIDC login is not configured")
+		return
+	}
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		countSignIn("oidc", false)
+		writeJSONError(w, apperr.OIDCFailed, "OIDC provider error: "+errCode)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || cookie.Value != query.Get("state") {
+		writeJSONError(w, apperr.OIDCInvalidState, "Invalid OIDC state")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/api", MaxAge: -1, HttpOnly: true})
+
+	pending, ok := takePendingOIDC(cookie.Value)
+	if !ok {
+		writeJSONError(w, apperr.OIDCInvalidState, "OIDC login expired, try again")
+		return
+	}
+
+	identity, err := oidcProvider.Exchange(r.Context(), query.Get("code"), pending.state)
+	if err != nil {
+		countSignIn("oidc", false)
+		writeError(w, apperr.Wrap(apperr.OIDCFailed, "OIDC login failed", err))
+		return
+	}
+
+	// Привязка продолжает сессию, в которой она начата, новая сессия не выдаётся
+	if pending.linkTo != "" {
+		if _, err := linkOIDCUser(identity, pending.linkTo); err != nil {
+			writeError(w, err)
+			return
+		}
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	user, err := oidcUser(identity)
+	if err != nil {
+		countSignIn("oidc", false)
+		writeError(w, err)
+		return
+	}
+
+	// Сессия выдаётся в куках, веб-интерфейс продолжает работу с главной страницы
+	if _, err := startSession(w, r, user); err != nil {
+		writeJSONError(w, apperr.Internal, "Token generation failed")
+		return
+	}
+	countSignIn("oidc", true)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
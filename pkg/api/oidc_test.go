@@ -0,0 +1,328 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+)
+
+// mockIssuer минимальный провайдер OpenID Connect: discovery, JWKS и выдача токенов по коду
+type mockIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockGrant
+}
+
+// mockGrant код авторизации, выданный провайдером
+type mockGrant struct {
+	subject   string
+	email     string
+	nonce     string
+	challenge string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockIssuer{key: key, codes: make(map[string]mockGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("GET /keys", m.keys)
+	mux.HandleFunc("POST /token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                m.URL,
+		"authorization_endpoint":                m.URL + "/authorize",
+		"token_endpoint":                        m.URL + "/token",
+		"jwks_uri":                              m.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockIssuer) keys(w http.ResponseWriter, r *http.Request) {
+	enc := base64.RawURLEncoding
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   enc.EncodeToString(m.key.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token обменивает код на ID-токен, проверяя секрет PKCE
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	grant, ok := m.codes[r.FormValue("code")]
+	delete(m.codes, r.FormValue("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            m.URL,
+		"sub":            grant.subject,
+		"aud":            "todo",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+		"nonce":          grant.nonce,
+		"email":          grant.email,
+		"email_verified": true,
+	})
+	idToken.Header["kid"] = "test"
+	raw, err := idToken.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     raw,
+	})
+}
+
+// authorize имитирует вход пользователя у провайдера по адресу, на который перенаправило приложение
+// Возвращает код авторизации и state для callback
+func (m *mockIssuer) authorize(t *testing.T, location, subject, email string) (code, state string) {
+	t.Helper()
+
+	u, err := url.Parse(location)
+	require.NoError(t, err)
+	q := u.Query()
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+	require.NotEmpty(t, q.Get("code_challenge"))
+
+	code = "code-" + subject
+	m.mu.Lock()
+	m.codes[code] = mockGrant{subject: subject, email: email, nonce: q.Get("nonce"), challenge: q.Get("code_challenge")}
+	m.mu.Unlock()
+	return code, q.Get("state")
+}
+
+// newOIDCTestAPI поднимает API с входом через mock-провайдер
+func newOIDCTestAPI(t *testing.T, signup bool) (http.Handler, *mockIssuer) {
+	t.Helper()
+
+	issuer := newMockIssuer(t)
+	h := newTestAPI(t, func(c *config.Config) {
+		c.OIDC = config.OIDC{
+			Issuer:      issuer.URL,
+			ClientID:    "todo",
+			RedirectURL: "http://localhost:7540/api/v1/oidc/callback",
+			Signup:      signup,
+		}
+	})
+	return h, issuer
+}
+
+// startOIDCLogin начинает вход и возвращает куку state и адрес провайдера
+func startOIDCLogin(t *testing.T, h http.Handler) (*http.Cookie, string) {
+	t.Helper()
+	return startOIDC(t, h, "/api/v1/oidc/login", "")
+}
+
+// startOIDC переходит по адресу path, который перенаправляет к провайдеру,
+// и возвращает куку state и адрес провайдера
+func startOIDC(t *testing.T, h http.Handler, path, token string) (*http.Cookie, string) {
+	t.Helper()
+
+	rec := request(t, h, http.MethodGet, path, token, nil)
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+
+	var stateCookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == oidcStateCookie {
+			stateCookie = c
+		}
+	}
+	require.NotNil(t, stateCookie)
+	// Кука должна приходить и на /api/oidc/callback, и на /api/v1/oidc/callback
+	assert.Equal(t, "/api", stateCookie.Path)
+	return stateCookie, rec.Header().Get("Location")
+}
+
+// oidcCallback возвращается от провайдера с кодом и state
+func oidcCallback(t *testing.T, h http.Handler, stateCookie *http.Cookie, code, state string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/v1/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOIDCLogin(t *testing.T) {
+	h, issuer := newOIDCTestAPI(t, true)
+
+	for i := 0; i < 2; i++ {
+		stateCookie, location := startOIDCLogin(t, h)
+		code, state := issuer.authorize(t, location, "user-1", "alice@example.com")
+
+		rec := oidcCallback(t, h, stateCookie, code, state)
+		require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+		assert.Equal(t, "/", rec.Header().Get("Location"))
+
+		var names []string
+		for _, c := range rec.Result().Cookies() {
+			names = append(names, c.Name)
+		}
+		assert.Contains(t, names, refreshCookie)
+	}
+
+	// Повторный вход того же пользователя провайдера не создаёт второго локального
+	user, err := db.GetUserByOIDC(issuer.URL, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", user.Login)
+}
+
+func TestOIDCStateMismatch(t *testing.T) {
+	h, issuer := newOIDCTestAPI(t, true)
+
+	stateCookie, location := startOIDCLogin(t, h)
+	code, _ := issuer.authorize(t, location, "user-1", "alice@example.com")
+
+	rec := oidcCallback(t, h, stateCookie, code, "forged")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "oidc_invalid_state", decode(t, rec)["code"])
+
+	// Без куки state тоже не принимается
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/oidc/callback?code="+code+"&state="+stateCookie.Value, nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestOIDCPKCE(t *testing.T) {
+	h, issuer := newOIDCTestAPI(t, true)
+
+	stateCookie, location := startOIDCLogin(t, h)
+	code, state := issuer.authorize(t, location, "user-1", "alice@example.com")
+
+	// Код, перехваченный для другого входа, не обменивается без его секрета PKCE
+	issuer.mu.Lock()
+	grant := issuer.codes[code]
+	grant.challenge = "intercepted"
+	issuer.codes[code] = grant
+	issuer.mu.Unlock()
+
+	rec := oidcCallback(t, h, stateCookie, code, state)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "oidc_failed", decode(t, rec)["code"])
+}
+
+func TestOIDCDoesNotLinkByEmail(t *testing.T) {
+	h, issuer := newOIDCTestAPI(t, true)
+
+	_, err := db.AddUser(&db.User{Login: "bob@example.com"})
+	require.NoError(t, err)
+
+	stateCookie, location := startOIDCLogin(t, h)
+	code, state := issuer.authorize(t, location, "attacker", "bob@example.com")
+
+	rec := oidcCallback(t, h, stateCookie, code, state)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "oidc_user_denied", decode(t, rec)["code"])
+
+	_, err = db.GetUserByOIDC(issuer.URL, "attacker")
+	assert.Error(t, err)
+}
+
+func TestOIDCWithoutSignup(t *testing.T) {
+	h, issuer := newOIDCTestAPI(t, false)
+
+	stateCookie, location := startOIDCLogin(t, h)
+	code, state := issuer.authorize(t, location, "user-1", "alice@example.com")
+
+	rec := oidcCallback(t, h, stateCookie, code, state)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestOIDCLinkExistingUser(t *testing.T) {
+	h, issuer := newOIDCTestAPI(t, false)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "bob", "bob-password")
+	addUser(t, h, admin, "carol", "carol-password")
+	bob := signIn(t, h, "bob", "bob-password")
+
+	assert.Equal(t, http.StatusUnauthorized, request(t, h, http.MethodGet, "/api/v1/oidc/link", "", nil).Code)
+
+	// Привязка начинается из сессии пользователя и не выдаёт новую сессию
+	stateCookie, location := startOIDC(t, h, "/api/v1/oidc/link", bob)
+	code, state := issuer.authorize(t, location, "bob-sso", "bob@example.com")
+	rec := oidcCallback(t, h, stateCookie, code, state)
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+	for _, c := range rec.Result().Cookies() {
+		assert.NotEqual(t, refreshCookie, c.Name)
+	}
+
+	user, err := db.GetUserByOIDC(issuer.URL, "bob-sso")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Login)
+
+	// Связанный пользователь входит через провайдера, хотя регистрация отключена
+	stateCookie, location = startOIDCLogin(t, h)
+	code, state = issuer.authorize(t, location, "bob-sso", "bob@example.com")
+	rec = oidcCallback(t, h, stateCookie, code, state)
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+	var names []string
+	for _, c := range rec.Result().Cookies() {
+		names = append(names, c.Name)
+	}
+	assert.Contains(t, names, refreshCookie)
+
+	// Учётную запись провайдера нельзя привязать ко второму пользователю
+	carol := signIn(t, h, "carol", "carol-password")
+	stateCookie, location = startOIDC(t, h, "/api/oidc/link", carol)
+	code, state = issuer.authorize(t, location, "bob-sso", "bob@example.com")
+	rec = oidcCallback(t, h, stateCookie, code, state)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "oidc_already_linked", decode(t, rec)["code"])
+
+	user, err = db.GetUserByOIDC(issuer.URL, "bob-sso")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Login)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/httplog"
+	"go1f/pkg/i18n"
+)
+
+func TestProblemStatus(t *testing.T) {
+	for code, status := range problemStatus {
+		rec := httptest.NewRecorder()
+		writeJSONError(rec, code, "detail")
+
+		assert.Equal(t, status, rec.Code, code)
+		assert.Equal(t, "application/problem+json; charset=utf-8", rec.Header().Get("Content-Type"), code)
+
+		var p Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p), code)
+		assert.Equal(t, code, p.Code)
+		assert.Equal(t, "about:blank", p.Type)
+		assert.NotEmpty(t, p.Title, code)
+		assert.Equal(t, p.Title, p.Error, code)
+		assert.Equal(t, "detail", p.Detail, code)
+	}
+}
+
+func TestProblemUnknownCode(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeJSONError(rec, apperr.Code("unknown"), "detail")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	// Для кода без перевода клиент получает стандартный заголовок статуса и detail
+	var p Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), p.Title)
+	assert.Equal(t, "detail", p.Error)
+}
+
+func TestProblemCodesTranslated(t *testing.T) {
+	for code := range problemStatus {
+		for _, lang := range []i18n.Lang{i18n.RU, i18n.EN} {
+			_, ok := i18n.Error(lang, code)
+			assert.True(t, ok, "%s: no %s message", code, lang)
+		}
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	h := httplog.Middleware(newTestAPI(t, nil))
+	token := signIn(t, h, "", testPassword)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/999999", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept-Language", "en")
+	req.Header.Set(httplog.Header, "req-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/problem+json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	p := decode(t, rec)
+	assert.Equal(t, "task_not_found", p["code"])
+	assert.Equal(t, "about:blank", p["type"])
+	assert.Equal(t, "req-123", p["request_id"])
+	msg, _ := i18n.Error(i18n.EN, apperr.TaskNotFound)
+	assert.Equal(t, msg, p["title"])
+	assert.Equal(t, msg, p["error"])
+}
+
+func TestProblemHidesInternalErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeError(rec, assert.AnError)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), assert.AnError.Error())
+	assert.Equal(t, "internal", decode(t, rec)["code"])
+}
+
+func TestProblemHidesWrappedCause(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeError(rec, fmt.Errorf("callback: %w", apperr.Wrap(apperr.OIDCFailed, "OIDC login failed", assert.AnError)))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotContains(t, rec.Body.String(), assert.AnError.Error())
+	assert.NotContains(t, rec.Body.String(), "callback")
+	p := decode(t, rec)
+	assert.Equal(t, "oidc_failed", p["code"])
+	assert.Equal(t, "OIDC login failed", p["detail"])
+}
@@ -0,0 +1,69 @@
+package api
+
+import "net/http"
+
+// routes собирает маршруты API.
+// Каждый обработчик доступен по прежнему адресу /api/... (параметры в строке запроса)
+// и по адресам /api/v1/... с методом и параметрами в пути
+func routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	handle := func(h http.HandlerFunc, patterns ...string) {
+		for _, p := range patterns {
+			mux.HandleFunc(p, h)
+		}
+	}
+
+	// Публичные маршруты (без аутентификации)
+	handle(signinThrottle.Middleware(signinHandler), "/api/signin", "POST /api/v1/signin")
+	handle(signupHandler, "/api/signup", "POST /api/v1/signup")
+	handle(signinThrottle.Middleware(signinTOTPHandler), "/api/signin/totp", "POST /api/v1/signin/totp")
+	handle(refreshHandler, "/api/refresh", "POST /api/v1/refresh")
+	handle(oidcHandler, "/api/oidc", "GET /api/v1/oidc")
+	handle(oidcLoginHandler, "/api/oidc/login", "GET /api/v1/oidc/login")
+	handle(oidcCallbackHandler, "/api/oidc/callback", "GET /api/v1/oidc/callback")
+	handle(nextDayHandler, "/api/nextdate", "GET /api/v1/nextdate")
+	handle(messagesHandler, "/api/messages", "GET /api/v1/messages")
+
+	// Маршруты задач работают со списком из параметра list
+	handle(authMiddleware(listAccess(taskHandler)), "/api/task",
+		"POST /api/v1/tasks", "GET /api/v1/tasks/{id}", "PUT /api/v1/tasks/{id}", "DELETE /api/v1/tasks/{id}")
+	handle(authMiddleware(listAccess(tasksHandler)), "/api/tasks", "GET /api/v1/tasks")
+	handle(authMiddleware(listAccess(taskDoneHandler)), "/api/task/done", "POST /api/v1/tasks/{id}/done")
+	handle(authMiddleware(listAccess(checklistHandler)), "/api/task/checklist",
+		"POST /api/v1/tasks/{task_id}/checklist", "DELETE /api/v1/checklist/{id}")
+	handle(authMiddleware(listAccess(checklistToggleHandler)), "/api/task/checklist/toggle", "POST /api/v1/checklist/{id}/toggle")
+	handle(authMiddleware(listAccess(checklistReorderHandler)), "/api/task/checklist/reorder",
+		"POST /api/v1/tasks/{task_id}/checklist/reorder")
+	handle(authMiddleware(listAccess(dependencyHandler)), "/api/task/dependencies",
+		"POST /api/v1/tasks/{task_id}/dependencies", "DELETE /api/v1/tasks/{task_id}/dependencies/{blocker_id}")
+	handle(authMiddleware(listAccess(statusHandler)), "/api/task/status",
+		"GET /api/v1/tasks/{id}/status", "POST /api/v1/tasks/{id}/status")
+	handle(authMiddleware(listAccess(boardHandler)), "/api/board", "GET /api/v1/board")
+	handle(authMiddleware(listAccess(uploadAttachmentHandler)), "/api/task/attachments", "POST /api/v1/tasks/{id}/attachments")
+	handle(authMiddleware(listAccess(attachmentHandler)), "/api/task/attachment",
+		"GET /api/v1/attachments/{id}", "DELETE /api/v1/attachments/{id}")
+
+	// Списки задач и их участники
+	handle(authMiddleware(listsHandler), "/api/lists", "GET /api/v1/lists")
+	handle(authMiddleware(listAccess(listMembersHandler)), "/api/lists/members",
+		"GET /api/v1/lists/{list}/members", "POST /api/v1/lists/{list}/members", "DELETE /api/v1/lists/{list}/members/{user}")
+
+	// Учётная запись и администрирование доступны только из сессии, поля читаются и по токену
+	handle(authMiddleware(fieldsHandler), "/api/fields",
+		"GET /api/v1/fields", "POST /api/v1/fields", "DELETE /api/v1/fields/{id}")
+	handle(authMiddleware(sessionOnly(usersHandler)), "/api/users", "GET /api/v1/users", "POST /api/v1/users")
+	handle(authMiddleware(sessionOnly(passwordHandler)), "/api/password", "POST /api/v1/password")
+	handle(authMiddleware(sessionOnly(keysHandler)), "/api/keys", "GET /api/v1/keys")
+	handle(authMiddleware(sessionOnly(rotateKeysHandler)), "/api/keys/rotate", "POST /api/v1/keys/rotate")
+	handle(authMiddleware(logoutHandler), "/api/logout", "POST /api/v1/logout")
+	handle(authMiddleware(sessionOnly(sessionsHandler)), "/api/sessions", "GET /api/v1/sessions", "DELETE /api/v1/sessions/{id}")
+	handle(authMiddleware(sessionOnly(signinStatsHandler)), "/api/signin/stats", "GET /api/v1/signin/stats")
+	handle(authMiddleware(sessionOnly(tokensHandler)), "/api/tokens", "GET /api/v1/tokens", "POST /api/v1/tokens", "DELETE /api/v1/tokens/{id}")
+	handle(authMiddleware(sessionOnly(totpHandler)), "/api/totp", "GET /api/v1/totp")
+	handle(authMiddleware(sessionOnly(totpSetupHandler)), "/api/totp/setup", "POST /api/v1/totp/setup")
+	handle(authMiddleware(sessionOnly(totpEnableHandler)), "/api/totp/enable", "POST /api/v1/totp/enable")
+	handle(authMiddleware(sessionOnly(totpDisableHandler)), "/api/totp/disable", "POST /api/v1/totp/disable")
+	handle(authMiddleware(sessionOnly(oidcLinkHandler)), "/api/oidc/link", "GET /api/v1/oidc/link")
+
+	return mux
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	rec := request(t, h, http.MethodPost, "/api/v1/tasks", token, map[string]string{"date": "20300101", "title": "v1"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	id := fmt.Sprint(decode(t, rec)["id"])
+
+	// Прежний и версионный адреса обслуживаются одним обработчиком
+	legacy := request(t, h, http.MethodGet, "/api/task?id="+id, token, nil)
+	v1 := request(t, h, http.MethodGet, "/api/v1/tasks/"+id, token, nil)
+	require.Equal(t, http.StatusOK, legacy.Code, legacy.Body.String())
+	assert.Equal(t, legacy.Code, v1.Code)
+	assert.JSONEq(t, legacy.Body.String(), v1.Body.String())
+
+	legacy = request(t, h, http.MethodGet, "/api/nextdate?now=20240126&date=20240126&repeat=d+1", "", nil)
+	v1 = request(t, h, http.MethodGet, "/api/v1/nextdate?now=20240126&date=20240126&repeat=d+1", "", nil)
+	assert.Equal(t, "20240127", legacy.Body.String())
+	assert.Equal(t, legacy.Body.String(), v1.Body.String())
+
+	rec = request(t, h, http.MethodPost, "/api/v1/tasks/"+id+"/done", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, request(t, h, http.MethodGet, "/api/task?id="+id, token, nil).Code)
+
+	// Метод версионного адреса входит в маршрут
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, h, http.MethodGet, "/api/v1/signin", "", nil).Code)
+}
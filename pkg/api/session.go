@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/auth"
+	"go1f/pkg/db"
+)
+
+// refreshCookie имя куки с refresh-токеном для веб-интерфейса
+const refreshCookie = "refresh_token"
+
+// RefreshRequest структура для запроса обновления токена
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SessionResp сессия в списке сессий пользователя
+type SessionResp struct {
+	*db.Session
+	Current bool `json:"current"`
+}
+
+// nowUTC возвращает текущее время в формате, в котором время хранится в сессиях
+func nowUTC() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// clientIP возвращает IP-адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// startSession создаёт сессию пользователя и выдаёт пару токенов
+func startSession(w http.ResponseWriter, r *http.Request, user *db.User) (SignInResponse, error) {
+	refresh, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(auth.RefreshTokenTTL)
+	session := &db.Session{
+		UserID:     user.ID,
+		TokenHash:  hash,
+		CreatedAt:  now.Format(time.RFC3339),
+		LastUsedAt: now.Format(time.RFC3339),
+		ExpiresAt:  expires.Format(time.RFC3339),
+		UserAgent:  r.UserAgent(),
+		IP:         clientIP(r),
+	}
+	id, err := db.AddSession(session)
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	token, err := accessToken(user, fmt.Sprint(id))
+	if err != nil {
+		return SignInResponse{}, err
+	}
+
+	setAuthCookies(w, token, refresh, expires)
+	setCSRFCookie(w)
+	return SignInResponse{Token: token, RefreshToken: refresh}, nil
+}
+
+// accessToken выдаёт access-токен сессии пользователя
+func accessToken(user *db.User, sessionID string) (string, error) {
+	return auth.GenerateToken(user.ID, user.Login, sessionID)
+}
+
+// refreshSession проверяет refresh-токен, заменяет его новым и выдаёт новый access-токен
+func refreshSession(w http.ResponseWriter, refresh string) (*db.Session, SignInResponse, error) {
+	oldHash := auth.HashToken(refresh)
+	session, err := db.GetSessionByToken(oldHash)
+	if err != nil {
+		return nil, SignInResponse{}, err
+	}
+	if session.Revoked || session.ExpiresAt <= nowUTC() {
+		return nil, SignInResponse{}, fmt.Errorf("session expired")
+	}
+
+	user, err := db.GetUser(session.UserID)
+	if err != nil {
+		return nil, SignInResponse{}, err
+	}
+
+	newRefresh, newHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, SignInResponse{}, err
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(auth.RefreshTokenTTL)
+	session.TokenHash = newHash
+	session.LastUsedAt = now.Format(time.RFC3339)
+	session.ExpiresAt = expires.Format(time.RFC3339)
+	if err := db.RotateSessionToken(session, oldHash); err != nil {
+		return nil, SignInResponse{}, err
+	}
+
+	token, err := accessToken(user, session.ID)
+	if err != nil {
+		return nil, SignInResponse{}, err
+	}
+
+	setAuthCookies(w, token, newRefresh, expires)
+	return session, SignInResponse{Token: token, RefreshToken: newRefresh}, nil
+}
+
+// activeSession проверяет, что сессия из токена не отозвана и не истекла
+func activeSession(claims *auth.Claims) bool {
+	session, err := db.GetSession(claims.SessionID)
+	if err != nil {
+		return false
+	}
+	return !session.Revoked && session.UserID == claims.UserID && session.ExpiresAt > nowUTC()
+}
+
+// setAuthCookies сохраняет токены в куки для веб-интерфейса
+func setAuthCookies(w http.ResponseWriter, token, refresh string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "token",
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   cfg.TLS.Enabled(),
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookie,
+		Value:    refresh,
+		Path:     "/api",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   cfg.TLS.Enabled(),
+	})
+}
+
+// clearAuthCookies удаляет куки с токенами
+func clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: "token", Path: "/", MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{Name: refreshCookie, Path: "/api", MaxAge: -1, HttpOnly: true})
+	clearCSRFCookie(w)
+}
+
+// refreshHandler выдаёт новую пару токенов по refresh-токену из тела запроса или куки
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req RefreshRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+			return
+		}
+	}
+	if req.RefreshToken == "" {
+		if cookie, err := r.Cookie(refreshCookie); err == nil {
+			req.RefreshToken = cookie.Value
+		}
+	}
+	if req.RefreshToken == "" {
+		writeJSONError(w, apperr.BadRequest, "Refresh token not specified")
+		return
+	}
+
+	_, resp, err := refreshSession(w, req.RefreshToken)
+	if err != nil {
+		clearAuthCookies(w)
+		writeJSONError(w, apperr.InvalidToken, "Invalid refresh token")
+		return
+	}
+
+	writeJSONSuccess(w, resp, http.StatusOK)
+}
+
+// logoutHandler отзывает текущую сессию
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if sid := currentSession(r); sid != "" {
+		if err := db.RevokeSession(currentUser(r), sid); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	clearAuthCookies(w)
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
+
+// sessionsHandler возвращает список сессий пользователя и отзывает выбранную
+func sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sessions, err := db.Sessions(currentUser(r), nowUTC())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		list := make([]SessionResp, 0, len(sessions))
+		for _, s := range sessions {
+			list = append(list, SessionResp{Session: s, Current: s.ID == currentSession(r)})
+		}
+		writeJSONSuccess(w, map[string]interface{}{"sessions": list}, http.StatusOK)
+	case http.MethodDelete:
+		id := param(r, "id")
+		if id == "" {
+			writeJSONError(w, apperr.IDRequired, "ID not specified")
+			return
+		}
+		if err := db.RevokeSession(currentUser(r), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
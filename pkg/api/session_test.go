@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signInPair входит по паролю и возвращает access- и refresh-токены
+func signInPair(t *testing.T, h http.Handler) (string, string) {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	resp := decode(t, rec)
+	return resp["token"].(string), resp["refresh_token"].(string)
+}
+
+// refresh обменивает refresh-токен на новую пару
+func refresh(t *testing.T, h http.Handler, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	return request(t, h, http.MethodPost, "/api/refresh", "", RefreshRequest{RefreshToken: token})
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	h := newTestAPI(t, nil)
+	_, first := signInPair(t, h)
+
+	rec := refresh(t, h, first)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	resp := decode(t, rec)
+	second := resp["refresh_token"].(string)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", resp["token"].(string), nil).Code)
+
+	// Использованный refresh-токен больше не принимается
+	rec = refresh(t, h, first)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, http.StatusOK, refresh(t, h, second).Code)
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	h := newTestAPI(t, nil)
+	access, refreshToken := signInPair(t, h)
+	other := signIn(t, h, "", testPassword)
+
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/api/logout", access, nil).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, request(t, h, http.MethodGet, "/api/tasks", access, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, refresh(t, h, refreshToken).Code)
+
+	// Другие сессии пользователя продолжают работать
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", other, nil).Code)
+}
+
+func TestRevokeSession(t *testing.T) {
+	h := newTestAPI(t, nil)
+	current := signIn(t, h, "", testPassword)
+	other := signIn(t, h, "", testPassword)
+
+	rec := request(t, h, http.MethodGet, "/api/sessions", current, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	sessions := decode(t, rec)["sessions"].([]interface{})
+	require.Len(t, sessions, 2)
+
+	var otherID string
+	for _, s := range sessions {
+		session := s.(map[string]interface{})
+		if session["current"] != true {
+			otherID = fmt.Sprint(session["id"])
+		}
+	}
+	require.NotEmpty(t, otherID)
+
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodDelete, "/api/sessions?id="+otherID, current, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(t, h, http.MethodGet, "/api/tasks", other, nil).Code)
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", current, nil).Code)
+}
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	h := newTestAPI(t, nil)
+	current := signIn(t, h, "", testPassword)
+	other := signIn(t, h, "", testPassword)
+
+	rec := request(t, h, http.MethodPost, "/api/password", current, ChangePasswordReq{OldPassword: testPassword, NewPassword: "new-password"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, request(t, h, http.MethodGet, "/api/tasks", other, nil).Code)
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", current, nil).Code)
+}
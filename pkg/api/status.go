@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/db"
+)
+
+// statusTransitions описывает допустимые переходы между статусами задачи
+var statusTransitions = map[string][]string{
+	db.StatusTodo:       {db.StatusInProgress, db.StatusWaiting, db.StatusDone},
+	db.StatusInProgress: {db.StatusTodo, db.StatusWaiting, db.StatusDone},
+	db.StatusWaiting:    {db.StatusTodo, db.StatusInProgress, db.StatusDone},
+	db.StatusDone:       {db.StatusTodo, db.StatusInProgress},
+}
+
+// StatusReq структура для запроса смены статуса
+type StatusReq struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// StatusResp структура для ответа с историей статусов
+type StatusResp struct {
+	Status  string             `json:"status"`
+	History []*db.StatusChange `json:"history"`
+}
+
+// BoardColumn колонка доски с задачами одного статуса
+type BoardColumn struct {
+	Status string     `json:"status"`
+	Tasks  []*db.Task `json:"tasks"`
+}
+
+// BoardResp структура для ответа с доской задач
+type BoardResp struct {
+	Columns []BoardColumn `json:"columns"`
+}
+
+// canTransition проверяет, допустим ли переход из статуса from в статус to
+func canTransition(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// statusHandler обрабатывает получение истории и смену статуса задачи
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getStatusHandler(w, r)
+	case http.MethodPost:
+		setStatusHandler(w, r)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// getStatusHandler возвращает текущий статус задачи и историю его изменений
+func getStatusHandler(w http.ResponseWriter, r *http.Request) {
+	owner := currentList(r)
+
+	id := param(r, "id")
+	if id == "" {
+		writeJSONError(w, apperr.IDRequired, "ID not specified")
+		return
+	}
+
+	task, err := db.GetTask(owner, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	history, err := db.StatusHistory(owner, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, StatusResp{Status: task.Status, History: history}, http.StatusOK)
+}
+
+// setStatusHandler переводит задачу в новый статус
+func setStatusHandler(w http.ResponseWriter, r *http.Request) {
+	owner := currentList(r)
+
+	var req StatusReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	if id := r.PathValue("id"); id != "" {
+		req.ID = id
+	}
+	if req.ID == "" {
+		writeJSONError(w, apperr.IDRequired, "Task ID not specified")
+		return
+	}
+	if _, ok := statusTransitions[req.Status]; !ok {
+		writeJSONError(w, apperr.InvalidStatus, "Unknown status: "+req.Status)
+		return
+	}
+
+	task, err := db.GetTask(owner, req.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !canTransition(task.Status, req.Status) {
+		writeJSONError(w, apperr.InvalidTransition, "Transition from "+task.Status+" to "+req.Status+" is not allowed")
+		return
+	}
+	if req.Status == db.StatusDone && task.Status != db.StatusDone && !requireUnblocked(w, r, owner, req.ID) {
+		return
+	}
+
+	if err := db.SetStatus(owner, req.ID, req.Status); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Status == db.StatusDone && task.Status != db.StatusDone {
+		tasksCompleted.Inc()
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
+
+// boardHandler возвращает задачи, сгруппированные по статусам
+func boardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	tasks, err := db.Tasks(currentList(r), cfg.TasksLimit, db.TaskFilter{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	columns := make([]BoardColumn, len(db.Statuses))
+	index := make(map[string]int, len(db.Statuses))
+	for i, status := range db.Statuses {
+		columns[i] = BoardColumn{Status: status, Tasks: []*db.Task{}}
+		index[status] = i
+	}
+	for _, task := range tasks {
+		i := index[task.Status]
+		columns[i].Tasks = append(columns[i].Tasks, task)
+	}
+
+	writeJSONSuccess(w, BoardResp{Columns: columns}, http.StatusOK)
+}
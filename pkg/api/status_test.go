@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/db"
+)
+
+// setStatus переводит задачу в статус status
+func setStatus(t *testing.T, h http.Handler, token, id, status string) int {
+	t.Helper()
+	return request(t, h, http.MethodPost, "/api/task/status", token, StatusReq{ID: id, Status: status}).Code
+}
+
+func TestStatusTransitions(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	id := addTask(t, h, token, "task")
+
+	assert.Equal(t, http.StatusOK, setStatus(t, h, token, id, db.StatusInProgress))
+	assert.Equal(t, http.StatusOK, setStatus(t, h, token, id, db.StatusDone))
+	assert.Equal(t, http.StatusConflict, setStatus(t, h, token, id, db.StatusWaiting))
+	assert.Equal(t, http.StatusBadRequest, setStatus(t, h, token, id, "archived"))
+	assert.Equal(t, http.StatusNotFound, setStatus(t, h, token, "999", db.StatusDone))
+
+	rec := request(t, h, http.MethodGet, "/api/task/status?id="+id, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	resp := decode(t, rec)
+	assert.Equal(t, db.StatusDone, resp["status"])
+
+	// История хранит изменения от старых к новым, отклонённые переходы в неё не попадают
+	var statuses []interface{}
+	for _, change := range resp["history"].([]interface{}) {
+		statuses = append(statuses, change.(map[string]interface{})["status"])
+	}
+	require.GreaterOrEqual(t, len(statuses), 2)
+	assert.Equal(t, []interface{}{db.StatusInProgress, db.StatusDone}, statuses[len(statuses)-2:])
+}
+
+func TestBoard(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	todo := addTask(t, h, token, "todo")
+	waiting := addTask(t, h, token, "waiting")
+	require.Equal(t, http.StatusOK, setStatus(t, h, token, waiting, db.StatusWaiting))
+
+	rec := request(t, h, http.MethodGet, "/api/board", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	columns := decode(t, rec)["columns"].([]interface{})
+	require.Len(t, columns, len(db.Statuses))
+	board := make(map[string][]string)
+	for i, c := range columns {
+		column := c.(map[string]interface{})
+		assert.Equal(t, db.Statuses[i], column["status"])
+		for _, task := range column["tasks"].([]interface{}) {
+			board[db.Statuses[i]] = append(board[db.Statuses[i]], fmt.Sprint(task.(map[string]interface{})["id"]))
+		}
+	}
+	assert.Equal(t, map[string][]string{db.StatusTodo: {todo}, db.StatusWaiting: {waiting}}, board)
+}
+
+func TestRepeatingTaskStatusReset(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	id := addRepeatingTask(t, h, token, "Отчёт", "d 7")
+	require.Equal(t, http.StatusOK, setStatus(t, h, token, id, db.StatusInProgress))
+
+	// Следующее повторение снова начинается в статусе todo
+	rec := request(t, h, http.MethodPost, "/api/task/done?id="+id, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = request(t, h, http.MethodGet, "/api/task/status?id="+id, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, db.StatusTodo, decode(t, rec)["status"])
+}
+
+func TestStatusDoneChecksBlockers(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	task := addTask(t, h, token, "task")
+	blocker := addTask(t, h, token, "blocker")
+	require.Equal(t, http.StatusOK, addDependency(t, h, token, task, blocker).Code)
+
+	rec := request(t, h, http.MethodPost, "/api/v1/tasks/"+task+"/status", token, StatusReq{Status: db.StatusDone})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "task_blocked", decode(t, rec)["code"])
+
+	// Другие переходы блокирующие задачи не ограничивают
+	assert.Equal(t, http.StatusOK, setStatus(t, h, token, task, db.StatusInProgress))
+
+	// Блокирующая задача в статусе done больше не блокирует
+	require.Equal(t, http.StatusOK, setStatus(t, h, token, blocker, db.StatusDone))
+	rec = request(t, h, http.MethodGet, "/api/task?id="+task, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := decode(t, rec)
+	assert.Nil(t, resp["blocked"])
+	assert.Nil(t, resp["blocked_by"])
+
+	rec = request(t, h, http.MethodGet, "/api/tasks?ready=true", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, decode(t, rec)["tasks"], 2)
+
+	assert.Equal(t, http.StatusOK, setStatus(t, h, token, task, db.StatusDone))
+}
+
+func TestStatusDoneForce(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	task := addTask(t, h, token, "task")
+	blocker := addTask(t, h, token, "blocker")
+	require.Equal(t, http.StatusOK, addDependency(t, h, token, task, blocker).Code)
+
+	rec := request(t, h, http.MethodPost, "/api/v1/tasks/"+task+"/status?force=true", token, StatusReq{Status: db.StatusDone})
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
@@ -2,6 +2,9 @@ package api
 
 import (
 	"net/http"
+	"strings"
+
+	"go1f/pkg/apperr"
 	"go1f/pkg/db"
 )
 
@@ -15,29 +18,45 @@ func tasksHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	
 	if r.Method != http.MethodGet {
-		writeJSONError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Получаем параметры из query string
 	search := r.URL.Query().Get("search")
+	filter := db.TaskFilter{Ready: r.URL.Query().Get("ready") == "true"}
+
+	// Фильтры по пользовательским полям передаются как field.<имя>=<значение>
+	fields := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "field."); ok && len(values) > 0 {
+			fields[name] = values[0]
+		}
+	}
+	if len(fields) > 0 {
+		var err error
+		filter.Fields, err = normalizeFieldValues(fields, false)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
 	
-	// Парсим лимит, по умолчанию 50
-	limit := 50
+	limit := cfg.TasksLimit
 
 	var tasks []*db.Task
 	var err error
 	
 	if search != "" {
 		// Если есть параметр search, используем поиск
-		tasks, err = db.SearchTasks(search, limit)
+		tasks, err = db.SearchTasks(currentList(r), search, limit, filter)
 	} else {
 		// Иначе получаем все задачи
-		tasks, err = db.Tasks(limit)
+		tasks, err = db.Tasks(currentList(r), limit, filter)
 	}
 	
 	if err != nil {
-		writeJSONError(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go1f/pkg/apperr"
+)
+
+// backoff описывает, сколько неудачных попыток допускается без задержки
+// и как растёт блокировка после них
+type backoff struct {
+	Free       int           // Попыток без блокировки
+	BaseDelay  time.Duration // Блокировка после первой лишней попытки, дальше удваивается
+	MaxDelay   time.Duration // Максимальная длительность блокировки
+	ResetAfter time.Duration // Через сколько после последней неудачи счётчик сбрасывается
+}
+
+// attempts неудачные попытки одного клиента или всех клиентов вместе
+type attempts struct {
+	failures     int
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// fail учитывает неудачную попытку и возвращает true, если клиент заблокирован
+func (a *attempts) fail(b backoff, now time.Time) bool {
+	if now.Sub(a.last) > b.ResetAfter {
+		a.failures = 0
+	}
+	a.failures++
+	a.last = now
+
+	extra := a.failures - b.Free
+	if extra <= 0 {
+		return false
+	}
+	delay := b.MaxDelay
+	if extra <= 32 {
+		delay = time.Duration(math.Min(float64(b.BaseDelay)*math.Pow(2, float64(extra-1)), float64(b.MaxDelay)))
+	}
+	a.blockedUntil = now.Add(delay)
+	return true
+}
+
+// ThrottleStats счётчики попыток для операторов
+type ThrottleStats struct {
+	Attempts        int64 `json:"attempts"`         // Все попытки, дошедшие до обработчика
+	Failures        int64 `json:"failures"`         // Неудачные попытки
+	Rejected        int64 `json:"rejected"`         // Попытки, отклонённые с 429
+	Lockouts        int64 `json:"lockouts"`         // Сколько раз клиент был заблокирован
+	GlobalLocked    bool  `json:"global_locked"`    // Действует ли сейчас общая блокировка
+	BlockedClients  int   `json:"blocked_clients"`  // Сколько IP-адресов сейчас заблокировано
+	BlockedAccounts int   `json:"blocked_accounts"` // Сколько учётных записей сейчас заблокировано
+}
+
+// Throttle ограничивает частоту неудачных попыток входа с одного IP-адреса, в одну учётную запись и в целом.
+// Middleware отклоняет заблокированные IP-адреса, а результат попытки сообщает сам обработчик через
+// fail и succeed: только он знает учётную запись и то, завершён ли вход.
+// Общая блокировка действует только на неудачные попытки, чтобы массовый перебор не мешал входить с верным паролем
+type Throttle struct {
+	PerIP      backoff
+	PerAccount backoff
+	Global     backoff
+
+	mu       sync.Mutex
+	ips      map[string]*attempts
+	accounts map[string]*attempts
+	global   attempts
+	stats    ThrottleStats
+}
+
+// NewThrottle создаёт ограничитель с настройками по умолчанию
+func NewThrottle() *Throttle {
+	return &Throttle{
+		PerIP:      backoff{Free: 5, BaseDelay: time.Second, MaxDelay: 15 * time.Minute, ResetAfter: time.Hour},
+		PerAccount: backoff{Free: 5, BaseDelay: time.Second, MaxDelay: 15 * time.Minute, ResetAfter: time.Hour},
+		Global:     backoff{Free: 100, BaseDelay: time.Second, MaxDelay: time.Minute, ResetAfter: time.Minute},
+		ips:        make(map[string]*attempts),
+		accounts:   make(map[string]*attempts),
+	}
+}
+
+// signinThrottle ограничитель для входа по паролю и второго шага входа
+var signinThrottle = NewThrottle()
+
+// loginAccount ключ счётчика попыток входа по паролю в учётную запись
+// Несуществующие логины учитываются так же, чтобы по ответам нельзя было отличить их от существующих
+func loginAccount(login string) string {
+	return "login:" + login
+}
+
+// totpAccount ключ счётчика попыток второго фактора пользователя
+func totpAccount(userID string) string {
+	return "totp:" + userID
+}
+
+// Middleware отклоняет запросы заблокированных клиентов
+func (t *Throttle) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if wait := t.blocked(clientIP(r), "", time.Now()); wait > 0 {
+			writeThrottled(w, wait)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeThrottled отвечает 429 со временем до следующей попытки
+func writeThrottled(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeJSONError(w, apperr.TooManyRequests, "Too many sign-in attempts, try again later")
+}
+
+// blocked возвращает, сколько клиенту осталось ждать до следующей попытки в учётную запись
+// Пустая учётная запись проверяет только IP-адрес
+func (t *Throttle) blocked(ip, account string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var wait time.Duration
+	if a, ok := t.ips[ip]; ok {
+		wait = a.blockedUntil.Sub(now)
+	}
+	if a, ok := t.accounts[account]; ok && account != "" {
+		if d := a.blockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		t.stats.Rejected++
+	}
+	return wait
+}
+
+// fail учитывает неудачную попытку с IP-адреса ip в учётную запись account (может быть пустой)
+// Возвращает, сколько осталось до конца общей блокировки, если она действует
+func (t *Throttle) fail(ip, account string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stats.Attempts++
+	t.stats.Failures++
+	t.prune(now)
+
+	if failure(t.ips, ip, t.PerIP, now) {
+		t.stats.Lockouts++
+	}
+	if account != "" && failure(t.accounts, account, t.PerAccount, now) {
+		t.stats.Lockouts++
+	}
+
+	// Во время общей блокировки неудачная попытка отклоняется и не продлевает её
+	if wait := t.global.blockedUntil.Sub(now); wait > 0 {
+		t.stats.Rejected++
+		return wait
+	}
+	if t.global.fail(t.Global, now) {
+		t.stats.Lockouts++
+		slog.Warn("failed sign-ins are throttled for all clients", "until", t.global.blockedUntil.Format(time.RFC3339))
+	}
+	return 0
+}
+
+// signinFailed учитывает неудачную попытку входа и отвечает клиенту ошибкой,
+// а во время общей блокировки — 429
+func signinFailed(w http.ResponseWriter, r *http.Request, account string, code apperr.Code, detail string) {
+	if wait := signinThrottle.fail(clientIP(r), account, time.Now()); wait > 0 {
+		writeThrottled(w, wait)
+		return
+	}
+	writeJSONError(w, code, detail)
+}
+
+// failure учитывает неудачу в счётчике по ключу и возвращает true, если ключ заблокирован
+func failure(m map[string]*attempts, key string, b backoff, now time.Time) bool {
+	a, ok := m[key]
+	if !ok {
+		a = &attempts{}
+		m[key] = a
+	}
+	return a.fail(b, now)
+}
+
+// pass учитывает попытку, которая ещё не завершила вход (пароль верный, нужен второй фактор):
+// счётчики не сбрасываются, иначе знание пароля открывало бы неограниченный перебор кодов
+func (t *Throttle) pass() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stats.Attempts++
+}
+
+// succeed учитывает завершённый вход и сбрасывает счётчики IP-адреса и учётных записей
+func (t *Throttle) succeed(ip string, accounts ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stats.Attempts++
+	delete(t.ips, ip)
+	for _, account := range accounts {
+		delete(t.accounts, account)
+	}
+}
+
+// prune забывает клиентов и учётные записи, чьи счётчики уже сброшены по времени
+func (t *Throttle) prune(now time.Time) {
+	for ip, a := range t.ips {
+		if now.Sub(a.last) > t.PerIP.ResetAfter && now.After(a.blockedUntil) {
+			delete(t.ips, ip)
+		}
+	}
+	for account, a := range t.accounts {
+		if now.Sub(a.last) > t.PerAccount.ResetAfter && now.After(a.blockedUntil) {
+			delete(t.accounts, account)
+		}
+	}
+}
+
+// Stats возвращает текущие значения счётчиков
+func (t *Throttle) Stats() ThrottleStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	stats := t.stats
+	stats.GlobalLocked = now.Before(t.global.blockedUntil)
+	for _, a := range t.ips {
+		if now.Before(a.blockedUntil) {
+			stats.BlockedClients++
+		}
+	}
+	for _, a := range t.accounts {
+		if now.Before(a.blockedUntil) {
+			stats.BlockedAccounts++
+		}
+	}
+	return stats
+}
+
+// signinStatsHandler возвращает счётчики попыток входа администратору
+func signinStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	writeJSONSuccess(w, signinThrottle.Stats(), http.StatusOK)
+}
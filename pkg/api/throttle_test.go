@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignInThrottledPerIP(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	const ip = "198.51.100.1"
+	for i := 0; i < 6; i++ {
+		login := fmt.Sprintf("user%d", i)
+		rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Login: login, Password: "wrong"})
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// С другого адреса вход работает
+	signIn(t, h, "", testPassword)
+}
+
+func TestSignInSuccessResetsThrottle(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	const ip = "198.51.100.1"
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 5; i++ {
+			rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Password: "wrong"})
+			require.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+		rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+}
+
+func TestSignInThrottledPerAccount(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	for i := 0; i < 6; i++ {
+		ip := fmt.Sprintf("203.0.113.%d", i+1)
+		rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Password: "wrong"})
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	rec := requestFrom(t, h, "203.0.113.100", http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, 1, signinThrottle.Stats().BlockedAccounts)
+}
+
+func TestGlobalThrottleAllowsValidPassword(t *testing.T) {
+	h := newTestAPI(t, nil)
+	signinThrottle.Global = backoff{Free: 3, BaseDelay: time.Minute, MaxDelay: time.Minute, ResetAfter: time.Minute}
+
+	for i := 0; i < 4; i++ {
+		ip := fmt.Sprintf("203.0.113.%d", i+1)
+		login := fmt.Sprintf("user%d", i)
+		rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Login: login, Password: "wrong"})
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	require.True(t, signinThrottle.Stats().GlobalLocked)
+
+	// Неудачные попытки во время общей блокировки отклоняются, верный пароль принимается
+	rec := requestFrom(t, h, "203.0.113.50", http.MethodPost, "/api/signin", "", SignInRequest{Login: "user9", Password: "wrong"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	signIn(t, h, "", testPassword)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/auth"
+	"go1f/pkg/db"
+)
+
+// APITokenReq структура для запроса создания API-токена
+type APITokenReq struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+// apiTokenUser проверяет персональный API-токен и отмечает его использование
+func apiTokenUser(token string) (*db.APIToken, error) {
+	t, err := db.GetAPITokenByHash(auth.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.TouchAPIToken(t.ID, nowUTC()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// readOnlyMethod проверяет, что метод запроса ничего не изменяет
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// currentScope возвращает область действия API-токена запроса
+// Для запросов с токеном сессии возвращается пустая строка
+func currentScope(r *http.Request) string {
+	scope, _ := r.Context().Value(scopeKey).(string)
+	return scope
+}
+
+// sessionOnly пропускает только запросы с сессией пользователя.
+// API-токены работают с задачами и списками, а учётная запись и администрирование им недоступны:
+// иначе утёкший токен мог бы выпускать новые токены, менять пароль или создавать администраторов
+func sessionOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if currentScope(r) != "" {
+			writeJSONError(w, apperr.Forbidden, "API tokens cannot access account and admin endpoints")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// tokensHandler возвращает, создаёт и отзывает API-токены пользователя
+func tokensHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tokens, err := db.APITokens(currentUser(r))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSONSuccess(w, map[string]interface{}{"tokens": tokens}, http.StatusOK)
+	case http.MethodPost:
+		addAPITokenHandler(w, r)
+	case http.MethodDelete:
+		id := param(r, "id")
+		if id == "" {
+			writeJSONError(w, apperr.IDRequired, "ID not specified")
+			return
+		}
+		if err := db.DeleteAPIToken(currentUser(r), id); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// addAPITokenHandler создаёт API-токен, сам токен возвращается только в этом ответе
+func addAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	var req APITokenReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	if req.Scope == "" {
+		req.Scope = db.ScopeRead
+	}
+	if req.Scope != db.ScopeRead && req.Scope != db.ScopeWrite {
+		writeJSONError(w, apperr.InvalidScope, "Scope must be read or write")
+		return
+	}
+	if len(req.Name) > 128 {
+		writeJSONError(w, apperr.BadRequest, "Token name is too long")
+		return
+	}
+
+	token, hash, err := auth.NewAPIToken()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := db.AddAPIToken(&db.APIToken{
+		UserID:    currentUser(r),
+		Name:      req.Name,
+		TokenHash: hash,
+		Scope:     req.Scope,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"id": id, "token": token, "scope": req.Scope}, http.StatusOK)
+}
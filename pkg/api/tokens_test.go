@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addAPIToken выпускает персональный API-токен с областью scope
+func addAPIToken(t *testing.T, h http.Handler, token, scope string) string {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/tokens", token, map[string]string{"name": "ci", "scope": scope})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return decode(t, rec)["token"].(string)
+}
+
+func TestAPITokenScope(t *testing.T) {
+	h := newTestAPI(t, nil)
+	session := signIn(t, h, "", testPassword)
+	read := addAPIToken(t, h, session, "read")
+	write := addAPIToken(t, h, session, "write")
+
+	task := map[string]string{"date": "20300101", "title": "task"}
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", read, nil).Code)
+	assert.Equal(t, http.StatusForbidden, request(t, h, http.MethodPost, "/api/task", read, task).Code)
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/api/task", write, task).Code)
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/fields", write, nil).Code)
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/lists", write, nil).Code)
+}
+
+func TestAPITokenAccountEndpoints(t *testing.T) {
+	h := newTestAPI(t, nil)
+	write := addAPIToken(t, h, signIn(t, h, "", testPassword), "write")
+
+	// Даже токен администратора с правом записи не управляет учётными записями и ключами
+	for _, tt := range []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodGet, "/api/users", nil},
+		{http.MethodPost, "/api/users", UserReq{Login: "mallory", Password: "secret123", Admin: true}},
+		{http.MethodPost, "/api/password", ChangePasswordReq{OldPassword: testPassword, NewPassword: "changed123"}},
+		{http.MethodPost, "/api/keys/rotate", nil},
+		{http.MethodGet, "/api/sessions", nil},
+		{http.MethodGet, "/api/tokens", nil},
+		{http.MethodPost, "/api/tokens", map[string]string{"name": "more", "scope": "write"}},
+		{http.MethodPost, "/api/totp/setup", nil},
+		{http.MethodPost, "/api/fields", map[string]string{"name": "prio", "type": "text"}},
+	} {
+		rec := request(t, h, tt.method, tt.path, write, tt.body)
+		assert.Equal(t, http.StatusForbidden, rec.Code, "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestAPITokenRevoke(t *testing.T) {
+	h := newTestAPI(t, nil)
+	session := signIn(t, h, "", testPassword)
+	token := addAPIToken(t, h, session, "read")
+
+	rec := request(t, h, http.MethodPost, "/api/tokens", session, map[string]string{"name": "ci", "scope": "admin"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = request(t, h, http.MethodGet, "/api/tokens", session, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	tokens := decode(t, rec)["tokens"].([]interface{})
+	require.Len(t, tokens, 1)
+	// Сам токен после создания не показывается
+	assert.NotContains(t, rec.Body.String(), token)
+
+	id := fmt.Sprint(tokens[0].(map[string]interface{})["id"])
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodDelete, "/api/tokens?id="+id, session, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(t, h, http.MethodGet, "/api/tasks", token, nil).Code)
+}
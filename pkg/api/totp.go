@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/auth"
+	"go1f/pkg/db"
+)
+
+// RecoveryCodesCount количество кодов восстановления, выдаваемых при включении TOTP
+const RecoveryCodesCount = 10
+
+// TOTPSignInRequest структура для второго шага входа
+type TOTPSignInRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // Код из приложения или код восстановления
+}
+
+// TOTPReq структура для запросов включения и отключения TOTP
+type TOTPReq struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+// checkSecondFactor проверяет код TOTP или одноразовый код восстановления пользователя
+func checkSecondFactor(userID, code string) bool {
+	totp, err := db.GetTOTP(userID)
+	if err != nil || !totp.Enabled {
+		return false
+	}
+
+	if step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now()); ok {
+		return db.UseTOTPStep(userID, step) == nil
+	}
+
+	hash := auth.HashToken(auth.NormalizeRecoveryCode(code))
+	return db.UseRecoveryCode(userID, hash, nowUTC()) == nil
+}
+
+// signinTOTPHandler завершает вход пользователя с включённым TOTP
+func signinTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req TOTPSignInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "Invalid request format")
+		return
+	}
+
+	ip := clientIP(r)
+	userID, err := auth.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		countSignIn("totp", false)
+		signinFailed(w, r, "", apperr.MFAExpired, "Sign-in confirmation expired, sign in again")
+		return
+	}
+
+	// Коды второго фактора перебираются отдельно от паролей, поэтому у них свой счётчик на пользователя:
+	// смена IP-адреса не даёт новых попыток
+	if wait := signinThrottle.blocked(ip, totpAccount(userID), time.Now()); wait > 0 {
+		writeThrottled(w, wait)
+		return
+	}
+	if !checkSecondFactor(userID, req.Code) {
+		countSignIn("totp", false)
+		signinFailed(w, r, totpAccount(userID), apperr.InvalidTOTPCode, "Invalid confirmation code")
+		return
+	}
+
+	user, err := db.GetUser(userID)
+	if err != nil {
+		writeJSONError(w, apperr.InvalidCredentials, "Invalid login or password")
+		return
+	}
+
+	response, err := startSession(w, r, user)
+	if err != nil {
+		writeJSONError(w, apperr.Internal, "Token generation failed")
+		return
+	}
+	countSignIn("totp", true)
+	signinThrottle.succeed(ip, totpAccount(userID), loginAccount(user.Login))
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// totpHandler возвращает состояние двухфакторной аутентификации пользователя
+func totpHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	enabled, err := db.TOTPEnabled(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	left, err := db.RecoveryCodesLeft(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"enabled": enabled, "recovery_codes_left": left}, http.StatusOK)
+}
+
+// totpSetupHandler создаёт секрет TOTP и возвращает URI для QR-кода
+// TOTP начинает действовать только после подтверждения кодом через /api/totp/enable
+func totpSetupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, err := db.GetUser(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := db.SetTOTPSecret(user.ID, secret); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{
+		"secret": secret,
+		"uri":    auth.TOTPURI("todo_app", user.Login, secret),
+	}, http.StatusOK)
+}
+
+// totpEnableHandler подтверждает секрет кодом, включает TOTP и выдаёт коды восстановления
+func totpEnableHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req TOTPReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	totp, err := db.GetTOTP(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if totp.Enabled {
+		writeJSONError(w, apperr.TOTPAlreadyEnabled, "TOTP is already enabled")
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(totp.Secret, req.Code, time.Now())
+	if !ok {
+		writeJSONError(w, apperr.TOTPConfirmFailed, "Invalid confirmation code")
+		return
+	}
+
+	codes, err := auth.NewRecoveryCodes(RecoveryCodesCount)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, auth.HashToken(code))
+	}
+
+	if err := db.EnableTOTP(totp.UserID, step, hashes); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"recovery_codes": codes}, http.StatusOK)
+}
+
+// totpDisableHandler отключает TOTP после проверки пароля и второго фактора
+func totpDisableHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req TOTPReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	user, err := db.GetUser(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !auth.CheckPassword(user.PasswordHash, req.Password) || !checkSecondFactor(user.ID, req.Code) {
+		writeJSONError(w, apperr.WrongPassword, "Invalid password or confirmation code")
+		return
+	}
+
+	if err := db.DisableTOTP(user.ID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
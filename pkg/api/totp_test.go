@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/auth"
+)
+
+// enableTOTP включает TOTP пользователю с токеном token и возвращает секрет
+// Код текущего шага уходит на подтверждение и для входа уже не годится
+func enableTOTP(t *testing.T, h http.Handler, token string) string {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/totp/setup", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	secret := decode(t, rec)["secret"].(string)
+
+	code, err := auth.TOTPCode(secret, time.Now().Unix()/30)
+	require.NoError(t, err)
+	rec = request(t, h, http.MethodPost, "/api/totp/enable", token, TOTPReq{Code: code})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return secret
+}
+
+// mfaToken входит по паролю и возвращает токен второго шага
+func mfaToken(t *testing.T, h http.Handler, ip string) string {
+	t.Helper()
+
+	rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	resp := decode(t, rec)
+	require.Equal(t, true, resp["mfa_required"])
+	return resp["mfa_token"].(string)
+}
+
+func TestSignInMFARequiredKeepsThrottle(t *testing.T) {
+	h := newTestAPI(t, nil)
+	enableTOTP(t, h, signIn(t, h, "", testPassword))
+
+	const ip = "198.51.100.1"
+	wrong := func() int {
+		return requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Password: "wrong"}).Code
+	}
+
+	for i := 0; i < 4; i++ {
+		require.Equal(t, http.StatusUnauthorized, wrong())
+	}
+	// Верный пароль без второго фактора не сбрасывает счётчик IP-адреса
+	mfaToken(t, h, ip)
+	require.Equal(t, http.StatusUnauthorized, wrong())
+	require.Equal(t, http.StatusUnauthorized, wrong())
+
+	rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestSignInTOTPThrottledPerUser(t *testing.T) {
+	h := newTestAPI(t, nil)
+	secret := enableTOTP(t, h, signIn(t, h, "", testPassword))
+	token := mfaToken(t, h, "198.51.100.1")
+
+	// Каждая попытка с нового IP-адреса: ограничивает счётчик пользователя
+	for i := 0; i < 6; i++ {
+		ip := fmt.Sprintf("203.0.113.%d", i+1)
+		rec := requestFrom(t, h, ip, http.MethodPost, "/api/signin/totp", "", TOTPSignInRequest{MFAToken: token, Code: "wrong"})
+		require.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	}
+
+	code, err := auth.TOTPCode(secret, time.Now().Unix()/30)
+	require.NoError(t, err)
+	rec := requestFrom(t, h, "203.0.113.100", http.MethodPost, "/api/signin/totp", "", TOTPSignInRequest{MFAToken: token, Code: code})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestSignInTOTPReplay(t *testing.T) {
+	h := newTestAPI(t, nil)
+	secret := enableTOTP(t, h, signIn(t, h, "", testPassword))
+
+	// Следующий шаг принимается из-за допустимого расхождения часов
+	code, err := auth.TOTPCode(secret, time.Now().Unix()/30+1)
+	require.NoError(t, err)
+
+	rec := request(t, h, http.MethodPost, "/api/signin/totp", "", TOTPSignInRequest{MFAToken: mfaToken(t, h, "192.0.2.1"), Code: code})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotEmpty(t, decode(t, rec)["token"])
+
+	// Тот же код повторно не принимается даже с новым токеном второго шага
+	rec = request(t, h, http.MethodPost, "/api/signin/totp", "", TOTPSignInRequest{MFAToken: mfaToken(t, h, "192.0.2.1"), Code: code})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSignInRecoveryCodeOnce(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+
+	rec := request(t, h, http.MethodPost, "/api/totp/setup", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	secret := decode(t, rec)["secret"].(string)
+	code, err := auth.TOTPCode(secret, time.Now().Unix()/30)
+	require.NoError(t, err)
+	rec = request(t, h, http.MethodPost, "/api/totp/enable", token, TOTPReq{Code: code})
+	require.Equal(t, http.StatusOK, rec.Code)
+	recovery := decode(t, rec)["recovery_codes"].([]interface{})
+	require.Len(t, recovery, RecoveryCodesCount)
+
+	signin := func() int {
+		req := TOTPSignInRequest{MFAToken: mfaToken(t, h, "192.0.2.1"), Code: recovery[0].(string)}
+		return request(t, h, http.MethodPost, "/api/signin/totp", "", req).Code
+	}
+	assert.Equal(t, http.StatusOK, signin())
+	assert.Equal(t, http.StatusUnauthorized, signin())
+}
+
+func TestTOTPDisable(t *testing.T) {
+	h := newTestAPI(t, nil)
+	token := signIn(t, h, "", testPassword)
+	secret := enableTOTP(t, h, token)
+
+	rec := request(t, h, http.MethodGet, "/api/totp", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, true, decode(t, rec)["enabled"])
+
+	// Отключение требует и пароль, и второй фактор
+	code, err := auth.TOTPCode(secret, time.Now().Unix()/30+1)
+	require.NoError(t, err)
+	rec = request(t, h, http.MethodPost, "/api/totp/disable", token, TOTPReq{Code: code, Password: "wrong"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = request(t, h, http.MethodPost, "/api/totp/disable", token, TOTPReq{Code: "000000", Password: testPassword})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = request(t, h, http.MethodPost, "/api/totp/disable", token, TOTPReq{Code: code, Password: testPassword})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	signIn(t, h, "", testPassword)
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"go1f/pkg/apperr"
+	"go1f/pkg/auth"
+	"go1f/pkg/db"
+)
+
+// loginRe ограничивает допустимые логины пользователей
+var loginRe = regexp.MustCompile(`^[A-Za-z0-9_.@-]{3,64}$`)
+
+// MinPasswordLength минимальная длина пароля пользователя
+const MinPasswordLength = 8
+
+// UserReq структура для запроса создания пользователя
+type UserReq struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+// signupHandler обрабатывает самостоятельную регистрацию пользователя
+func signupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !cfg.Signup {
+		writeJSONError(w, apperr.SignupDisabled, "Signup is disabled")
+		return
+	}
+
+	var req UserReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	// Самостоятельно зарегистрированный пользователь не может быть администратором
+	req.Admin = false
+	id, err := createUser(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{"id": id}, http.StatusOK)
+}
+
+// requireAdmin проверяет, что запрос выполняет администратор, иначе отвечает 403
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	user, err := db.GetUser(currentUser(r))
+	if err != nil || !user.Admin {
+		writeJSONError(w, apperr.AdminRequired, "Administrator access required")
+		return false
+	}
+	return true
+}
+
+// adminOnly пропускает к обработчику только администраторов
+func adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if requireAdmin(w, r) {
+			next(w, r)
+		}
+	}
+}
+
+// usersHandler обрабатывает управление пользователями администратором
+func usersHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		users, err := db.Users()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSONSuccess(w, map[string]interface{}{"users": users}, http.StatusOK)
+	case http.MethodPost:
+		var req UserReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+			return
+		}
+		id, err := createUser(req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSONSuccess(w, map[string]interface{}{"id": id}, http.StatusOK)
+	default:
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+	}
+}
+
+// createUser проверяет данные и создаёт учётную запись
+func createUser(req UserReq) (int64, error) {
+	if !loginRe.MatchString(req.Login) {
+		return 0, apperr.New(apperr.InvalidLogin, "login must be 3-64 letters, digits or _.@- characters")
+	}
+
+	if err := validatePassword(req.Password); err != nil {
+		return 0, err
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	return db.AddUser(&db.User{Login: req.Login, PasswordHash: hash, Admin: req.Admin})
+}
+
+// validatePassword проверяет требования к новому паролю
+func validatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return apperr.Errorf(apperr.WeakPassword, "password must be at least %d characters", MinPasswordLength)
+	}
+	return nil
+}
+
+// ChangePasswordReq структура для запроса смены пароля
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// passwordHandler обрабатывает смену пароля текущего пользователя
+func passwordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, apperr.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req ChangePasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, apperr.InvalidJSON, "JSON decoding error: "+err.Error())
+		return
+	}
+
+	user, err := db.GetUser(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !auth.CheckPassword(user.PasswordHash, req.OldPassword) {
+		writeJSONError(w, apperr.WrongPassword, "Invalid password")
+		return
+	}
+
+	if err := validatePassword(req.NewPassword); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := db.SetPasswordHash(user.ID, hash); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// После смены пароля остальные сессии пользователя должны войти заново
+	if err := db.RevokeOtherSessions(user.ID, currentSession(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSONSuccess(w, map[string]interface{}{}, http.StatusOK)
+}
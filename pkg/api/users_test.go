@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/auth"
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+)
+
+// addUser создаёт пользователя от имени администратора
+func addUser(t *testing.T, h http.Handler, adminToken, login, password string) {
+	t.Helper()
+
+	rec := request(t, h, http.MethodPost, "/api/users", adminToken, UserReq{Login: login, Password: password})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestTasksScopedByOwner(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "alice", "alice-password")
+	addUser(t, h, admin, "bob", "bob-password")
+	alice := signIn(t, h, "alice", "alice-password")
+	bob := signIn(t, h, "bob", "bob-password")
+
+	id := addTask(t, h, alice, "Задача Алисы")
+
+	// Чужая задача для пользователя не существует
+	for _, rec := range []*httptest.ResponseRecorder{
+		request(t, h, http.MethodGet, "/api/task?id="+id, bob, nil),
+		request(t, h, http.MethodPut, "/api/task", bob, map[string]string{"id": id, "date": "20300101", "title": "Чужая"}),
+		request(t, h, http.MethodDelete, "/api/task?id="+id, bob, nil),
+		request(t, h, http.MethodPost, "/api/task/done?id="+id, bob, nil),
+	} {
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "task_not_found", decode(t, rec)["code"])
+	}
+
+	rec := request(t, h, http.MethodGet, "/api/tasks", bob, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, decode(t, rec)["tasks"])
+
+	rec = request(t, h, http.MethodGet, "/api/task?id="+id, alice, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "Задача Алисы", decode(t, rec)["title"])
+}
+
+func TestUsersAdminOnly(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "alice", "alice-password")
+	alice := signIn(t, h, "alice", "alice-password")
+
+	rec := request(t, h, http.MethodPost, "/api/users", alice, UserReq{Login: "mallory", Password: "password", Admin: true})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = request(t, h, http.MethodGet, "/api/users", alice, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = request(t, h, http.MethodPost, "/api/users", admin, UserReq{Login: "a", Password: "password"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = request(t, h, http.MethodPost, "/api/users", admin, UserReq{Login: "alice", Password: "password"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "user_exists", decode(t, rec)["code"])
+
+	rec = request(t, h, http.MethodPost, "/api/signin", "", SignInRequest{Login: "alice", Password: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSignup(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	rec := request(t, h, http.MethodPost, "/api/signup", "", UserReq{Login: "alice", Password: "alice-password"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	cfg.Signup = true
+	rec = request(t, h, http.MethodPost, "/api/signup", "", UserReq{Login: "alice", Password: "alice-password", Admin: true})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Самостоятельно зарегистрированный пользователь не получает прав администратора
+	alice := signIn(t, h, "alice", "alice-password")
+	rec = request(t, h, http.MethodGet, "/api/users", alice, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestChangePassword(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+	addUser(t, h, admin, "alice", "alice-password")
+	alice := signIn(t, h, "alice", "alice-password")
+
+	other := signIn(t, h, "alice", "alice-password")
+
+	rec := request(t, h, http.MethodPost, "/api/password", alice, ChangePasswordReq{OldPassword: "wrong-password", NewPassword: "new-password"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "wrong_password", decode(t, rec)["code"])
+
+	rec = request(t, h, http.MethodPost, "/api/password", alice, ChangePasswordReq{OldPassword: "alice-password", NewPassword: "short"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "weak_password", decode(t, rec)["code"])
+
+	rec = request(t, h, http.MethodPost, "/api/password", alice, ChangePasswordReq{OldPassword: "alice-password", NewPassword: "new-password"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Сессия, в которой сменили пароль, продолжает работать, остальные завершаются
+	assert.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/api/tasks", alice, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, request(t, h, http.MethodGet, "/api/tasks", other, nil).Code)
+
+	rec = request(t, h, http.MethodPost, "/api/signin", "", SignInRequest{Login: "alice", Password: "alice-password"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	signIn(t, h, "alice", "new-password")
+}
+
+func TestAdminPasswordStoredOnce(t *testing.T) {
+	h := newTestAPI(t, nil)
+	admin := signIn(t, h, "", testPassword)
+
+	rec := request(t, h, http.MethodPost, "/api/password", admin, ChangePasswordReq{OldPassword: testPassword, NewPassword: "new-password"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Пароль из окружения нужен только при первом запуске и не возвращает старый пароль
+	require.NoError(t, initAdminPassword())
+	rec = request(t, h, http.MethodPost, "/api/signin", "", SignInRequest{Password: testPassword})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	signIn(t, h, "", "new-password")
+}
+
+func TestAdminPasswordNotStoredWithoutAuth(t *testing.T) {
+	newTestAPI(t, func(c *config.Config) { c.Password = "" })
+
+	admin, err := db.GetUser(db.AdminID)
+	require.NoError(t, err)
+	assert.Empty(t, admin.PasswordHash)
+
+	// Хеш пустого пароля от прежних версий заменяется паролем из настроек после включения аутентификации
+	hash, err := auth.HashPassword("")
+	require.NoError(t, err)
+	require.NoError(t, db.SetPasswordHash(db.AdminID, hash))
+
+	cfg.Password = testPassword
+	auth.Configure(true, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	t.Cleanup(func() { auth.Configure(true, cfg.AccessTokenTTL, cfg.RefreshTokenTTL) })
+	require.NoError(t, initAdminPassword())
+
+	admin, err = db.GetUser(db.AdminID)
+	require.NoError(t, err)
+	assert.True(t, auth.CheckPassword(admin.PasswordHash, testPassword))
+}
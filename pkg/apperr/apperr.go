@@ -0,0 +1,132 @@
+// Package apperr описывает ошибки приложения со стабильными кодами.
+// Клиенты различают ошибки по коду, текст ошибки предназначен только для человека
+// и может меняться. Ошибки без кода считаются внутренними и не показываются клиенту
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code стабильный машиночитаемый код ошибки
+type Code string
+
+// Общие коды
+const (
+	Internal         Code = "internal"
+	BadRequest       Code = "bad_request"
+	InvalidJSON      Code = "invalid_json"
+	MethodNotAllowed Code = "method_not_allowed"
+	Unauthorized     Code = "unauthorized"
+	Forbidden        Code = "forbidden"
+	NotFound         Code = "not_found"
+	Conflict         Code = "conflict"
+	TooManyRequests  Code = "too_many_requests"
+)
+
+// Аутентификация и доступ
+const (
+	InvalidCredentials Code = "invalid_credentials"
+	WrongPassword      Code = "wrong_password"
+	InvalidToken       Code = "invalid_token"
+	CSRFFailed         Code = "csrf_failed"
+	ReadOnlyToken      Code = "read_only_token"
+	AdminRequired      Code = "admin_required"
+	SignupDisabled     Code = "signup_disabled"
+	InvalidTOTPCode    Code = "invalid_totp_code"
+	TOTPConfirmFailed  Code = "totp_confirm_failed"
+	MFAExpired         Code = "mfa_expired"
+	TOTPNotConfigured  Code = "totp_not_configured"
+	TOTPAlreadyEnabled Code = "totp_already_enabled"
+	OIDCDisabled       Code = "oidc_disabled"
+	OIDCFailed         Code = "oidc_failed"
+	OIDCInvalidState   Code = "oidc_invalid_state"
+	OIDCUserDenied     Code = "oidc_user_denied"
+	OIDCAlreadyLinked  Code = "oidc_already_linked"
+)
+
+// Задачи и их данные
+const (
+	IDRequired            Code = "id_required"
+	TaskNotFound          Code = "task_not_found"
+	TitleRequired         Code = "title_required"
+	InvalidDate           Code = "invalid_date"
+	InvalidRepeat         Code = "invalid_repeat"
+	InvalidStatus         Code = "invalid_status"
+	InvalidTransition     Code = "invalid_transition"
+	TaskBlocked           Code = "task_blocked"
+	ChecklistItemNotFound Code = "checklist_item_not_found"
+	InvalidChecklistOrder Code = "invalid_checklist_order"
+	DependencyNotFound    Code = "dependency_not_found"
+	InvalidDependency     Code = "invalid_dependency"
+	AttachmentNotFound    Code = "attachment_not_found"
+	FileTooLarge          Code = "file_too_large"
+	QuotaExceeded         Code = "quota_exceeded"
+	FieldNotFound         Code = "field_not_found"
+	FieldExists           Code = "field_exists"
+	InvalidField          Code = "invalid_field"
+	InvalidFieldValue     Code = "invalid_field_value"
+)
+
+// Пользователи, списки, сессии и токены
+const (
+	UserNotFound    Code = "user_not_found"
+	UserExists      Code = "user_exists"
+	InvalidLogin    Code = "invalid_login"
+	WeakPassword    Code = "weak_password"
+	ListNotFound    Code = "list_not_found"
+	MemberNotFound  Code = "member_not_found"
+	InvalidRole     Code = "invalid_role"
+	SessionNotFound Code = "session_not_found"
+	TokenNotFound   Code = "token_not_found"
+	InvalidScope    Code = "invalid_scope"
+)
+
+// Error ошибка приложения с кодом
+type Error struct {
+	Code    Code
+	Message string
+	Err     error // Исходная ошибка, если есть
+}
+
+// Error возвращает текст ошибки вместе с исходной ошибкой
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New создаёт ошибку с кодом
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Errorf создаёт ошибку с кодом и форматированным текстом
+func Errorf(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap присваивает код исходной ошибке, её текст добавляется к message
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// CodeOf возвращает код ошибки или Internal, если у ошибки нет кода
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Internal
+}
+
+// Is сообщает, что ошибка имеет указанный код
+func Is(err error, code Code) bool {
+	return CodeOf(err) == code
+}
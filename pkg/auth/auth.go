@@ -1,46 +1,55 @@
 package auth
 
 import (
-	"crypto/sha256"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// secretKey будет вычисляться на основе пароля
-func getSecretKey() []byte {
-	password := getPassword()
-	hash := sha256.Sum256([]byte(password))
-	return hash[:]
-}
+// MFATokenTTL время на ввод второго фактора после пароля
+const MFATokenTTL = 5 * time.Minute
 
-func getPassword() string {
-	password := os.Getenv("TODO_PASSWORD")
-	if password == "" {
-		return "qwerty123" // пароль по умолчанию
-	}
-	return password
+// Параметры токенов, задаются при запуске через Configure
+var (
+	AccessTokenTTL  = 15 * time.Minute    // Время жизни access-токена
+	RefreshTokenTTL = 30 * 24 * time.Hour // Время жизни сессии без обновления
+	authEnabled     = true
+)
+
+// mfaAudience назначение токена, подтверждающего только первый шаг входа
+const mfaAudience = "mfa"
+
+// Configure задаёт параметры аутентификации из настроек приложения
+// Вызывается один раз при запуске, до обработки запросов
+func Configure(enabled bool, accessTTL, refreshTTL time.Duration) {
+	authEnabled = enabled
+	AccessTokenTTL = accessTTL
+	RefreshTokenTTL = refreshTTL
 }
 
 // Claims структура для JWT claims
 type Claims struct {
-	Login string `json:"login"`
+	UserID    string `json:"uid"`
+	Login     string `json:"login"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken создает JWT токен
-func GenerateToken() (string, error) {
+// GenerateToken создает access-токен для сессии пользователя
+func GenerateToken(userID, login, sessionID string) (string, error) {
 	if !IsAuthEnabled() {
 		return "no_auth", nil
 	}
 
-	// Создаем claims с информацией
+	// Создаем claims с информацией о пользователе
 	claims := &Claims{
-		Login: "todo_user", 
+		UserID:    userID,
+		Login:     login,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(8 * time.Hour)),
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "todo_app",
@@ -50,8 +59,13 @@ func GenerateToken() (string, error) {
 	// Создаем токен с claims
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	
-	// Подписываем токен ключом на основе пароля
-	signedToken, err := token.SignedString(getSecretKey())
+	// Подписываем токен текущим ключом и указываем его идентификатор
+	key, ok := currentSigningKey()
+	if !ok {
+		return "", fmt.Errorf("signing key is not set")
+	}
+	token.Header["kid"] = key.ID
+	signedToken, err := token.SignedString(key.Secret)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -59,29 +73,81 @@ func GenerateToken() (string, error) {
 	return signedToken, nil
 }
 
-// ValidateToken проверяет JWT токен
-func ValidateToken(tokenString string) (bool, error) {
-	if !IsAuthEnabled() {
-		return true, nil
-	}
-
+// ValidateToken проверяет JWT токен и возвращает его claims
+func ValidateToken(tokenString string) (*Claims, error) {
 	// Парсим токен
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		// Проверяем алгоритм подписи
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return getSecretKey(), nil
+		kid, _ := token.Header["kid"].(string)
+		key, ok := verificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", kid)
+		}
+		return key.Secret, nil
 	})
 
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+
+	if !token.Valid || claims.UserID == "" || claims.SessionID == "" {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return token.Valid, nil
+	return claims, nil
 }
 
 // IsAuthEnabled проверяет включена ли аутентификация
 func IsAuthEnabled() bool {
-	return getPassword() != ""
-}
\ No newline at end of file
+	return authEnabled
+}
+
+// GenerateMFAToken создаёт короткий токен после проверки пароля для второго шага входа
+// Токен не содержит сессии, поэтому не принимается как access-токен
+func GenerateMFAToken(userID string) (string, error) {
+	key, ok := currentSigningKey()
+	if !ok {
+		return "", fmt.Errorf("signing key is not set")
+	}
+
+	now := time.Now()
+	claims := &jwt.RegisteredClaims{
+		Subject:   userID,
+		Audience:  jwt.ClaimStrings{mfaAudience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(MFATokenTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    "todo_app",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = key.ID
+	signedToken, err := token.SignedString(key.Secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signedToken, nil
+}
+
+// ValidateMFAToken проверяет токен второго шага входа и возвращает ID пользователя
+func ValidateMFAToken(tokenString string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := verificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", kid)
+		}
+		return key.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(mfaAudience))
+	if err != nil {
+		return "", err
+	}
+
+	if !token.Valid || claims.Subject == "" {
+		return "", fmt.Errorf("invalid token")
+	}
+	return claims.Subject, nil
+}
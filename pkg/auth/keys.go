@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// SigningKey ключ подписи токенов с идентификатором, передаваемым в заголовке kid
+type SigningKey struct {
+	ID        string
+	Secret    []byte
+	RetiredAt time.Time // нулевое значение у действующего ключа
+}
+
+var (
+	signingKeys   []SigningKey // Первый ключ используется для подписи, остальные только для проверки
+	signingKeysMu sync.RWMutex // Мьютекс для защиты набора ключей
+)
+
+// SetSigningKeys задаёт набор ключей, первый из них используется для подписи новых токенов
+func SetSigningKeys(keys []SigningKey) {
+	signingKeysMu.Lock()
+	defer signingKeysMu.Unlock()
+	signingKeys = keys
+}
+
+// currentSigningKey возвращает ключ для подписи новых токенов
+func currentSigningKey() (SigningKey, bool) {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	if len(signingKeys) == 0 || !signingKeys[0].RetiredAt.IsZero() {
+		return SigningKey{}, false
+	}
+	return signingKeys[0], true
+}
+
+// verificationKey возвращает ключ для проверки токена по kid
+// Выведенный из работы ключ принимается, пока не истекут подписанные им токены
+func verificationKey(id string) (SigningKey, bool) {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	for _, key := range signingKeys {
+		if key.ID != id {
+			continue
+		}
+		if !key.RetiredAt.IsZero() && time.Since(key.RetiredAt) > AccessTokenTTL {
+			return SigningKey{}, false
+		}
+		return key, true
+	}
+	return SigningKey{}, false
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig параметры клиента OpenID Connect
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// OIDCIdentity пользователь, подтверждённый провайдером
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// OIDCProvider выполняет вход через провайдера по коду авторизации с PKCE
+type OIDCProvider struct {
+	issuer   string
+	verifier *oidc.IDTokenVerifier
+	oauth    oauth2.Config
+}
+
+// NewOIDCProvider получает настройки провайдера из /.well-known/openid-configuration
+func NewOIDCProvider(ctx context.Context, cfg OIDCConfig) (*OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+
+	return &OIDCProvider{
+		issuer:   cfg.Issuer,
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+	}, nil
+}
+
+// OIDCState одноразовые значения, связывающие переход к провайдеру с возвратом от него
+type OIDCState struct {
+	State    string // Защита от подделки запроса на callback
+	Nonce    string // Привязка ID-токена к этому входу
+	Verifier string // Секрет PKCE
+}
+
+// NewOIDCState создаёт значения для нового входа через провайдера
+func NewOIDCState() (OIDCState, error) {
+	state, err := randomToken()
+	if err != nil {
+		return OIDCState{}, err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return OIDCState{}, err
+	}
+	return OIDCState{State: state, Nonce: nonce, Verifier: oauth2.GenerateVerifier()}, nil
+}
+
+// AuthCodeURL возвращает адрес страницы входа провайдера
+func (p *OIDCProvider) AuthCodeURL(s OIDCState) string {
+	return p.oauth.AuthCodeURL(s.State, oidc.Nonce(s.Nonce), oauth2.S256ChallengeOption(s.Verifier))
+}
+
+// Exchange обменивает код авторизации на ID-токен и проверяет его
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, s OIDCState) (*OIDCIdentity, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(s.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("no id_token in token response")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if idToken.Nonce != s.Nonce {
+		return nil, fmt.Errorf("invalid ID token nonce")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+
+	return &OIDCIdentity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash хеш с той же стоимостью bcrypt, что и у настоящих паролей.
+// Проверка по нему занимает столько же времени, поэтому по времени ответа нельзя узнать,
+// существует ли логин и задан ли у пользователя пароль
+const dummyHash = "$2a$10$3SPrbOkxqMOzMIpsTy9PL.I3JW0D.R/PR7L7Gv884Ps/oYYRGzQT."
+
+// HashPassword возвращает bcrypt-хеш пароля для хранения в базе
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword проверяет пароль по сохранённому хешу
+// Сравнение выполняется за постоянное время. Пустой пароль и пустой хеш никогда не подходят,
+// но и для них bcrypt выполняется по фиксированному хешу. Для неизвестного логина передаётся пустой хеш
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil && password != ""
+}
+
+// IsEmptyPasswordHash проверяет, что хеш получен из пустого пароля
+// Такие хеши могли остаться после запуска с отключённой аутентификацией
+func IsEmptyPasswordHash(hash string) bool {
+	return hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), nil) == nil
+}
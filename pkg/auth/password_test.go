@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("secret123")
+	require.NoError(t, err)
+
+	assert.True(t, CheckPassword(hash, "secret123"))
+	assert.False(t, CheckPassword(hash, "secret"))
+	assert.False(t, CheckPassword("", ""))
+	assert.False(t, IsEmptyPasswordHash(hash))
+
+	// Хеш пустого пароля не подходит даже к пустому паролю
+	empty, err := HashPassword("")
+	require.NoError(t, err)
+	assert.False(t, CheckPassword(empty, ""))
+	assert.True(t, IsEmptyPasswordHash(empty))
+	assert.False(t, IsEmptyPasswordHash(""))
+}
+
+func TestCheckPasswordWithoutHashTakesBcryptTime(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyHash))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.DefaultCost, cost)
+
+	hash, err := HashPassword("secret123")
+	require.NoError(t, err)
+
+	start := time.Now()
+	CheckPassword(hash, "wrong")
+	known := time.Since(start)
+
+	// Неизвестный логин отклоняется не быстрее существующего
+	start = time.Now()
+	assert.False(t, CheckPassword("", "wrong"))
+	assert.False(t, CheckPassword("", ""))
+	assert.Greater(t, time.Since(start), known/2)
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// APITokenPrefix начало персональных API-токенов, по нему они отличаются от JWT
+const APITokenPrefix = "todo_"
+
+// randomToken возвращает 32 случайных байта в base64url
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// NewRefreshToken создаёт случайный refresh-токен и возвращает его вместе с хешем для хранения
+func NewRefreshToken() (token, hash string, err error) {
+	token, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashToken(token), nil
+}
+
+// NewAPIToken создаёт персональный API-токен и возвращает его вместе с хешем для хранения
+func NewAPIToken() (token, hash string, err error) {
+	token, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	token = APITokenPrefix + token
+	return token, HashToken(token), nil
+}
+
+// IsAPIToken проверяет, что строка похожа на персональный API-токен
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// HashToken возвращает хеш токена, под которым он хранится в базе
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret ключ SHA-1 из тестовых векторов RFC 6238
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// Векторы RFC 6238 (приложение B) для SHA-1; у шестизначного кода остаются последние цифры
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := TOTPCode(rfcSecret, tt.unix/TOTPPeriod)
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "time %d", tt.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / TOTPPeriod
+
+	for _, s := range []int64{step - 1, step, step + 1} {
+		code, err := TOTPCode(rfcSecret, s)
+		require.NoError(t, err)
+		got, ok := ValidateTOTP(rfcSecret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, s, got)
+	}
+
+	// Коды за пределами допустимого расхождения часов не принимаются
+	code, err := TOTPCode(rfcSecret, step+2)
+	require.NoError(t, err)
+	_, ok := ValidateTOTP(rfcSecret, code, now)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPSecretLowercase(t *testing.T) {
+	upper, err := TOTPCode(rfcSecret, 1)
+	require.NoError(t, err)
+	lower, err := TOTPCode("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1)
+	require.NoError(t, err)
+	assert.Equal(t, upper, lower)
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config настройки приложения.
+// Значения берутся по возрастанию приоритета: значения по умолчанию, YAML-файл,
+// переменные окружения, флаги командной строки
+type Config struct {
+	Port   int    `yaml:"port"`    // TODO_PORT, -port
+	DBFile string `yaml:"db_file"` // TODO_DBFILE, -db
+	WebDir string `yaml:"web_dir"` // TODO_WEB_DIR, -web; пустая строка — встроенный интерфейс
+
+	Password        string        `yaml:"password"`          // TODO_PASSWORD, начальный пароль admin; пустая строка отключает аутентификацию
+	Signup          bool          `yaml:"signup"`            // TODO_SIGNUP, -signup
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`  // TODO_ACCESS_TOKEN_TTL, -access-token-ttl
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"` // TODO_REFRESH_TOKEN_TTL, -refresh-token-ttl
+
+	TasksLimit int `yaml:"tasks_limit"` // TODO_TASKS_LIMIT, -tasks-limit
+
+	AttachmentsDir    string `yaml:"attachments_dir"`     // TODO_ATTACHMENTS_DIR, -attachments-dir
+	AttachmentMaxSize int64  `yaml:"attachment_max_size"` // TODO_ATTACHMENT_MAX_SIZE, в байтах
+	AttachmentQuota   int64  `yaml:"attachment_quota"`    // TODO_ATTACHMENT_QUOTA, в байтах на владельца списка задач
+
+	ReadTimeout     time.Duration `yaml:"read_timeout"`     // TODO_READ_TIMEOUT
+	WriteTimeout    time.Duration `yaml:"write_timeout"`    // TODO_WRITE_TIMEOUT
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`     // TODO_IDLE_TIMEOUT
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // TODO_SHUTDOWN_TIMEOUT
+
+	MetricsAddr string `yaml:"metrics_addr"` // TODO_METRICS_ADDR, -metrics-addr; пустая строка отключает /metrics
+
+	TLS  TLS  `yaml:"tls"`
+	OIDC OIDC `yaml:"oidc"`
+	Log  Log  `yaml:"log"`
+}
+
+// TLS настройки HTTPS
+type TLS struct {
+	CertFile     string `yaml:"cert_file"`     // TODO_TLS_CERT, -tls-cert
+	KeyFile      string `yaml:"key_file"`      // TODO_TLS_KEY, -tls-key
+	SelfSigned   bool   `yaml:"self_signed"`   // TODO_TLS_SELF_SIGNED, создать самоподписанный сертификат, если файлов нет
+	RedirectPort int    `yaml:"redirect_port"` // TODO_TLS_REDIRECT_PORT, порт HTTP для перенаправления на HTTPS, 0 отключает
+}
+
+// Enabled проверяет, работает ли сервер по HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.SelfSigned
+}
+
+// OIDC настройки входа через провайдера OpenID Connect
+type OIDC struct {
+	Issuer       string `yaml:"issuer"`        // TODO_OIDC_ISSUER, пустая строка отключает вход через провайдера
+	ClientID     string `yaml:"client_id"`     // TODO_OIDC_CLIENT_ID
+	ClientSecret string `yaml:"client_secret"` // TODO_OIDC_CLIENT_SECRET
+	RedirectURL  string `yaml:"redirect_url"`  // TODO_OIDC_REDIRECT_URL
+	Signup       bool   `yaml:"signup"`        // TODO_OIDC_SIGNUP
+}
+
+// Log настройки журнала
+type Log struct {
+	Format string `yaml:"format"` // TODO_LOG_FORMAT, -log-format: text или json
+	Level  string `yaml:"level"`  // TODO_LOG_LEVEL, -log-level: debug, info, warn или error
+}
+
+// Default возвращает настройки по умолчанию
+func Default() *Config {
+	return &Config{
+		Port:   7540,
+		DBFile: "scheduler.db",
+
+		Password:        "qwerty123",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+
+		TasksLimit: 50,
+
+		AttachmentsDir:    "attachments",
+		AttachmentMaxSize: 10 << 20,
+		AttachmentQuota:   50 << 20,
+
+		ReadTimeout:     60 * time.Second,
+		WriteTimeout:    60 * time.Second,
+		IdleTimeout:     120 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+
+		OIDC: OIDC{RedirectURL: "http://localhost:7540/api/oidc/callback"},
+		Log:  Log{Format: "text", Level: "info"},
+	}
+}
+
+// setting связывает поле настроек с переменной окружения и флагом
+// Пустое имя флага означает, что значение нельзя передать в командной строке (например, секреты)
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	value interface{} // указатель на поле Config
+}
+
+// settings перечисляет настройки, которые можно переопределить окружением и флагами
+func (c *Config) settings() []setting {
+	return []setting{
+		{"TODO_PORT", "port", "HTTP port", &c.Port},
+		{"TODO_DBFILE", "db", "SQLite database file", &c.DBFile},
+		{"TODO_WEB_DIR", "web", "serve web UI from this directory instead of the embedded one", &c.WebDir},
+		{"TODO_PASSWORD", "", "", &c.Password},
+		{"TODO_SIGNUP", "signup", "allow self-registration", &c.Signup},
+		{"TODO_ACCESS_TOKEN_TTL", "access-token-ttl", "access token lifetime", &c.AccessTokenTTL},
+		{"TODO_REFRESH_TOKEN_TTL", "refresh-token-ttl", "session lifetime without refresh", &c.RefreshTokenTTL},
+		{"TODO_TASKS_LIMIT", "tasks-limit", "maximum number of tasks in a list", &c.TasksLimit},
+		{"TODO_ATTACHMENTS_DIR", "attachments-dir", "directory for task attachments", &c.AttachmentsDir},
+		{"TODO_ATTACHMENT_MAX_SIZE", "attachment-max-size", "maximum attachment size in bytes", &c.AttachmentMaxSize},
+		{"TODO_ATTACHMENT_QUOTA", "attachment-quota", "maximum attachments size per task list owner in bytes", &c.AttachmentQuota},
+		{"TODO_READ_TIMEOUT", "read-timeout", "HTTP read timeout", &c.ReadTimeout},
+		{"TODO_WRITE_TIMEOUT", "write-timeout", "HTTP write timeout", &c.WriteTimeout},
+		{"TODO_IDLE_TIMEOUT", "idle-timeout", "HTTP keep-alive idle timeout", &c.IdleTimeout},
+		{"TODO_SHUTDOWN_TIMEOUT", "shutdown-timeout", "time to finish in-flight requests on shutdown", &c.ShutdownTimeout},
+		{"TODO_METRICS_ADDR", "metrics-addr", "separate listen address for Prometheus metrics, e.g. 127.0.0.1:9090", &c.MetricsAddr},
+		{"TODO_TLS_CERT", "tls-cert", "TLS certificate file", &c.TLS.CertFile},
+		{"TODO_TLS_KEY", "tls-key", "TLS private key file", &c.TLS.KeyFile},
+		{"TODO_TLS_SELF_SIGNED", "tls-self-signed", "generate a self-signed certificate when files are missing", &c.TLS.SelfSigned},
+		{"TODO_TLS_REDIRECT_PORT", "tls-redirect-port", "plain HTTP port redirecting to HTTPS", &c.TLS.RedirectPort},
+		{"TODO_OIDC_ISSUER", "oidc-issuer", "OpenID Connect issuer URL", &c.OIDC.Issuer},
+		{"TODO_OIDC_CLIENT_ID", "oidc-client-id", "OpenID Connect client ID", &c.OIDC.ClientID},
+		{"TODO_OIDC_CLIENT_SECRET", "", "", &c.OIDC.ClientSecret},
+		{"TODO_OIDC_REDIRECT_URL", "oidc-redirect-url", "OpenID Connect redirect URL", &c.OIDC.RedirectURL},
+		{"TODO_OIDC_SIGNUP", "oidc-signup", "create users on first OpenID Connect login", &c.OIDC.Signup},
+		{"TODO_LOG_FORMAT", "log-format", "log format: text or json", &c.Log.Format},
+		{"TODO_LOG_LEVEL", "log-level", "minimum log level: debug, info, warn or error", &c.Log.Level},
+	}
+}
+
+// set разбирает строковое значение в поле настроек
+func set(value interface{}, s string) error {
+	switch v := value.(type) {
+	case *string:
+		*v = s
+	case *bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*v = b
+	case *int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*v = n
+	case *int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		*v = n
+	case *time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*v = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", value)
+	}
+	return nil
+}
+
+// Load собирает настройки из файла, окружения и флагов и проверяет их.
+// Путь к файлу задаётся флагом -config или переменной TODO_CONFIG
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	// Флаги применяются последними, поэтому сначала только запоминаем их
+	var flags []func() error
+	fs := flag.NewFlagSet("todo", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("TODO_CONFIG"), "path to YAML config file")
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		s := s
+		record := func(v string) error {
+			flags = append(flags, func() error { return set(s.value, v) })
+			return nil
+		}
+		// Логические флаги можно указывать без значения: -signup вместо -signup=true
+		if _, ok := s.value.(*bool); ok {
+			fs.BoolFunc(s.flag, s.usage+" ("+s.env+")", record)
+		} else {
+			fs.Func(s.flag, s.usage+" ("+s.env+")", record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %w", *configFile, err)
+		}
+	}
+
+	// Заданная пустой переменная окружения очищает строковую настройку (например, TODO_PASSWORD=
+	// отключает аутентификацию); для чисел, флагов и длительностей пустое значение игнорируется
+	for _, s := range settings {
+		v, ok := os.LookupEnv(s.env)
+		if _, isString := s.value.(*string); !ok || (v == "" && !isString) {
+			continue
+		}
+		if err := set(s.value, v); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", s.env, err)
+		}
+	}
+
+	for _, apply := range flags {
+		if err := apply(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate проверяет согласованность настроек
+func (c *Config) Validate() error {
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", c.Port)
+	}
+	if c.DBFile == "" {
+		return fmt.Errorf("database file is not set")
+	}
+	if c.WebDir != "" {
+		if info, err := os.Stat(c.WebDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("web directory not found: %s", c.WebDir)
+		}
+	}
+	if c.AccessTokenTTL <= 0 || c.RefreshTokenTTL < c.AccessTokenTTL {
+		return fmt.Errorf("token lifetimes must be positive and refresh_token_ttl not shorter than access_token_ttl")
+	}
+	if c.TasksLimit <= 0 {
+		return fmt.Errorf("tasks_limit must be positive")
+	}
+	if c.AttachmentsDir == "" || c.AttachmentMaxSize <= 0 || c.AttachmentQuota < c.AttachmentMaxSize {
+		return fmt.Errorf("attachments_dir must be set and attachment_quota not smaller than attachment_max_size")
+	}
+	for name, d := range map[string]time.Duration{
+		"read_timeout": c.ReadTimeout, "write_timeout": c.WriteTimeout,
+		"idle_timeout": c.IdleTimeout, "shutdown_timeout": c.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("tls cert_file and key_file must be set together")
+	}
+	if c.TLS.RedirectPort != 0 && (!c.TLS.Enabled() || c.TLS.RedirectPort == c.Port || c.TLS.RedirectPort < 0 || c.TLS.RedirectPort > 65535) {
+		return fmt.Errorf("invalid tls redirect_port: %d", c.TLS.RedirectPort)
+	}
+	if c.OIDC.Issuer != "" && c.OIDC.ClientID == "" {
+		return fmt.Errorf("oidc client_id is required with oidc issuer")
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		return fmt.Errorf("log format must be text or json")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		return fmt.Errorf("invalid log level: %s", c.Log.Level)
+	}
+	return nil
+}
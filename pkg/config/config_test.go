@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig сохраняет YAML-файл настроек во временный каталог
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	return path
+}
+
+// clearEnv убирает переменные окружения настроек, чтобы тест не зависел от окружения
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("TODO_CONFIG", "")
+	for _, s := range Default().settings() {
+		t.Setenv(s.env, "")
+		os.Unsetenv(s.env)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+
+	path := writeConfig(t, `
+port: 8000
+db_file: yaml.db
+tasks_limit: 10
+access_token_ttl: 5m
+log:
+  level: debug
+`)
+	t.Setenv("TODO_PORT", "9000")
+	t.Setenv("TODO_DBFILE", "env.db")
+
+	cfg, err := Load([]string{"-config", path, "-port", "9100"})
+	require.NoError(t, err)
+
+	// Флаг важнее окружения, окружение важнее файла, файл важнее значений по умолчанию
+	assert.Equal(t, 9100, cfg.Port)
+	assert.Equal(t, "env.db", cfg.DBFile)
+	assert.Equal(t, 10, cfg.TasksLimit)
+	assert.Equal(t, 5*time.Minute, cfg.AccessTokenTTL)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, Default().RefreshTokenTTL, cfg.RefreshTokenTTL)
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("TODO_CONFIG", writeConfig(t, "port: 8000\n"))
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 8000, cfg.Port)
+}
+
+func TestLoadBoolFlags(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("TODO_SIGNUP", "false")
+
+	// Логический флаг без значения включает настройку и перекрывает окружение
+	cfg, err := Load([]string{"-signup"})
+	require.NoError(t, err)
+	assert.True(t, cfg.Signup)
+
+	cfg, err = Load([]string{"-signup=false", "-oidc-signup=true"})
+	require.NoError(t, err)
+	assert.False(t, cfg.Signup)
+	assert.True(t, cfg.OIDC.Signup)
+}
+
+func TestLoadEmptyEnv(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "password: secret\ntasks_limit: 10\n")
+
+	// Пустая строковая переменная очищает значение из файла, пустое число игнорируется
+	t.Setenv("TODO_PASSWORD", "")
+	t.Setenv("TODO_TASKS_LIMIT", "")
+
+	cfg, err := Load([]string{"-config", path})
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Password)
+	assert.Equal(t, 10, cfg.TasksLimit)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	clearEnv(t)
+
+	t.Setenv("TODO_PORT", "http")
+	_, err := Load(nil)
+	assert.Error(t, err)
+
+	t.Setenv("TODO_PORT", "")
+	_, err = Load([]string{"-config", writeConfig(t, "unknown: 1\n")})
+	assert.Error(t, err)
+
+	_, err = Load([]string{"-tasks-limit", "many"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go1f/pkg/apperr"
+)
+
+// Области действия API-токенов
+const (
+	ScopeRead  = "read"  // Только чтение
+	ScopeWrite = "write" // Чтение и изменение
+)
+
+// APIToken персональный API-токен пользователя для скриптов и интеграций
+type APIToken struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	Name       string `json:"name"`
+	TokenHash  string `json:"-"` // SHA-256 токена, сам токен не хранится
+	Scope      string `json:"scope"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+}
+
+// apiTokenColumns перечисляет колонки, которые читают запросы API-токенов
+const apiTokenColumns = "id, user_id, name, token_hash, scope, created_at, last_used_at"
+
+// scanAPIToken сканирует строку с колонками apiTokenColumns
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (*APIToken, error) {
+	var t APIToken
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.Scope, &t.CreatedAt, &t.LastUsedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.New(apperr.TokenNotFound, "API token not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &t, nil
+}
+
+// AddAPIToken сохраняет API-токен пользователя
+// Возвращает ID токена или ошибку
+func AddAPIToken(t *APIToken) (int64, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	query := "INSERT INTO api_tokens (user_id, name, token_hash, scope, created_at) VALUES (?, ?, ?, ?, ?)"
+	res, err := GetDB().Exec(query, t.UserID, t.Name, t.TokenHash, t.Scope, t.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("insert error: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// GetAPITokenByHash возвращает API-токен по хешу
+func GetAPITokenByHash(hash string) (*APIToken, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+	return scanAPIToken(GetDB().QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = ?", hash))
+}
+
+// APITokens возвращает API-токены пользователя от новых к старым
+func APITokens(userID string) ([]*APIToken, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query("SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = ? ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*APIToken{}
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// TouchAPIToken запоминает время последнего использования токена
+func TouchAPIToken(id, at string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	if _, err := GetDB().Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", at, id); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	return nil
+}
+
+// DeleteAPIToken отзывает API-токен пользователя
+func DeleteAPIToken(userID, id string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.TokenNotFound, "API token not found")
+	}
+
+	return nil
+}
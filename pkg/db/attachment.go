@@ -0,0 +1,202 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go1f/pkg/apperr"
+)
+
+// Attachment описывает файл, прикреплённый к задаче
+type Attachment struct {
+	ID          string `json:"id"`
+	TaskID      string `json:"task_id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	CreatedAt   string `json:"created_at"`
+	Path        string `json:"-"` // путь к файлу относительно каталога вложений
+}
+
+// attachmentsDir каталог, относительно которого хранятся пути файлов вложений
+var attachmentsDir string
+
+// SetAttachmentsDir задаёт каталог вложений, чтобы его можно было перенести, поменяв только настройки
+func SetAttachmentsDir(dir string) {
+	attachmentsDir = dir
+}
+
+// AttachmentFile возвращает путь к файлу вложения на диске
+// Абсолютные пути, сохранённые старыми версиями, возвращаются как есть
+func AttachmentFile(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(attachmentsDir, path)
+}
+
+// AddAttachment сохраняет сведения о файле, прикреплённом к задаче владельца
+// Если вложения всех задач владельца вместе с новым превышают quota байт, вложение не сохраняется
+// Возвращает ID вложения или ошибку
+func AddAttachment(owner string, a *Attachment, quota int64) (int64, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return 0, fmt.Errorf("transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Сначала добавляем запись: она захватывает блокировку базы, и параллельные загрузки
+	// не могут вместе превысить квоту, каждая по отдельности уложившись в неё
+	a.CreatedAt = time.Now().Format(time.RFC3339)
+	query := `INSERT INTO attachments (task_id, name, content_type, size, path, created_at)
+	          SELECT id, ?, ?, ?, ?, ? FROM scheduler WHERE id = ? AND owner = ?`
+	res, err := tx.Exec(query, a.Name, a.ContentType, a.Size, a.Path, a.CreatedAt, a.TaskID, owner)
+	if err != nil {
+		return 0, fmt.Errorf("insert error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("insert check error: %w", err)
+	}
+	if count == 0 {
+		return 0, apperr.New(apperr.TaskNotFound, "task not found")
+	}
+
+	var used int64
+	err = tx.QueryRow("SELECT COALESCE(SUM(size), 0) FROM attachments WHERE task_id IN ("+ownedTasks+")", owner).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+	if used > quota {
+		return 0, apperr.New(apperr.QuotaExceeded, "attachment quota exceeded")
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("insert check error: %w", err)
+	}
+	return id, tx.Commit()
+}
+
+// GetAttachment возвращает вложение задачи владельца по ID
+func GetAttachment(owner string, id string) (*Attachment, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	var a Attachment
+	err := GetDB().QueryRow(
+		"SELECT id, task_id, name, content_type, size, created_at, path FROM attachments WHERE id = ? AND task_id IN ("+ownedTasks+")",
+		id, owner,
+	).Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.CreatedAt, &a.Path)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.New(apperr.AttachmentNotFound, "attachment not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &a, nil
+}
+
+// Attachments возвращает вложения задачи владельца в порядке добавления
+func Attachments(owner string, taskID string) ([]*Attachment, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query(
+		`SELECT id, task_id, name, content_type, size, created_at, path FROM attachments
+         WHERE task_id = ? AND task_id IN (`+ownedTasks+`) ORDER BY id`,
+		taskID, owner,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*Attachment
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.CreatedAt, &a.Path); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		list = append(list, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return list, nil
+}
+
+// AttachmentsSize возвращает суммарный размер вложений всех задач владельца в байтах
+func AttachmentsSize(owner string) (int64, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	var size int64
+	err := GetDB().QueryRow(
+		"SELECT COALESCE(SUM(size), 0) FROM attachments WHERE task_id IN ("+ownedTasks+")",
+		owner,
+	).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+	return size, nil
+}
+
+// DeleteAttachment удаляет вложение задачи владельца и его файл
+func DeleteAttachment(owner string, id string) error {
+	a, err := GetAttachment(owner, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := GetDB().Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	removeFiles([]string{a.Path})
+	return nil
+}
+
+// attachmentPaths возвращает пути к файлам вложений задачи
+func attachmentPaths(tx *sql.Tx, taskID string) ([]string, error) {
+	rows, err := tx.Query("SELECT path FROM attachments WHERE task_id = ?", taskID)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// removeFiles удаляет файлы вложений, ошибки только выводятся в лог,
+// так как запись в базе к этому моменту уже удалена
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		path = AttachmentFile(path)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("failed to remove attachment file", "file", path, "err", err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go1f/pkg/apperr"
+)
+
+// ChecklistItem представляет пункт чек-листа внутри задачи
+type ChecklistItem struct {
+	ID       string `json:"id"`
+	TaskID   string `json:"task_id"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+	Done     bool   `json:"done"`
+}
+
+// AddChecklistItem добавляет пункт в конец чек-листа задачи владельца
+// Возвращает ID добавленного пункта или ошибку
+func AddChecklistItem(owner string, item *ChecklistItem) (int64, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	query := `INSERT INTO checklist (task_id, position, text, done)
+	          SELECT id, (SELECT COALESCE(MAX(position), 0) + 1 FROM checklist WHERE task_id = scheduler.id), ?, ?
+	          FROM scheduler WHERE id = ? AND owner = ?`
+	res, err := GetDB().Exec(query, item.Text, item.Done, item.TaskID, owner)
+	if err != nil {
+		return 0, fmt.Errorf("insert error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("insert check error: %w", err)
+	}
+	if count == 0 {
+		return 0, apperr.New(apperr.TaskNotFound, "task not found")
+	}
+
+	return res.LastInsertId()
+}
+
+// ChecklistItems возвращает пункты чек-листа задачи владельца в заданном порядке
+func ChecklistItems(owner string, taskID string) ([]*ChecklistItem, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query(
+		`SELECT id, task_id, position, text, done FROM checklist
+         WHERE task_id = :task AND task_id IN (SELECT id FROM scheduler WHERE owner = :owner)
+         ORDER BY position, id`,
+		sql.Named("task", taskID),
+		sql.Named("owner", owner),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*ChecklistItem
+	for rows.Next() {
+		var item ChecklistItem
+		if err := rows.Scan(&item.ID, &item.TaskID, &item.Position, &item.Text, &item.Done); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return items, nil
+}
+
+// ToggleChecklistItem инвертирует отметку о выполнении пункта в задаче владельца
+func ToggleChecklistItem(owner string, id string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec("UPDATE checklist SET done = NOT done WHERE id = ? AND task_id IN ("+ownedTasks+")", id, owner)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.ChecklistItemNotFound, "checklist item not found")
+	}
+
+	return nil
+}
+
+// ReorderChecklist задаёт новый порядок пунктов чек-листа задачи владельца
+// ids должен содержать все пункты задачи ровно по одному разу
+func ReorderChecklist(owner string, taskID string, ids []string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int
+	err = tx.QueryRow("SELECT COUNT(*) FROM checklist WHERE task_id = ? AND task_id IN ("+ownedTasks+")", taskID, owner).
+		Scan(&total)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if total != len(ids) {
+		return apperr.New(apperr.InvalidChecklistOrder, "order must list every checklist item of the task")
+	}
+
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		if seen[id] {
+			return apperr.Errorf(apperr.InvalidChecklistOrder, "duplicate checklist item in order: %s", id)
+		}
+		seen[id] = true
+
+		res, err := tx.Exec("UPDATE checklist SET position = ? WHERE id = ? AND task_id = ?", i+1, id, taskID)
+		if err != nil {
+			return fmt.Errorf("update error: %w", err)
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("update check error: %w", err)
+		}
+		if count == 0 {
+			return apperr.Errorf(apperr.InvalidChecklistOrder, "checklist item not found: %s", id)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteChecklistItem удаляет пункт чек-листа задачи владельца по ID
+func DeleteChecklistItem(owner string, id string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec("DELETE FROM checklist WHERE id = ? AND task_id IN ("+ownedTasks+")", id, owner)
+	if err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.ChecklistItemNotFound, "checklist item not found")
+	}
+
+	return nil
+}
+
+// ResetChecklist снимает отметки со всех пунктов чек-листа задачи владельца
+func ResetChecklist(owner string, taskID string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	query := "UPDATE checklist SET done = 0 WHERE task_id = ? AND task_id IN (" + ownedTasks + ")"
+	if _, err := GetDB().Exec(query, taskID, owner); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	return nil
+}
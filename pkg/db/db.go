@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	_ "modernc.org/sqlite"
 	"os"
 	"sync"
@@ -26,6 +28,161 @@ CREATE TABLE scheduler (
 
 CREATE INDEX IF NOT EXISTS idx_date ON scheduler (date);`
 
+// migrations содержит изменения схемы, появившиеся после первой версии.
+// Каждая миграция применяется один раз, номер последней хранится в PRAGMA user_version
+var migrations = []string{
+	// 1: пункты чек-листа задачи
+	`CREATE TABLE IF NOT EXISTS checklist (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    text VARCHAR(256) NOT NULL,
+    done INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_task ON checklist (task_id, position);`,
+	// 2: зависимости между задачами (task_id заблокирована blocker_id)
+	`CREATE TABLE IF NOT EXISTS dependencies (
+    task_id INTEGER NOT NULL,
+    blocker_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, blocker_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dependencies_blocker ON dependencies (blocker_id);`,
+	// 3: история смены статусов задач
+	`CREATE TABLE IF NOT EXISTS status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    changed_at VARCHAR(32) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_task ON status_history (task_id, id);`,
+	// 4: пользовательские поля и их значения у задач
+	`CREATE TABLE IF NOT EXISTS fields (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    type VARCHAR(16) NOT NULL,
+    options TEXT NOT NULL DEFAULT '[]',
+    required INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS field_values (
+    task_id INTEGER NOT NULL,
+    field_id INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (task_id, field_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_field_values_field ON field_values (field_id, value);`,
+	// 5: файлы, прикреплённые к задачам
+	`CREATE TABLE IF NOT EXISTS attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    name VARCHAR(256) NOT NULL,
+    content_type VARCHAR(128) NOT NULL,
+    size INTEGER NOT NULL,
+    path TEXT NOT NULL,
+    created_at VARCHAR(32) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_task ON attachments (task_id);`,
+	// 6: учётные записи пользователей и владелец задачи,
+	// существующие задачи достаются встроенному администратору
+	`CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    login VARCHAR(64) NOT NULL UNIQUE,
+    password_hash VARCHAR(128) NOT NULL DEFAULT '',
+    admin INTEGER NOT NULL DEFAULT 0,
+    created_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+
+INSERT OR IGNORE INTO users (id, login, admin) VALUES (1, 'admin', 1);
+
+ALTER TABLE scheduler ADD COLUMN owner INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_owner_date ON scheduler (owner, date);`,
+	// 7: служебные настройки приложения
+	`CREATE TABLE IF NOT EXISTS settings (
+    name VARCHAR(64) PRIMARY KEY,
+    value TEXT NOT NULL
+);`,
+	// 8: ключи подписи токенов с идентификаторами, прежний ключ из settings становится первым
+	`CREATE TABLE IF NOT EXISTS signing_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kid VARCHAR(32) NOT NULL UNIQUE,
+    secret TEXT NOT NULL,
+    created_at VARCHAR(32) NOT NULL,
+    retired_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+
+INSERT INTO signing_keys (kid, secret, created_at)
+SELECT lower(hex(randomblob(8))), value, strftime('%Y-%m-%dT%H:%M:%SZ', 'now') FROM settings WHERE name = 'jwt_secret';
+
+DELETE FROM settings WHERE name = 'jwt_secret';`,
+	// 9: сессии пользователей с refresh-токенами
+	`CREATE TABLE IF NOT EXISTS sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at VARCHAR(32) NOT NULL,
+    last_used_at VARCHAR(32) NOT NULL,
+    expires_at VARCHAR(32) NOT NULL,
+    user_agent VARCHAR(256) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    revoked INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions (user_id);`,
+	// 10: персональные API-токены пользователей
+	`CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    scope VARCHAR(16) NOT NULL,
+    created_at VARCHAR(32) NOT NULL,
+    last_used_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens (user_id);`,
+	// 11: двухфакторная аутентификация по TOTP и коды восстановления
+	`CREATE TABLE IF NOT EXISTS totp (
+    user_id INTEGER PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 0,
+    last_step INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes (user_id);`,
+	// 12: учётные записи внешних провайдеров OpenID Connect, привязанные к пользователям
+	`CREATE TABLE IF NOT EXISTS oidc_identities (
+    issuer VARCHAR(256) NOT NULL,
+    subject VARCHAR(256) NOT NULL,
+    user_id INTEGER NOT NULL,
+    email VARCHAR(256) NOT NULL DEFAULT '',
+    created_at VARCHAR(32) NOT NULL,
+    PRIMARY KEY (issuer, subject)
+);`,
+	// 13: участники общих списков задач, список задач пользователя обозначается его ID
+	`CREATE TABLE IF NOT EXISTS list_members (
+    list_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    created_at VARCHAR(32) NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_members_user ON list_members (user_id);`,
+}
+
 // Init инициализирует подключение к базе данных и создает схему при необходимости
 func Init(dbFile string) error {
 	dbMu.Lock()
@@ -74,12 +231,59 @@ func Init(dbFile string) error {
 			db = nil
 			return fmt.Errorf("failed to create schema: %w", err)
 		}
-		fmt.Printf("The database was created successfully: %s\n", dbFile)
+		slog.Info("database created", "file", dbFile)
+	}
+
+	// Применяем недостающие миграции
+	if err := migrate(); err != nil {
+		db.Close()
+		db = nil
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return nil
+}
+
+// migrate применяет миграции, которые ещё не были применены к базе
+func migrate() error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
 	}
 
 	return nil
 }
 
+// SchemaVersion возвращает применённую версию схемы и версию, которую ожидает приложение
+func SchemaVersion(ctx context.Context) (current, expected int, err error) {
+	if GetDB() == nil {
+		return 0, len(migrations), fmt.Errorf("database connection is not initialized")
+	}
+
+	if err := GetDB().QueryRowContext(ctx, "PRAGMA user_version").Scan(&current); err != nil {
+		return 0, len(migrations), fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return current, len(migrations), nil
+}
+
 // GetDB возвращает глобальное подключение к базе данных
 func GetDB() *sql.DB {
 	dbMu.RLock()
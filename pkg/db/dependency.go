@@ -0,0 +1,125 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go1f/pkg/apperr"
+)
+
+// AddDependency отмечает, что задача taskID заблокирована задачей blockerID
+// Обе задачи должны принадлежать владельцу. Возвращает ошибку, если зависимость образует цикл
+func AddDependency(owner string, taskID, blockerID string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	if taskID == blockerID {
+		return apperr.New(apperr.InvalidDependency, "task cannot block itself")
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	var owned int
+	err = tx.QueryRow("SELECT COUNT(*) FROM scheduler WHERE id IN (?, ?) AND owner = ?", taskID, blockerID, owner).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if owned != 2 {
+		return apperr.New(apperr.TaskNotFound, "task not found")
+	}
+
+	// Сначала добавляем зависимость: запись сразу захватывает блокировку базы,
+	// и параллельный запрос не сможет добавить встречную зависимость между проверкой и вставкой
+	_, err = tx.Exec("INSERT OR IGNORE INTO dependencies (task_id, blocker_id) VALUES (?, ?)", taskID, blockerID)
+	if err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+
+	// Проверяем, не зависит ли блокирующая задача (в том числе транзитивно) от текущей
+	var cycle bool
+	err = tx.QueryRow(
+		`WITH RECURSIVE chain(id) AS (
+             SELECT blocker_id FROM dependencies WHERE task_id = CAST(:blocker AS INTEGER)
+             UNION
+             SELECT d.blocker_id FROM dependencies d JOIN chain c ON d.task_id = c.id
+         )
+         SELECT EXISTS (SELECT 1 FROM chain WHERE id = CAST(:task AS INTEGER))`,
+		sql.Named("blocker", blockerID),
+		sql.Named("task", taskID),
+	).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if cycle {
+		return apperr.New(apperr.InvalidDependency, "dependency would create a cycle")
+	}
+
+	return tx.Commit()
+}
+
+// DeleteDependency снимает блокировку задачи владельца taskID задачей blockerID
+func DeleteDependency(owner string, taskID, blockerID string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec(
+		"DELETE FROM dependencies WHERE task_id = ? AND blocker_id = ? AND task_id IN ("+ownedTasks+")",
+		taskID, blockerID, owner,
+	)
+	if err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.DependencyNotFound, "dependency not found")
+	}
+
+	return nil
+}
+
+// openBlocker отбирает блокирующие задачи b, которые ещё не переведены в статус done
+const openBlocker = `COALESCE((SELECT status FROM status_history WHERE task_id = b.id ORDER BY id DESC LIMIT 1), '` +
+	StatusTodo + `') != '` + StatusDone + `'`
+
+// Blockers возвращает ID незавершённых задач, блокирующих задачу владельца taskID
+// Задачи со статусом done больше не блокируют, даже если ещё не удалены
+func Blockers(owner string, taskID string) ([]string, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query(
+		`SELECT d.blocker_id FROM dependencies d JOIN scheduler b ON b.id = d.blocker_id
+         WHERE d.task_id = ? AND b.owner = ? AND `+openBlocker+` ORDER BY d.blocker_id`,
+		taskID, owner,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ids, nil
+}
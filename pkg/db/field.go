@@ -0,0 +1,183 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go1f/pkg/apperr"
+)
+
+// Типы пользовательских полей
+const (
+	FieldText   = "text"
+	FieldNumber = "number"
+	FieldDate   = "date"
+	FieldSelect = "select"
+)
+
+// Field описывает пользовательское поле задач
+type Field struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options,omitempty"`
+	Required bool     `json:"required"`
+}
+
+// AddField добавляет определение пользовательского поля
+// Возвращает ID добавленного поля или ошибку
+func AddField(field *Field) (int64, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	options, err := json.Marshal(field.Options)
+	if err != nil {
+		return 0, fmt.Errorf("options encoding error: %w", err)
+	}
+
+	query := `INSERT INTO fields (name, type, options, required) VALUES(?, ?, ?, ?)`
+	res, err := GetDB().Exec(query, field.Name, field.Type, string(options), field.Required)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return 0, apperr.Errorf(apperr.FieldExists, "field already exists: %s", field.Name)
+		}
+		return 0, fmt.Errorf("insert error: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// Fields возвращает все определения пользовательских полей
+func Fields() ([]*Field, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query("SELECT id, name, type, options, required FROM fields ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	fields := []*Field{}
+	for rows.Next() {
+		var field Field
+		var options string
+		if err := rows.Scan(&field.ID, &field.Name, &field.Type, &options, &field.Required); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		if err := json.Unmarshal([]byte(options), &field.Options); err != nil {
+			return nil, fmt.Errorf("options decoding error: %w", err)
+		}
+		fields = append(fields, &field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return fields, nil
+}
+
+// DeleteField удаляет определение поля вместе со всеми его значениями
+func DeleteField(id string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM fields WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.FieldNotFound, "field not found")
+	}
+
+	if _, err := tx.Exec("DELETE FROM field_values WHERE field_id = ?", id); err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// setFieldValues заменяет значения пользовательских полей задачи
+// values индексируется по имени поля
+func setFieldValues(tx *sql.Tx, taskID string, values map[string]string) error {
+	if _, err := tx.Exec("DELETE FROM field_values WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	query := `INSERT INTO field_values (task_id, field_id, value)
+	          SELECT ?, id, ? FROM fields WHERE name = ?`
+	for name, value := range values {
+		res, err := tx.Exec(query, taskID, value, name)
+		if err != nil {
+			return fmt.Errorf("insert error: %w", err)
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("insert check error: %w", err)
+		}
+		if count == 0 {
+			return apperr.Errorf(apperr.InvalidField, "unknown field: %s", name)
+		}
+	}
+
+	return nil
+}
+
+// loadFieldValues заполняет значения пользовательских полей у списка задач
+func loadFieldValues(tasks []*Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*Task, len(tasks))
+	placeholders := make([]string, 0, len(tasks))
+	args := make([]interface{}, 0, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+		placeholders = append(placeholders, "?")
+		args = append(args, task.ID)
+	}
+
+	rows, err := GetDB().Query(
+		`SELECT v.task_id, f.name, v.value FROM field_values v JOIN fields f ON f.id = v.field_id
+         WHERE v.task_id IN (`+strings.Join(placeholders, ", ")+`)`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID, name, value string
+		if err := rows.Scan(&taskID, &name, &value); err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		task := byID[taskID]
+		if task.Fields == nil {
+			task.Fields = make(map[string]string)
+		}
+		task.Fields[name] = value
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go1f/pkg/apperr"
+)
+
+// Роли пользователей в списке задач
+const (
+	RoleOwner  = "owner"  // Владелец списка, управляет участниками
+	RoleEditor = "editor" // Читает и изменяет задачи
+	RoleViewer = "viewer" // Только читает задачи
+)
+
+// ListMember участник общего списка задач
+// Список задач принадлежит пользователю, поэтому ID списка совпадает с ID владельца
+type ListMember struct {
+	ListID    string `json:"list_id"`
+	UserID    string `json:"user_id"`
+	Login     string `json:"login"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+// TaskList список задач, доступный пользователю
+type TaskList struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"` // Логин владельца
+	Role  string `json:"role"`
+}
+
+// SetListMember добавляет участника в список задач или меняет его роль
+func SetListMember(listID, userID, role string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	query := `INSERT INTO list_members (list_id, user_id, role, created_at) VALUES (?, ?, ?, ?)
+	          ON CONFLICT (list_id, user_id) DO UPDATE SET role = excluded.role`
+	if _, err := GetDB().Exec(query, listID, userID, role, time.Now().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+	return nil
+}
+
+// DeleteListMember закрывает пользователю доступ к списку задач
+func DeleteListMember(listID, userID string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec("DELETE FROM list_members WHERE list_id = ? AND user_id = ?", listID, userID)
+	if err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.MemberNotFound, "member not found")
+	}
+
+	return nil
+}
+
+// ListMembers возвращает участников списка задач
+func ListMembers(listID string) ([]*ListMember, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query(
+		`SELECT m.list_id, m.user_id, u.login, m.role, m.created_at
+         FROM list_members m JOIN users u ON u.id = m.user_id
+         WHERE m.list_id = ? ORDER BY u.login`,
+		listID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*ListMember{}
+	for rows.Next() {
+		var m ListMember
+		if err := rows.Scan(&m.ListID, &m.UserID, &m.Login, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		members = append(members, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return members, nil
+}
+
+// TaskLists возвращает списки задач пользователя: собственный и открытые ему другими
+func TaskLists(userID string) ([]*TaskList, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query(
+		`SELECT id, login, ? FROM users WHERE id = ?
+         UNION ALL
+         SELECT m.list_id, u.login, m.role FROM list_members m JOIN users u ON u.id = m.list_id
+         WHERE m.user_id = ?`,
+		RoleOwner, userID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []*TaskList{}
+	for rows.Next() {
+		var l TaskList
+		if err := rows.Scan(&l.ID, &l.Owner, &l.Role); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		lists = append(lists, &l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return lists, nil
+}
+
+// ListRole возвращает роль пользователя в чужом списке задач или пустую строку, если доступа нет
+func ListRole(listID, userID string) (string, error) {
+	if GetDB() == nil {
+		return "", fmt.Errorf("database connection is not initialized")
+	}
+
+	var role string
+	err := GetDB().QueryRow("SELECT role FROM list_members WHERE list_id = ? AND user_id = ?", listID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("database error: %w", err)
+	}
+	return role, nil
+}
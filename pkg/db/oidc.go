@@ -0,0 +1,42 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go1f/pkg/apperr"
+)
+
+// GetUserByOIDC возвращает пользователя, привязанного к учётной записи провайдера
+func GetUserByOIDC(issuer, subject string) (*User, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	var userID string
+	err := GetDB().QueryRow("SELECT user_id FROM oidc_identities WHERE issuer = ? AND subject = ?", issuer, subject).
+		Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.New(apperr.UserNotFound, "user not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return GetUser(userID)
+}
+
+// LinkOIDC привязывает учётную запись провайдера к пользователю
+func LinkOIDC(issuer, subject, userID, email string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	query := `INSERT INTO oidc_identities (issuer, subject, user_id, email, created_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := GetDB().Exec(query, issuer, subject, userID, email, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go1f/pkg/apperr"
+)
+
+// Session сессия пользователя, продлеваемая refresh-токеном
+type Session struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	TokenHash  string `json:"-"` // SHA-256 текущего refresh-токена
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	Revoked    bool   `json:"-"`
+}
+
+// sessionColumns перечисляет колонки, которые читают запросы сессий
+const sessionColumns = "id, user_id, token_hash, created_at, last_used_at, expires_at, user_agent, ip, revoked"
+
+// scanSession сканирует строку с колонками sessionColumns
+func scanSession(row interface{ Scan(...interface{}) error }) (*Session, error) {
+	var s Session
+	err := row.Scan(&s.ID, &s.UserID, &s.TokenHash, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.UserAgent, &s.IP, &s.Revoked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.New(apperr.SessionNotFound, "session not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &s, nil
+}
+
+// AddSession создаёт сессию пользователя
+// Возвращает ID сессии или ошибку
+func AddSession(s *Session) (int64, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	query := `INSERT INTO sessions (user_id, token_hash, created_at, last_used_at, expires_at, user_agent, ip)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	res, err := GetDB().Exec(query, s.UserID, s.TokenHash, s.CreatedAt, s.LastUsedAt, s.ExpiresAt, s.UserAgent, s.IP)
+	if err != nil {
+		return 0, fmt.Errorf("insert error: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// GetSession возвращает сессию по ID
+func GetSession(id string) (*Session, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+	return scanSession(GetDB().QueryRow("SELECT "+sessionColumns+" FROM sessions WHERE id = ?", id))
+}
+
+// GetSessionByToken возвращает сессию по хешу refresh-токена
+func GetSessionByToken(hash string) (*Session, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+	return scanSession(GetDB().QueryRow("SELECT "+sessionColumns+" FROM sessions WHERE token_hash = ?", hash))
+}
+
+// RotateSessionToken заменяет refresh-токен сессии и продлевает её
+// Замена выполняется, только если сессия не отозвана и старый токен ещё не был использован
+func RotateSessionToken(s *Session, oldHash string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	query := `UPDATE sessions SET token_hash = ?, last_used_at = ?, expires_at = ?
+	          WHERE id = ? AND token_hash = ? AND revoked = 0`
+	res, err := GetDB().Exec(query, s.TokenHash, s.LastUsedAt, s.ExpiresAt, s.ID, oldHash)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.SessionNotFound, "session not found")
+	}
+
+	return nil
+}
+
+// Sessions возвращает действующие сессии пользователя от новых к старым
+func Sessions(userID string, now string) ([]*Session, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query(
+		"SELECT "+sessionColumns+" FROM sessions WHERE user_id = ? AND revoked = 0 AND expires_at > ? ORDER BY id DESC",
+		userID, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession отзывает сессию пользователя
+func RevokeSession(userID, id string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec("UPDATE sessions SET revoked = 1 WHERE id = ? AND user_id = ? AND revoked = 0", id, userID)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.SessionNotFound, "session not found")
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions отзывает все сессии пользователя, кроме keepID
+func RevokeOtherSessions(userID, keepID string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	if _, err := GetDB().Exec("UPDATE sessions SET revoked = 1 WHERE user_id = ? AND id != ?", userID, keepID); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"fmt"
+)
+
+// SigningKey ключ подписи токенов, секрет хранится в hex
+type SigningKey struct {
+	ID        string `json:"kid"`
+	Secret    string `json:"-"`
+	CreatedAt string `json:"created_at"`
+	RetiredAt string `json:"retired_at,omitempty"`
+}
+
+// SigningKeys возвращает ключи подписи от новых к старым
+func SigningKeys() ([]*SigningKey, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query("SELECT kid, secret, created_at, retired_at FROM signing_keys ORDER BY id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*SigningKey{}
+	for rows.Next() {
+		var key SigningKey
+		if err := rows.Scan(&key.ID, &key.Secret, &key.CreatedAt, &key.RetiredAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		keys = append(keys, &key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RotateSigningKey добавляет новый ключ подписи и выводит из работы действующие
+// Ключи, выведенные раньше pruneBefore, удаляются: подписанные ими токены уже истекли
+func RotateSigningKey(key *SigningKey, pruneBefore string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE signing_keys SET retired_at = ? WHERE retired_at = ''`, key.CreatedAt); err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM signing_keys WHERE retired_at != '' AND retired_at < ?`, pruneBefore); err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	query := `INSERT INTO signing_keys (kid, secret, created_at) VALUES (?, ?, ?)`
+	if _, err := tx.Exec(query, key.ID, key.Secret, key.CreatedAt); err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"go1f/pkg/apperr"
+)
+
+// Статусы задачи
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusWaiting    = "waiting"
+	StatusDone       = "done"
+)
+
+// Statuses перечисляет статусы в порядке колонок доски
+var Statuses = []string{StatusTodo, StatusInProgress, StatusWaiting, StatusDone}
+
+// StatusChange представляет запись истории статусов задачи
+type StatusChange struct {
+	Status    string `json:"status"`
+	ChangedAt string `json:"changed_at"`
+}
+
+// SetStatus записывает новый статус задачи владельца с текущим временем
+func SetStatus(owner string, taskID, status string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	query := `INSERT INTO status_history (task_id, status, changed_at)
+	          SELECT id, ?, ? FROM scheduler WHERE id = ? AND owner = ?`
+	res, err := GetDB().Exec(query, status, time.Now().Format(time.RFC3339), taskID, owner)
+	if err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("insert check error: %w", err)
+	}
+	if count == 0 {
+		return apperr.New(apperr.TaskNotFound, "task not found")
+	}
+
+	return nil
+}
+
+// StatusHistory возвращает историю статусов задачи владельца от старых к новым
+func StatusHistory(owner string, taskID string) ([]*StatusChange, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query(
+		"SELECT status, changed_at FROM status_history WHERE task_id = ? AND task_id IN ("+ownedTasks+") ORDER BY id",
+		taskID, owner,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	history := []*StatusChange{}
+	for rows.Next() {
+		var change StatusChange
+		if err := rows.Scan(&change.Status, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		history = append(history, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return history, nil
+}
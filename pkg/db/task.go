@@ -3,8 +3,12 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"go1f/pkg/apperr"
 )
 
 // Task представляет задачу в планировщике
@@ -14,31 +18,86 @@ type Task struct {
     Title   string `json:"title"`
     Comment string `json:"comment"`
     Repeat  string `json:"repeat"`
+
+    Status          string `json:"status"`
+    StatusChangedAt string `json:"status_changed_at,omitempty"`
+
+    Fields map[string]string `json:"fields,omitempty"`
+
+    Checklist []*ChecklistItem `json:"checklist,omitempty"`
+    Blocked   bool             `json:"blocked,omitempty"`
+    BlockedBy []string         `json:"blocked_by,omitempty"`
+
+    Attachments []*Attachment `json:"attachments,omitempty"`
+}
+
+// blockedColumn вычисляет, есть ли у задачи незавершённые блокирующие задачи
+const blockedColumn = `EXISTS (SELECT 1 FROM dependencies d JOIN scheduler b ON b.id = d.blocker_id
+    WHERE d.task_id = scheduler.id AND ` + openBlocker + `) AS blocked`
+
+// statusColumns вычисляют текущий статус задачи и время его установки по последней записи истории
+const statusColumns = `COALESCE((SELECT status FROM status_history WHERE task_id = scheduler.id ORDER BY id DESC LIMIT 1), '` + StatusTodo + `') AS status,
+    COALESCE((SELECT changed_at FROM status_history WHERE task_id = scheduler.id ORDER BY id DESC LIMIT 1), '') AS status_changed_at`
+
+// TaskFilter задаёт дополнительные условия отбора задач в списке
+type TaskFilter struct {
+    Ready  bool              // только незаблокированные задачи
+    Fields map[string]string // значения пользовательских полей по имени
 }
 
-// AddTask добавляет новую задачу в базу данных
+// ownedTasks выбирает ID задач владельца, параметр — ID владельца
+const ownedTasks = "SELECT id FROM scheduler WHERE owner = ?"
+
+// taskColumns перечисляет колонки, которые читают все запросы задач
+const taskColumns = "id, date, title, comment, repeat, " + statusColumns + ", " + blockedColumn
+
+// taskFields возвращает поля задачи в порядке taskColumns для Scan
+func taskFields(task *Task) []interface{} {
+    return []interface{}{&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat,
+        &task.Status, &task.StatusChangedAt, &task.Blocked}
+}
+
+// AddTask добавляет новую задачу владельца в базу данных
 // Возвращает ID добавленной задачи или ошибку
-func AddTask(task *Task) (int64, error) {
+func AddTask(owner string, task *Task) (int64, error) {
     if GetDB() == nil {
         return 0, fmt.Errorf("database connection is not initialized")
     }
     
-    var id int64
-    query := `INSERT INTO scheduler (date, title, comment, repeat) VALUES(?, ?, ?, ?)`
-    res, err := GetDB().Exec(query, task.Date, task.Title, task.Comment, task.Repeat)
-    if err == nil {
-        id, err = res.LastInsertId()
+    tx, err := GetDB().Begin()
+    if err != nil {
+        return 0, fmt.Errorf("transaction error: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `INSERT INTO scheduler (date, title, comment, repeat, owner) VALUES(?, ?, ?, ?, ?)`
+    res, err := tx.Exec(query, task.Date, task.Title, task.Comment, task.Repeat, owner)
+    if err != nil {
+        return 0, err
     }
-    return id, err
+    id, err := res.LastInsertId()
+    if err != nil {
+        return 0, err
+    }
+
+    if err := setFieldValues(tx, strconv.FormatInt(id, 10), task.Fields); err != nil {
+        return 0, err
+    }
+
+    return id, tx.Commit()
 }
 
-// Tasks возвращает список задач с ограничением по количеству
-func Tasks(limit int) ([]*Task, error) {
+// Tasks возвращает список задач владельца с ограничением по количеству
+func Tasks(owner string, limit int, filter TaskFilter) ([]*Task, error) {
     if GetDB() == nil {
         return nil, fmt.Errorf("database connection is not initialized")
     }
 
-    rows, err := GetDB().Query("SELECT id, date, title, comment, repeat FROM scheduler ORDER BY date LIMIT :limit", sql.Named("limit", limit))
+    where, args := filterConditions(owner, filter)
+    rows, err := GetDB().Query(
+        "SELECT "+taskColumns+" FROM scheduler WHERE "+where+" ORDER BY date LIMIT :limit",
+        append(args, sql.Named("limit", limit))...,
+    )
     if err != nil {
         return nil, fmt.Errorf("database query error: %w", err)
     }
@@ -47,19 +106,20 @@ func Tasks(limit int) ([]*Task, error) {
     return scanTasks(rows)
 }
 
-// SearchTasks ищет задачи по подстроке или дате
-func SearchTasks(search string, limit int) ([]*Task, error) {
+// SearchTasks ищет задачи владельца по подстроке или дате
+func SearchTasks(owner string, search string, limit int, filter TaskFilter) ([]*Task, error) {
     if GetDB() == nil {
         return nil, fmt.Errorf("database connection is not initialized")
     }
 
+    where, args := filterConditions(owner, filter)
+
     // Проверяем, является ли search датой в формате 02.01.2006
     if date, ok := parseDate(search); ok {
         // Поиск по дате
         rows, err := GetDB().Query(
-            "SELECT id, date, title, comment, repeat FROM scheduler WHERE date = :date ORDER BY date LIMIT :limit",
-            sql.Named("date", date),
-            sql.Named("limit", limit),
+            "SELECT "+taskColumns+" FROM scheduler WHERE date = :date AND "+where+" ORDER BY date LIMIT :limit",
+            append(args, sql.Named("date", date), sql.Named("limit", limit))...,
         )
         if err != nil {
             return nil, fmt.Errorf("database query error: %w", err)
@@ -72,12 +132,10 @@ func SearchTasks(search string, limit int) ([]*Task, error) {
     // Поиск по подстроке в заголовке или комментарии
     searchPattern := "%" + strings.ToLower(search) + "%"
     rows, err := GetDB().Query(
-        `SELECT id, date, title, comment, repeat FROM scheduler 
-         WHERE LOWER(title) LIKE :search OR LOWER(comment) LIKE :search 
+        "SELECT "+taskColumns+` FROM scheduler 
+         WHERE (LOWER(title) LIKE :search OR LOWER(comment) LIKE :search) AND `+where+`
          ORDER BY date LIMIT :limit`,
-        sql.Named("search", searchPattern),
-        sql.Named("search", searchPattern),
-        sql.Named("limit", limit),
+        append(args, sql.Named("search", searchPattern), sql.Named("limit", limit))...,
     )
     if err != nil {
         return nil, fmt.Errorf("database query error: %w", err)
@@ -87,6 +145,29 @@ func SearchTasks(search string, limit int) ([]*Task, error) {
     return scanTasks(rows)
 }
 
+// filterConditions возвращает SQL-условие и параметры для отбора задач владельца по фильтру
+func filterConditions(owner string, filter TaskFilter) (string, []interface{}) {
+    conds := []string{"owner = :owner", "(:ready = 0 OR NOT blocked)"}
+    args := []interface{}{sql.Named("owner", owner), sql.Named("ready", filter.Ready)}
+
+    names := make([]string, 0, len(filter.Fields))
+    for name := range filter.Fields {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    for i, name := range names {
+        conds = append(conds, fmt.Sprintf(`EXISTS (SELECT 1 FROM field_values v JOIN fields f ON f.id = v.field_id
+            WHERE v.task_id = scheduler.id AND f.name = :fname%d AND v.value = :fvalue%d)`, i, i))
+        args = append(args,
+            sql.Named(fmt.Sprintf("fname%d", i), name),
+            sql.Named(fmt.Sprintf("fvalue%d", i), filter.Fields[name]),
+        )
+    }
+
+    return strings.Join(conds, " AND "), args
+}
+
 // parseDate пытается разобрать строку как дату в формате 02.01.2006
 // Возвращает дату в формате 20060102 и true, если разбор успешен
 func parseDate(dateStr string) (string, bool) {
@@ -109,7 +190,7 @@ func scanTasks(rows *sql.Rows) ([]*Task, error) {
     
     for rows.Next() {
         var task Task
-        err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+        err := rows.Scan(taskFields(&task)...)
         if err != nil {
             return nil, fmt.Errorf("scan error: %w", err)
         }
@@ -125,38 +206,53 @@ func scanTasks(rows *sql.Rows) ([]*Task, error) {
     if tasks == nil {
         tasks = []*Task{}
     }
+
+    if err := loadFieldValues(tasks); err != nil {
+        return nil, err
+    }
     
     return tasks, nil
 }
 
-// GetTask возвращает задачу по ID
-func GetTask(id string) (*Task, error) {
+// GetTask возвращает задачу владельца по ID
+func GetTask(owner string, id string) (*Task, error) {
     if GetDB() == nil {
         return nil, fmt.Errorf("database connection is not initialized")
     }
 
     var task Task
-    err := GetDB().QueryRow("SELECT id, date, title, comment, repeat FROM scheduler WHERE id = ?", id).
-        Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+    err := GetDB().QueryRow("SELECT "+taskColumns+" FROM scheduler WHERE id = ? AND owner = ?", id, owner).
+        Scan(taskFields(&task)...)
     
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, fmt.Errorf("task not found")
+            return nil, apperr.New(apperr.TaskNotFound, "task not found")
         }
         return nil, fmt.Errorf("database error: %w", err)
     }
 
+    if err := loadFieldValues([]*Task{&task}); err != nil {
+        return nil, err
+    }
+
     return &task, nil
 }
 
-// UpdateTask обновляет существующую задачу
-func UpdateTask(task *Task) error {
+// UpdateTask обновляет существующую задачу владельца
+// Значения пользовательских полей заменяются, только если task.Fields не nil
+func UpdateTask(owner string, task *Task) error {
     if GetDB() == nil {
         return fmt.Errorf("database connection is not initialized")
     }
 
-    query := `UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ? WHERE id = ?`
-    res, err := GetDB().Exec(query, task.Date, task.Title, task.Comment, task.Repeat, task.ID)
+    tx, err := GetDB().Begin()
+    if err != nil {
+        return fmt.Errorf("transaction error: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ? WHERE id = ? AND owner = ?`
+    res, err := tx.Exec(query, task.Date, task.Title, task.Comment, task.Repeat, task.ID, owner)
     if err != nil {
         return fmt.Errorf("update error: %w", err)
     }
@@ -167,19 +263,31 @@ func UpdateTask(task *Task) error {
     }
     
     if count == 0 {
-        return fmt.Errorf("task not found")
+        return apperr.New(apperr.TaskNotFound, "task not found")
+    }
+
+    if task.Fields != nil {
+        if err := setFieldValues(tx, task.ID, task.Fields); err != nil {
+            return err
+        }
     }
     
-    return nil
+    return tx.Commit()
 }
 
-// DeleteTask удаляет задачу по ID
-func DeleteTask(id string) error {
+// DeleteTask удаляет задачу владельца по ID
+func DeleteTask(owner string, id string) error {
     if GetDB() == nil {
         return fmt.Errorf("database connection is not initialized")
     }
 
-    res, err := GetDB().Exec("DELETE FROM scheduler WHERE id = ?", id)
+    tx, err := GetDB().Begin()
+    if err != nil {
+        return fmt.Errorf("transaction error: %w", err)
+    }
+    defer tx.Rollback()
+
+    res, err := tx.Exec("DELETE FROM scheduler WHERE id = ? AND owner = ?", id, owner)
     if err != nil {
         return fmt.Errorf("delete error: %w", err)
     }
@@ -190,20 +298,48 @@ func DeleteTask(id string) error {
     }
     
     if count == 0 {
-        return fmt.Errorf("task not found")
+        return apperr.New(apperr.TaskNotFound, "task not found")
     }
-    
+
+    // Файлы вложений удаляем после фиксации транзакции
+    paths, err := attachmentPaths(tx, id)
+    if err != nil {
+        return err
+    }
+
+    // Удаляем связанные с задачей данные
+    if _, err := tx.Exec("DELETE FROM checklist WHERE task_id = ?", id); err != nil {
+        return fmt.Errorf("delete error: %w", err)
+    }
+    if _, err := tx.Exec("DELETE FROM status_history WHERE task_id = ?", id); err != nil {
+        return fmt.Errorf("delete error: %w", err)
+    }
+    if _, err := tx.Exec("DELETE FROM field_values WHERE task_id = ?", id); err != nil {
+        return fmt.Errorf("delete error: %w", err)
+    }
+    if _, err := tx.Exec("DELETE FROM dependencies WHERE task_id = ? OR blocker_id = ?", id, id); err != nil {
+        return fmt.Errorf("delete error: %w", err)
+    }
+    if _, err := tx.Exec("DELETE FROM attachments WHERE task_id = ?", id); err != nil {
+        return fmt.Errorf("delete error: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+
+    removeFiles(paths)
     return nil
 }
 
-// UpdateDate обновляет дату задачи
-func UpdateDate(next string, id string) error {
+// UpdateDate обновляет дату задачи владельца
+func UpdateDate(owner string, next string, id string) error {
     if GetDB() == nil {
         return fmt.Errorf("database connection is not initialized")
     }
 
-    query := `UPDATE scheduler SET date = ? WHERE id = ?`
-    res, err := GetDB().Exec(query, next, id)
+    query := `UPDATE scheduler SET date = ? WHERE id = ? AND owner = ?`
+    res, err := GetDB().Exec(query, next, id, owner)
     if err != nil {
         return fmt.Errorf("update error: %w", err)
     }
@@ -214,7 +350,7 @@ func UpdateDate(next string, id string) error {
     }
     
     if count == 0 {
-        return fmt.Errorf("task not found")
+        return apperr.New(apperr.TaskNotFound, "task not found")
     }
     
     return nil
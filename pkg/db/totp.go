@@ -0,0 +1,196 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go1f/pkg/apperr"
+)
+
+// TOTP настройки двухфакторной аутентификации пользователя
+type TOTP struct {
+	UserID   string
+	Secret   string
+	Enabled  bool
+	LastStep int64 // Последний принятый шаг, коды этого и более ранних шагов не принимаются
+}
+
+// GetTOTP возвращает настройки TOTP пользователя
+func GetTOTP(userID string) (*TOTP, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	var t TOTP
+	err := GetDB().QueryRow("SELECT user_id, secret, enabled, last_step FROM totp WHERE user_id = ?", userID).
+		Scan(&t.UserID, &t.Secret, &t.Enabled, &t.LastStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.New(apperr.TOTPNotConfigured, "TOTP is not configured")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &t, nil
+}
+
+// TOTPEnabled проверяет, включена ли у пользователя двухфакторная аутентификация
+func TOTPEnabled(userID string) (bool, error) {
+	if GetDB() == nil {
+		return false, fmt.Errorf("database connection is not initialized")
+	}
+
+	var enabled bool
+	err := GetDB().QueryRow("SELECT EXISTS (SELECT 1 FROM totp WHERE user_id = ? AND enabled = 1)", userID).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return enabled, nil
+}
+
+// SetTOTPSecret сохраняет новый секрет, ожидающий подтверждения кодом
+// Если TOTP уже включён, секрет не меняется
+func SetTOTPSecret(userID, secret string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	query := `INSERT INTO totp (user_id, secret) VALUES (?, ?)
+	          ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, last_step = 0 WHERE enabled = 0`
+	res, err := GetDB().Exec(query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("insert error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("insert check error: %w", err)
+	}
+	if count == 0 {
+		return apperr.New(apperr.TOTPAlreadyEnabled, "TOTP is already enabled")
+	}
+
+	return nil
+}
+
+// EnableTOTP включает TOTP и заменяет коды восстановления пользователя
+func EnableTOTP(userID string, step int64, codeHashes []string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE totp SET enabled = 1, last_step = ? WHERE user_id = ? AND enabled = 0", step, userID)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update check error: %w", err)
+	}
+	if count == 0 {
+		return apperr.New(apperr.TOTPNotConfigured, "TOTP setup not started")
+	}
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return fmt.Errorf("insert error: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP отключает TOTP и удаляет коды восстановления пользователя
+func DisableTOTP(userID string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	tx, err := GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM totp WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("delete error: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep запоминает принятый шаг TOTP
+// Возвращает ошибку, если код этого шага уже использовался
+func UseTOTPStep(userID string, step int64) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec("UPDATE totp SET last_step = ? WHERE user_id = ? AND last_step < ?", step, userID, step)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update check error: %w", err)
+	}
+	if count == 0 {
+		return apperr.New(apperr.InvalidTOTPCode, "TOTP code already used")
+	}
+
+	return nil
+}
+
+// UseRecoveryCode отмечает код восстановления использованным
+// Возвращает ошибку, если код не найден или уже использован
+func UseRecoveryCode(userID, hash, at string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec(
+		`UPDATE recovery_codes SET used_at = ? WHERE id = (
+             SELECT id FROM recovery_codes WHERE user_id = ? AND code_hash = ? AND used_at = '' LIMIT 1
+         )`,
+		at, userID, hash,
+	)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update check error: %w", err)
+	}
+	if count == 0 {
+		return apperr.New(apperr.InvalidTOTPCode, "recovery code not found")
+	}
+
+	return nil
+}
+
+// RecoveryCodesLeft возвращает количество неиспользованных кодов восстановления
+func RecoveryCodesLeft(userID string) (int, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	var count int
+	err := GetDB().QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at = ''`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go1f/pkg/apperr"
+)
+
+// AdminID идентификатор встроенной учётной записи администратора
+const AdminID = "1"
+
+// User представляет учётную запись пользователя
+type User struct {
+	ID           string `json:"id"`
+	Login        string `json:"login"`
+	PasswordHash string `json:"-"`
+	Admin        bool   `json:"admin"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// AddUser создаёт учётную запись пользователя
+// Возвращает ID пользователя или ошибку
+func AddUser(user *User) (int64, error) {
+	if GetDB() == nil {
+		return 0, fmt.Errorf("database connection is not initialized")
+	}
+
+	user.CreatedAt = time.Now().Format(time.RFC3339)
+	query := `INSERT INTO users (login, password_hash, admin, created_at) VALUES(?, ?, ?, ?)`
+	res, err := GetDB().Exec(query, user.Login, user.PasswordHash, user.Admin, user.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return 0, apperr.Errorf(apperr.UserExists, "user already exists: %s", user.Login)
+		}
+		return 0, fmt.Errorf("insert error: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// GetUser возвращает пользователя по ID
+func GetUser(id string) (*User, error) {
+	return getUser("id", id)
+}
+
+// GetUserByLogin возвращает пользователя по логину
+func GetUserByLogin(login string) (*User, error) {
+	return getUser("login", login)
+}
+
+// getUser возвращает пользователя по значению колонки id или login
+func getUser(column, value string) (*User, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	var user User
+	err := GetDB().QueryRow(
+		"SELECT id, login, password_hash, admin, created_at FROM users WHERE "+column+" = ?", value,
+	).Scan(&user.ID, &user.Login, &user.PasswordHash, &user.Admin, &user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.New(apperr.UserNotFound, "user not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &user, nil
+}
+
+// Users возвращает список всех пользователей
+func Users() ([]*User, error) {
+	if GetDB() == nil {
+		return nil, fmt.Errorf("database connection is not initialized")
+	}
+
+	rows, err := GetDB().Query("SELECT id, login, password_hash, admin, created_at FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("database query error: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Login, &user.PasswordHash, &user.Admin, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		users = append(users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return users, nil
+}
+
+// SetPasswordHash сохраняет новый хеш пароля пользователя
+func SetPasswordHash(id, hash string) error {
+	if GetDB() == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	res, err := GetDB().Exec("UPDATE users SET password_hash = ? WHERE id = ?", hash, id)
+	if err != nil {
+		return fmt.Errorf("update error: %w", err)
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update check error: %w", err)
+	}
+
+	if count == 0 {
+		return apperr.New(apperr.UserNotFound, "user not found")
+	}
+
+	return nil
+}
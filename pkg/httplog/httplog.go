@@ -0,0 +1,140 @@
+// Package httplog журналирует HTTP-запросы через log/slog.
+// Каждому запросу присваивается ID, он возвращается клиенту в заголовке X-Request-ID
+// и попадает в журнал, чтобы по обращению в поддержку можно было найти запрос
+package httplog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Header заголовок с ID запроса
+const Header = "X-Request-ID"
+
+// maxIDLength ограничивает длину ID, пришедшего от клиента или прокси
+const maxIDLength = 64
+
+// NewLogger создаёт журнал в формате text или json с минимальным уровнем level (debug, info, warn, error)
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %s", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format: %s", format)
+	}
+}
+
+// ctxKey ключ записи о запросе в контексте
+type ctxKey struct{}
+
+// entry данные запроса, которые обработчики дополняют по ходу обработки
+type entry struct {
+	id   string
+	user string
+}
+
+// Middleware присваивает запросу ID и после обработки пишет в журнал
+// метод, путь, статус, время обработки и пользователя.
+// ID от прокси в заголовке X-Request-ID сохраняется, если он похож на идентификатор
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(Header)
+		if !validID(id) {
+			id = newID()
+		}
+		w.Header().Set(Header, id)
+
+		e := &entry{id: id}
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), ctxKey{}, e)))
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "request",
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("user", e.user),
+			slog.String("remote", r.RemoteAddr),
+		)
+	})
+}
+
+// SetUser запоминает пользователя запроса для журнала
+func SetUser(ctx context.Context, user string) {
+	if e, ok := ctx.Value(ctxKey{}).(*entry); ok {
+		e.user = user
+	}
+}
+
+// RequestID возвращает ID запроса или пустую строку вне Middleware
+func RequestID(ctx context.Context) string {
+	if e, ok := ctx.Value(ctxKey{}).(*entry); ok {
+		return e.id
+	}
+	return ""
+}
+
+// newID создаёт случайный ID запроса
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validID проверяет, что ID из заголовка можно без опасений писать в журнал и ответ
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// recorder запоминает статус и размер ответа
+type recorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *recorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap даёт http.ResponseController доступ к исходному ResponseWriter
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,64 @@
+package httplog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serve выполняет запрос через Middleware и возвращает ответ и ID, который видел обработчик
+func serve(requestID string) (*httptest.ResponseRecorder, string) {
+	var seen string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil)
+	if requestID != "" {
+		req.Header.Set(Header, requestID)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+func TestRequestIDEchoed(t *testing.T) {
+	rec, seen := serve("proxy-42.a_b")
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "proxy-42.a_b", rec.Header().Get(Header))
+	assert.Equal(t, "proxy-42.a_b", seen)
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	rec, seen := serve("")
+	id := rec.Header().Get(Header)
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, seen)
+
+	other, _ := serve("")
+	assert.NotEqual(t, id, other.Header().Get(Header))
+
+	// ID, который нельзя безопасно записать в журнал, заменяется новым
+	for _, bad := range []string{"id with spaces", "id\nforged", strings.Repeat("a", maxIDLength+1)} {
+		rec, seen := serve(bad)
+		assert.NotEqual(t, bad, rec.Header().Get(Header))
+		assert.Len(t, seen, 16)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		_, err := NewLogger(&strings.Builder{}, format, "debug")
+		assert.NoError(t, err, format)
+	}
+
+	_, err := NewLogger(&strings.Builder{}, "xml", "info")
+	assert.Error(t, err)
+	_, err = NewLogger(&strings.Builder{}, "text", "verbose")
+	assert.Error(t, err)
+}
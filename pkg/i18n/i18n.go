@@ -0,0 +1,220 @@
+// Package i18n содержит каталог сообщений API и веб-интерфейса на русском и английском.
+// Язык выбирается по заголовку Accept-Language, по умолчанию русский
+package i18n
+
+import (
+	"strconv"
+	"strings"
+
+	"go1f/pkg/apperr"
+)
+
+// Lang код языка
+type Lang string
+
+// Поддерживаемые языки
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+)
+
+// Default язык, если клиент не указал поддерживаемый
+const Default = RU
+
+// Negotiate выбирает язык по заголовку Accept-Language с учётом весов q
+// Из тега учитывается только основной язык: en-US считается en
+func Negotiate(acceptLanguage string) Lang {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		lang := Lang(primary)
+		if _, ok := errorMessages[lang]; ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Error возвращает сообщение об ошибке с кодом code на языке lang
+func Error(lang Lang, code apperr.Code) (string, bool) {
+	msg, ok := errorMessages[lang][code]
+	return msg, ok
+}
+
+// UI возвращает строки веб-интерфейса на языке lang
+func UI(lang Lang) map[string]string {
+	return uiMessages[lang]
+}
+
+// errorMessages сообщения об ошибках по их кодам
+var errorMessages = map[Lang]map[apperr.Code]string{
+	RU: {
+		apperr.Internal:         "Внутренняя ошибка сервера",
+		apperr.BadRequest:       "Некорректный запрос",
+		apperr.InvalidJSON:      "Неверный формат запроса",
+		apperr.MethodNotAllowed: "Метод не поддерживается",
+		apperr.Unauthorized:     "Требуется вход",
+		apperr.Forbidden:        "Недостаточно прав",
+		apperr.NotFound:         "Не найдено",
+		apperr.Conflict:         "Конфликт с текущим состоянием",
+		apperr.TooManyRequests:  "Слишком много попыток входа, попробуйте позже",
+
+		apperr.InvalidCredentials: "Неверный логин или пароль",
+		apperr.WrongPassword:      "Неверный пароль",
+		apperr.InvalidToken:       "Недействительный токен",
+		apperr.CSRFFailed:         "Запрос отклонён: нет CSRF-токена",
+		apperr.ReadOnlyToken:      "API-токен только для чтения",
+		apperr.AdminRequired:      "Нужны права администратора",
+		apperr.SignupDisabled:     "Регистрация отключена",
+		apperr.InvalidTOTPCode:    "Неверный код подтверждения",
+		apperr.TOTPConfirmFailed:  "Неверный код подтверждения",
+		apperr.MFAExpired:         "Срок подтверждения входа истёк, войдите заново",
+		apperr.TOTPNotConfigured:  "Двухфакторная аутентификация не настроена",
+		apperr.TOTPAlreadyEnabled: "Двухфакторная аутентификация уже включена",
+		apperr.OIDCDisabled:       "Вход через провайдера не настроен",
+		apperr.OIDCFailed:         "Не удалось войти через провайдера",
+		apperr.OIDCInvalidState:   "Срок входа через провайдера истёк, попробуйте ещё раз",
+		apperr.OIDCUserDenied:     "Учётная запись провайдера не связана с пользователем",
+		apperr.OIDCAlreadyLinked:  "Учётная запись провайдера уже связана с пользователем",
+
+		apperr.IDRequired:            "Не указан ID",
+		apperr.TaskNotFound:          "Задача не найдена",
+		apperr.TitleRequired:         "Не указан заголовок задачи",
+		apperr.InvalidDate:           "Неверный формат даты",
+		apperr.InvalidRepeat:         "Неверное правило повторения",
+		apperr.InvalidStatus:         "Неизвестный статус задачи",
+		apperr.InvalidTransition:     "Такой переход статуса не разрешён",
+		apperr.TaskBlocked:           "Задачу блокируют незавершённые задачи",
+		apperr.ChecklistItemNotFound: "Пункт чек-листа не найден",
+		apperr.InvalidChecklistOrder: "Неверный порядок пунктов чек-листа",
+		apperr.DependencyNotFound:    "Зависимость не найдена",
+		apperr.InvalidDependency:     "Недопустимая зависимость",
+		apperr.AttachmentNotFound:    "Вложение не найдено",
+		apperr.FileTooLarge:          "Файл слишком большой",
+		apperr.QuotaExceeded:         "Превышена квота вложений",
+		apperr.FieldNotFound:         "Поле не найдено",
+		apperr.FieldExists:           "Поле уже существует",
+		apperr.InvalidField:          "Недопустимое поле",
+		apperr.InvalidFieldValue:     "Недопустимое значение поля",
+
+		apperr.UserNotFound:    "Пользователь не найден",
+		apperr.UserExists:      "Пользователь уже существует",
+		apperr.InvalidLogin:    "Логин должен содержать 3-64 буквы, цифры или символы _.@-",
+		apperr.WeakPassword:    "Слишком короткий пароль",
+		apperr.ListNotFound:    "Список задач не найден",
+		apperr.MemberNotFound:  "Участник не найден",
+		apperr.InvalidRole:     "Роль должна быть editor или viewer",
+		apperr.SessionNotFound: "Сессия не найдена",
+		apperr.TokenNotFound:   "API-токен не найден",
+		apperr.InvalidScope:    "Область токена должна быть read или write",
+	},
+	EN: {
+		apperr.Internal:         "Internal server error",
+		apperr.BadRequest:       "Bad request",
+		apperr.InvalidJSON:      "Invalid request format",
+		apperr.MethodNotAllowed: "Method not allowed",
+		apperr.Unauthorized:     "Authentication required",
+		apperr.Forbidden:        "Access denied",
+		apperr.NotFound:         "Not found",
+		apperr.Conflict:         "Conflict with the current state",
+		apperr.TooManyRequests:  "Too many sign-in attempts, try again later",
+
+		apperr.InvalidCredentials: "Invalid login or password",
+		apperr.WrongPassword:      "Invalid password",
+		apperr.InvalidToken:       "Invalid token",
+		apperr.CSRFFailed:         "Request rejected: CSRF token missing",
+		apperr.ReadOnlyToken:      "API token is read-only",
+		apperr.AdminRequired:      "Administrator access required",
+		apperr.SignupDisabled:     "Signup is disabled",
+		apperr.InvalidTOTPCode:    "Invalid confirmation code",
+		apperr.TOTPConfirmFailed:  "Invalid confirmation code",
+		apperr.MFAExpired:         "Sign-in confirmation expired, sign in again",
+		apperr.TOTPNotConfigured:  "Two-factor authentication is not set up",
+		apperr.TOTPAlreadyEnabled: "Two-factor authentication is already enabled",
+		apperr.OIDCDisabled:       "OIDC login is not configured",
+		apperr.OIDCFailed:         "OIDC login failed",
+		apperr.OIDCInvalidState:   "OIDC login expired, try again",
+		apperr.OIDCUserDenied:     "The provider account is not linked to a user",
+		apperr.OIDCAlreadyLinked:  "The provider account is already linked to a user",
+
+		apperr.IDRequired:            "ID not specified",
+		apperr.TaskNotFound:          "Task not found",
+		apperr.TitleRequired:         "Task title not specified",
+		apperr.InvalidDate:           "Invalid date format",
+		apperr.InvalidRepeat:         "Invalid repeat rule",
+		apperr.InvalidStatus:         "Unknown task status",
+		apperr.InvalidTransition:     "This status transition is not allowed",
+		apperr.TaskBlocked:           "Task is blocked by open tasks",
+		apperr.ChecklistItemNotFound: "Checklist item not found",
+		apperr.InvalidChecklistOrder: "Invalid checklist order",
+		apperr.DependencyNotFound:    "Dependency not found",
+		apperr.InvalidDependency:     "Invalid dependency",
+		apperr.AttachmentNotFound:    "Attachment not found",
+		apperr.FileTooLarge:          "File is too large",
+		apperr.QuotaExceeded:         "Attachment quota exceeded",
+		apperr.FieldNotFound:         "Field not found",
+		apperr.FieldExists:           "Field already exists",
+		apperr.InvalidField:          "Invalid field",
+		apperr.InvalidFieldValue:     "Invalid field value",
+
+		apperr.UserNotFound:    "User not found",
+		apperr.UserExists:      "User already exists",
+		apperr.InvalidLogin:    "Login must be 3-64 letters, digits or _.@- characters",
+		apperr.WeakPassword:    "Password is too short",
+		apperr.ListNotFound:    "Task list not found",
+		apperr.MemberNotFound:  "Member not found",
+		apperr.InvalidRole:     "Role must be editor or viewer",
+		apperr.SessionNotFound: "Session not found",
+		apperr.TokenNotFound:   "API token not found",
+		apperr.InvalidScope:    "Scope must be read or write",
+	},
+}
+
+// uiMessages строки веб-интерфейса, ключи совпадают со словарём интерфейса
+var uiMessages = map[Lang]map[string]string{
+	RU: {
+		"title":   "Планировщик задач",
+		"sso":     "Войти через SSO",
+		"signin":  "Войти",
+		"login":   "Логин (пусто для администратора)",
+		"mfa":     "Введите код подтверждения",
+		"mfacode": "Код из приложения или код восстановления",
+		"close":   "Закрыть",
+		"confirm": "Подтверждение",
+		"ereq":    "Заполните поле: ",
+		"ereqs":   "Заполните поля: ",
+		"error":   "Ошибка",
+		"info":    "Информация",
+		"no":      "Нет",
+		"ok":      "ОК",
+		"qdelete": "Вы действительно хотите удалить задачу?",
+		"yes":     "Да",
+	},
+	EN: {
+		"title":   "Task planner",
+		"sso":     "Sign in with SSO",
+		"signin":  "Sign in",
+		"login":   "Login (empty for the administrator)",
+		"mfa":     "Enter the confirmation code",
+		"mfacode": "Code from the app or a recovery code",
+		"close":   "Close",
+		"confirm": "Confirmation",
+		"ereq":    "Fill in the field: ",
+		"ereqs":   "Fill in the fields: ",
+		"error":   "Error",
+		"info":    "Information",
+		"no":      "No",
+		"ok":      "OK",
+		"qdelete": "Do you really want to delete the task?",
+		"yes":     "Yes",
+	},
+}
@@ -0,0 +1,51 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tbl := []struct {
+		header string
+		want   Lang
+	}{
+		{"", RU},
+		{"en", EN},
+		{"en-US,en;q=0.9", EN},
+		{"EN-gb", EN},
+		{"ru-RU,ru;q=0.9,en;q=0.8", RU},
+		{"ru;q=0.5,en;q=0.8", EN},
+		{"de,en;q=0.3", EN},
+		{"de,fr", RU},
+		{"en;q=bad,ru;q=0.1", RU},
+		{"*", RU},
+	}
+	for _, v := range tbl {
+		assert.Equal(t, v.want, Negotiate(v.header), v.header)
+	}
+}
+
+func TestErrorCatalog(t *testing.T) {
+	// Каждое сообщение об ошибке переведено на все языки
+	assert.Equal(t, len(errorMessages[RU]), len(errorMessages[EN]))
+	for code, msg := range errorMessages[RU] {
+		assert.NotEmpty(t, msg, code)
+		en, ok := Error(EN, code)
+		assert.True(t, ok, "%s: no English message", code)
+		assert.NotEmpty(t, en, code)
+	}
+
+	_, ok := Error(RU, "unknown")
+	assert.False(t, ok)
+}
+
+func TestUICatalog(t *testing.T) {
+	ru, en := UI(RU), UI(EN)
+	assert.Equal(t, len(ru), len(en))
+	for key, msg := range ru {
+		assert.NotEmpty(t, msg, key)
+		assert.NotEmpty(t, en[key], "%s: no English string", key)
+	}
+}
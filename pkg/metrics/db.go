@@ -0,0 +1,26 @@
+package metrics
+
+import "database/sql"
+
+// RegisterDBStats регистрирует метрики пула соединений database/sql.
+// stats вызывается при каждом запросе метрик
+func RegisterDBStats(stats func() sql.DBStats) {
+	NewGaugeFunc("todo_db_max_open_connections", "Maximum number of open connections to the database.",
+		func() float64 { return float64(stats().MaxOpenConnections) })
+	NewGaugeFunc("todo_db_open_connections", "Number of established connections, in use and idle.",
+		func() float64 { return float64(stats().OpenConnections) })
+	NewGaugeFunc("todo_db_in_use_connections", "Number of connections currently in use.",
+		func() float64 { return float64(stats().InUse) })
+	NewGaugeFunc("todo_db_idle_connections", "Number of idle connections.",
+		func() float64 { return float64(stats().Idle) })
+	NewCounterFunc("todo_db_wait_count_total", "Total number of connections waited for.",
+		func() float64 { return float64(stats().WaitCount) })
+	NewCounterFunc("todo_db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+		func() float64 { return stats().WaitDuration.Seconds() })
+	NewCounterFunc("todo_db_max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns.",
+		func() float64 { return float64(stats().MaxIdleClosed) })
+	NewCounterFunc("todo_db_max_idle_time_closed_total", "Total number of connections closed due to SetConnMaxIdleTime.",
+		func() float64 { return float64(stats().MaxIdleTimeClosed) })
+	NewCounterFunc("todo_db_max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime.",
+		func() float64 { return float64(stats().MaxLifetimeClosed) })
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Метрики HTTP-запросов
+var (
+	httpRequests = NewCounterVec("todo_http_requests_total",
+		"HTTP requests by route, method and status code.", "route", "method", "code")
+	httpDuration = NewHistogramVec("todo_http_request_duration_seconds",
+		"HTTP request latency by route and method.", DefBuckets, "route", "method")
+)
+
+// knownMethods методы, которые попадают в метку method как есть; остальные учитываются как other
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// Middleware учитывает запросы и время их обработки по маршрутам.
+// Маршрут берётся из шаблона ServeMux, поэтому middleware должен оборачивать ServeMux непосредственно:
+// вложенный ServeMux уточняет шаблон в том же запросе, а адреса с ID не размножают серии
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			// Метод из шаблона "GET /api/v1/tasks/{id}" уже есть в отдельной метке
+			route = path
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		method := r.Method
+		if !knownMethods[method] {
+			method = "other"
+		}
+
+		httpRequests.Inc(route, method, strconv.Itoa(rec.status))
+		httpDuration.Observe(time.Since(start).Seconds(), route, method)
+	})
+}
+
+// statusRecorder запоминает код ответа обработчика
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap даёт http.ResponseController доступ к исходному ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
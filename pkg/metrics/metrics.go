@@ -0,0 +1,239 @@
+// Package metrics собирает метрики приложения и отдаёт их в текстовом формате Prometheus.
+// Метрики регистрируются при создании и отдаются обработчиком Handler
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector метрика, которая умеет записать себя в формате Prometheus
+type collector interface {
+	write(w io.Writer)
+}
+
+// registry зарегистрированные метрики в порядке создания
+var registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// register добавляет метрику в реестр; повторное имя — ошибка программиста
+func register(name string, c collector) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.names == nil {
+		registry.names = make(map[string]bool)
+	}
+	if registry.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry.names[name] = true
+	registry.collectors = append(registry.collectors, c)
+}
+
+// Handler отдаёт все метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		collectors := append([]collector(nil), registry.collectors...)
+		registry.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, c := range collectors {
+			c.write(w)
+		}
+	})
+}
+
+// labelSet имена меток метрики; серии хранятся по ключу из значений меток через \xff
+type labelSet []string
+
+// key собирает ключ серии по значениям меток
+func (l labelSet) key(values []string) string {
+	if len(values) != len(l) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d", len(l), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// format собирает метки для строки вывода, extra добавляется последней (le у гистограмм)
+func (l labelSet) format(key string, extra ...string) string {
+	var pairs []string
+	if len(l) > 0 {
+		for i, v := range strings.Split(key, "\xff") {
+			pairs = append(pairs, l[i]+`="`+escape(v)+`"`)
+		}
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+`="`+escape(extra[1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec счётчик с метками
+type CounterVec struct {
+	name, help string
+	labels     labelSet
+	mu         sync.Mutex
+	values     map[string]float64
+}
+
+// NewCounterVec создаёт и регистрирует счётчик; без меток он работает как простой счётчик
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	register(name, c)
+	return c
+}
+
+// Inc увеличивает счётчик для значений меток на единицу
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add увеличивает счётчик для значений меток на n
+func (c *CounterVec) Add(n float64, values ...string) {
+	key := c.labels.key(values)
+	c.mu.Lock()
+	c.values[key] += n
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	// Серии выводятся в постоянном порядке, чтобы вывод не менялся между запросами
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labels.format(key), formatFloat(c.values[key]))
+	}
+}
+
+// DefBuckets границы корзин гистограммы времени ответа в секундах
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// histogram наблюдения одной серии гистограммы
+type histogram struct {
+	counts []uint64 // по корзинам, без накопления
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec гистограмма с метками
+type HistogramVec struct {
+	name, help string
+	buckets    []float64
+	labels     labelSet
+	mu         sync.Mutex
+	values     map[string]*histogram
+}
+
+// NewHistogramVec создаёт и регистрирует гистограмму с границами корзин buckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, buckets: buckets, labels: labels, values: make(map[string]*histogram)}
+	register(name, h)
+	return h
+}
+
+// Observe добавляет наблюдение v для значений меток
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.labels.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	// Серии выводятся в постоянном порядке, чтобы вывод не менялся между запросами
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.values[key]
+		var cumulative uint64
+		for i, b := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labels.format(key, "le", formatFloat(b)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labels.format(key, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labels.format(key), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labels.format(key), s.count)
+	}
+}
+
+// valueFunc метрика, значение которой вычисляется при каждом запросе метрик
+type valueFunc struct {
+	name, help, kind string
+	f                func() float64
+}
+
+// NewGaugeFunc регистрирует показатель, значение которого возвращает f
+func NewGaugeFunc(name, help string, f func() float64) {
+	register(name, &valueFunc{name: name, help: help, kind: "gauge", f: f})
+}
+
+// NewCounterFunc регистрирует счётчик, который ведётся вне пакета (например, статистика database/sql)
+func NewCounterFunc(name, help string, f func() float64) {
+	register(name, &valueFunc{name: name, help: help, kind: "counter", f: f})
+}
+
+func (v *valueFunc) write(w io.Writer) {
+	writeHeader(w, v.name, v.help, v.kind)
+	fmt.Fprintf(w, "%s %s\n", v.name, formatFloat(v.f()))
+}
+
+// writeHeader пишет строки HELP и TYPE метрики
+func writeHeader(w io.Writer, name, help, kind string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// escape экранирует значение метки
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat записывает число так, как его ожидает Prometheus
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape возвращает метрики в том виде, в каком их получит Prometheus
+func scrape(t *testing.T) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	return rec.Body.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_counter_total", "Test counter.\nSecond line.", "kind")
+	c.Inc("b")
+	c.Add(2.5, "a")
+	c.Inc(`quote"d`)
+
+	assert.Contains(t, scrape(t), `# HELP test_counter_total Test counter.\nSecond line.
+# TYPE test_counter_total counter
+test_counter_total{kind="a"} 2.5
+test_counter_total{kind="b"} 1
+test_counter_total{kind="quote\"d"} 1
+`)
+
+	assert.Panics(t, func() { c.Inc("a", "extra") })
+	assert.Panics(t, func() { NewCounterVec("test_counter_total", "Duplicate.") })
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_duration_seconds", "Test histogram.", []float64{0.1, 1}, "route")
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(5, "/a")
+
+	assert.Contains(t, scrape(t), `# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.1"} 1
+test_duration_seconds_bucket{route="/a",le="1"} 2
+test_duration_seconds_bucket{route="/a",le="+Inf"} 3
+test_duration_seconds_sum{route="/a"} 5.55
+test_duration_seconds_count{route="/a"} 3
+`)
+}
+
+func TestValueFuncs(t *testing.T) {
+	NewGaugeFunc("test_gauge", "Test gauge.", func() float64 { return 3 })
+	NewCounterFunc("test_func_total", "Test counter func.", func() float64 { return math.Inf(1) })
+
+	out := scrape(t)
+	assert.Contains(t, out, "# TYPE test_gauge gauge\ntest_gauge 3\n")
+	assert.Contains(t, out, "# TYPE test_func_total counter\ntest_func_total +Inf\n")
+}
+
+func TestMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /test/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			http.NotFound(w, r)
+		}
+	})
+	h := Middleware(mux)
+
+	for _, path := range []string{"/test/tasks/1", "/test/tasks/2", "/test/tasks/0", "/test/unknown"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/test/tasks/1", nil))
+
+	// Адреса с ID попадают в одну серию по шаблону маршрута, неизвестные методы — в other
+	out := scrape(t)
+	assert.Contains(t, out, `todo_http_requests_total{route="/test/tasks/{id}",method="GET",code="200"} 2`+"\n")
+	assert.Contains(t, out, `todo_http_requests_total{route="/test/tasks/{id}",method="GET",code="404"} 1`+"\n")
+	assert.Contains(t, out, `todo_http_requests_total{route="unmatched",method="GET",code="404"} 1`+"\n")
+	assert.Contains(t, out, `method="other",code="405"} 1`+"\n")
+	assert.Contains(t, out, `todo_http_request_duration_seconds_count{route="/test/tasks/{id}",method="GET"} 3`+"\n")
+	assert.NotContains(t, out, "/test/tasks/1")
+}
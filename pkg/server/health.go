@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+)
+
+// readyTimeout ограничивает время проверок готовности, чтобы зависшая база не задерживала пробу
+const readyTimeout = 2 * time.Second
+
+// healthResp ответ /healthz и /readyz
+// Для проверок отдаётся только ok или error: подробности ошибок пишутся в журнал, а не клиенту
+type healthResp struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// healthzHandler отвечает, пока процесс жив и обрабатывает запросы
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, healthResp{Status: "ok"})
+}
+
+// readyzHandler проверяет, что сервер готов обслуживать запросы:
+// база отвечает, миграции применены, а каталоги базы и вложений доступны для записи
+func readyzHandler(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		checks := map[string]error{
+			"database":    checkDatabase(ctx),
+			"migrations":  checkMigrations(ctx),
+			"disk":        checkWritable(filepath.Dir(cfg.DBFile)),
+			"attachments": checkWritable(cfg.AttachmentsDir),
+		}
+
+		resp := healthResp{Status: "ok", Checks: make(map[string]string, len(checks))}
+		for name, err := range checks {
+			if err != nil {
+				slog.Warn("readiness check failed", "check", name, "err", err)
+				resp.Status = "error"
+				resp.Checks[name] = "error"
+				continue
+			}
+			resp.Checks[name] = "ok"
+		}
+		writeHealth(w, resp)
+	}
+}
+
+// writeHealth отправляет результат проверок, при ошибке со статусом 503
+func writeHealth(w http.ResponseWriter, resp healthResp) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkDatabase проверяет, что база отвечает
+func checkDatabase(ctx context.Context) error {
+	conn := db.GetDB()
+	if conn == nil {
+		return errors.New("database connection is not initialized")
+	}
+	return conn.PingContext(ctx)
+}
+
+// checkMigrations проверяет, что схема базы соответствует версии приложения
+func checkMigrations(ctx context.Context) error {
+	current, expected, err := db.SchemaVersion(ctx)
+	if err == nil && current != expected {
+		err = fmt.Errorf("schema version %d, expected %d", current, expected)
+	}
+	return err
+}
+
+// checkWritable проверяет, что в каталоге можно создать файл.
+// Если каталог ещё не создан (каталог вложений создаётся при первой загрузке),
+// проверяется ближайший существующий родительский каталог
+func checkWritable(dir string) error {
+	for {
+		if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
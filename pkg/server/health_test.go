@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+)
+
+// readyz выполняет проверку готовности с настройками cfg
+func readyz(t *testing.T, cfg *config.Config) (*httptest.ResponseRecorder, healthResp) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	readyzHandler(cfg)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var resp healthResp
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return rec, resp
+}
+
+func TestReadyz(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.DBFile = filepath.Join(dir, "scheduler.db")
+	cfg.AttachmentsDir = filepath.Join(dir, "attachments")
+
+	require.NoError(t, db.Init(cfg.DBFile))
+	t.Cleanup(func() { db.Close() })
+
+	rec, resp := readyz(t, cfg)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, map[string]string{"database": "ok", "migrations": "ok", "disk": "ok", "attachments": "ok"}, resp.Checks)
+
+	// Файл вместо каталога вложений: проверка не проходит, но путь и текст ошибки клиенту не отдаются
+	cfg.AttachmentsDir = filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(cfg.AttachmentsDir, nil, 0o600))
+
+	rec, resp = readyz(t, cfg)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "error", resp.Status)
+	assert.Equal(t, "error", resp.Checks["attachments"])
+	assert.False(t, strings.Contains(rec.Body.String(), dir))
+}
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	healthzHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
@@ -1,24 +1,121 @@
 package server
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"net/http"
- 	"os"
- 	"go1f/pkg/api"
+	"time"
+
+	"go1f/pkg/api"
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+	"go1f/pkg/httplog"
+	"go1f/pkg/metrics"
 )
 
+// Run запускает HTTP-сервер и работает до отмены ctx.
+// webFS содержит встроенный веб-интерфейс, он используется, если в настройках не задан каталог на диске.
+// После отмены сервер перестаёт принимать соединения и ждёт завершения начатых запросов
+func Run(ctx context.Context, cfg *config.Config, webFS fs.FS) error {
+	// регистрируем API
+	apiHandler, err := api.Init(cfg)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/api/", apiHandler)
+
+	// пробы оркестратора, без аутентификации
+	mux.HandleFunc("GET /healthz", healthzHandler)
+	mux.HandleFunc("GET /readyz", readyzHandler(cfg))
+
+	// статика: встроенный интерфейс или каталог на диске при разработке
+	if cfg.WebDir != "" {
+		mux.Handle("/", noStore(http.FileServer(http.Dir(cfg.WebDir))))
+	} else {
+		static, err := newStaticHandler(webFS)
+		if err != nil {
+			return err
+		}
+		mux.Handle("/", static)
+	}
 
-func Run() error {
-	port := "7540"
-	if envPort := os.Getenv("TODO_PORT"); envPort != "" {
-		port = envPort
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           httplog.Middleware(metrics.Middleware(mux)),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
+	servers := []*http.Server{srv}
+
+	// метрики Prometheus, включая пул соединений с базой, отдаются только на отдельном адресе,
+	// который не публикуется наружу: на основном порту они были бы доступны без аутентификации
+	if cfg.MetricsAddr != "" {
+		metrics.RegisterDBStats(func() sql.DBStats {
+			if db.GetDB() == nil {
+				return sql.DBStats{}
+			}
+			return db.GetDB().Stats()
+		})
 
-	webDir := "web"
-	fileServer := http.FileServer(http.Dir(webDir))
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler())
+		servers = append(servers, &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       cfg.IdleTimeout,
+		})
+	}
 
-	api.Init() // регистрируем API
+	if cfg.TLS.Enabled() {
+		tlsCfg, err := tlsConfig(cfg.TLS)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsCfg
 
-	http.Handle("/", fileServer) // статика
-	
-	return http.ListenAndServe(":"+port, nil)
-}
\ No newline at end of file
+		if cfg.TLS.RedirectPort != 0 {
+			servers = append(servers, &http.Server{
+				Addr:              fmt.Sprintf(":%d", cfg.TLS.RedirectPort),
+				Handler:           redirectHandler(cfg.Port),
+				ReadHeaderTimeout: 10 * time.Second,
+				IdleTimeout:       cfg.IdleTimeout,
+			})
+		}
+	}
+
+	errCh := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s *http.Server) {
+			if s.TLSConfig != nil {
+				errCh <- s.ListenAndServeTLS("", "")
+			} else {
+				errCh <- s.ListenAndServe()
+			}
+		}(s)
+	}
+
+	var runErr error
+	select {
+	case runErr = <-errCh:
+		// Один из серверов не запустился, останавливаем остальные
+	case <-ctx.Done():
+		slog.Info("shutting down, waiting for in-flight requests")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	for _, s := range servers {
+		if err := s.Shutdown(shutdownCtx); err != nil && runErr == nil {
+			runErr = fmt.Errorf("shutdown: %w", err)
+		}
+	}
+	return runErr
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/config"
+	"go1f/pkg/db"
+)
+
+// freePort возвращает свободный TCP-порт
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startServer запускает сервер на свободном порту и ждёт, пока он начнёт принимать соединения
+func startServer(t *testing.T, configure func(*config.Config)) (addr string, cancel context.CancelFunc, runErr chan error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.DBFile = filepath.Join(dir, "scheduler.db")
+	cfg.AttachmentsDir = filepath.Join(dir, "attachments")
+	cfg.WebDir = dir
+	cfg.Port = freePort(t)
+	if configure != nil {
+		configure(cfg)
+	}
+
+	require.NoError(t, db.Init(cfg.DBFile))
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	runErr = make(chan error, 1)
+	go func() { runErr <- Run(ctx, cfg, nil) }()
+
+	addr = fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(addr + "/")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return addr, cancel, runErr
+}
+
+func TestRunDrainsInFlightRequests(t *testing.T) {
+	addr, cancel, runErr := startServer(t, nil)
+
+	// Тело запроса передаётся по частям, поэтому запрос остаётся незавершённым во время остановки
+	pr, pw := io.Pipe()
+	code := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(addr+"/api/signin", "application/json", pr)
+		if err != nil {
+			code <- 0
+			return
+		}
+		resp.Body.Close()
+		code <- resp.StatusCode
+	}()
+	_, err := pw.Write([]byte(`{"password":`))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case err := <-runErr:
+		t.Fatalf("server stopped before the request finished: %v", err)
+	default:
+	}
+
+	_, err = pw.Write([]byte(`"qwerty123"}`))
+	require.NoError(t, err)
+	require.NoError(t, pw.Close())
+	assert.Equal(t, http.StatusOK, <-code)
+	require.NoError(t, <-runErr)
+
+	_, err = http.Get(addr + "/")
+	assert.Error(t, err)
+}
+
+func TestMetricsOnSeparateAddr(t *testing.T) {
+	metricsAddr := fmt.Sprintf("127.0.0.1:%d", freePort(t))
+	addr, _, _ := startServer(t, func(c *config.Config) { c.MetricsAddr = metricsAddr })
+
+	// На основном порту метрики недоступны
+	resp, err := http.Get(addr + "/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var body []byte
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + metricsAddr + "/metrics")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		body, err = io.ReadAll(resp.Body)
+		return err == nil && resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, string(body), "todo_db_open_connections")
+}
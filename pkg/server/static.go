@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// asset файл интерфейса, подготовленный к отдаче
+type asset struct {
+	data        []byte
+	hash        string // Начало SHA-256 содержимого, используется в ETag
+	contentType string
+}
+
+// staticHandler отдаёт встроенный интерфейс из памяти.
+// ETag вычисляется по содержимому, поэтому после обновления интерфейса браузер сразу получит
+// новые файлы, а неизменённые подтвердятся ответом 304
+type staticHandler struct {
+	assets map[string]*asset
+}
+
+// newStaticHandler читает все файлы интерфейса и вычисляет их хеши
+func newStaticHandler(fsys fs.FS) (*staticHandler, error) {
+	h := &staticHandler{assets: make(map[string]*asset)}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		h.assets["/"+name] = newAsset(name, data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// newAsset вычисляет хеш и тип содержимого файла
+func newAsset(name string, data []byte) *asset {
+	sum := sha256.Sum256(data)
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return &asset{data: data, hash: hex.EncodeToString(sum[:8]), contentType: contentType}
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Clean(r.URL.Path)
+	if strings.HasSuffix(name, "/") {
+		name += "index.html"
+	}
+	a, ok := h.assets[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", `"`+a.hash+`"`)
+	w.Header().Set("Content-Type", a.contentType)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(a.data))
+}
+
+// noStore запрещает кеширование, чтобы при разработке интерфейса изменения были видны сразу
+func noStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getStatic запрашивает файл интерфейса с заголовками headers
+func getStatic(h http.Handler, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStaticETag(t *testing.T) {
+	h, err := newStaticHandler(fstest.MapFS{
+		"index.html":    {Data: []byte("<html></html>")},
+		"js/scripts.js": {Data: []byte("console.log(1)")},
+	})
+	require.NoError(t, err)
+
+	rec := getStatic(h, "/", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<html></html>", rec.Body.String())
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Неизменённый файл подтверждается без содержимого
+	rec = getStatic(h, "/", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = getStatic(h, "/js/scripts.js", map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	// Параметр версии не делает ответ кешируемым надолго
+	rec = getStatic(h, "/js/scripts.js?v="+h.assets["/js/scripts.js"].hash, nil)
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+
+	assert.Equal(t, http.StatusNotFound, getStatic(h, "/missing.js", nil).Code)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go1f/pkg/config"
+)
+
+// certCheckInterval как часто проверять, не изменились ли файлы сертификата
+const certCheckInterval = 5 * time.Second
+
+// certReloader отдаёт сертификат для TLS и перечитывает его при изменении файлов
+type certReloader struct {
+	certFile, keyFile string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time // Время изменения файлов, из которых загружен сертификат
+	checkedAt time.Time
+}
+
+// newCertReloader загружает сертификат из файлов
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// filesModTime возвращает время последнего изменения файлов сертификата и ключа
+func (r *certReloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// load читает сертификат и ключ из файлов
+func (r *certReloader) load() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
+
+// GetCertificate возвращает текущий сертификат, при изменении файлов загружает новый.
+// Если новый сертификат не читается (например, файлы записаны не полностью), остаётся прежний
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.checkedAt) >= certCheckInterval {
+		r.checkedAt = now
+		if modTime, err := r.filesModTime(); err == nil && !modTime.Equal(r.modTime) {
+			if err := r.load(); err != nil {
+				slog.Warn("keeping previous TLS certificate", "err", err)
+			} else {
+				slog.Info("TLS certificate reloaded")
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+// tlsConfig готовит настройки TLS: сертификат из файлов или самоподписанный
+func tlsConfig(cfg config.TLS) (*tls.Config, error) {
+	certFile, keyFile := cfg.CertFile, cfg.KeyFile
+	if cfg.SelfSigned {
+		if certFile == "" {
+			certFile, keyFile = "tls.crt", "tls.key"
+		}
+		if _, err := os.Stat(certFile); os.IsNotExist(err) {
+			if err := writeSelfSigned(certFile, keyFile); err != nil {
+				return nil, err
+			}
+			slog.Info("generated self-signed TLS certificate", "file", certFile)
+		}
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}, nil
+}
+
+// writeSelfSigned создаёт самоподписанный сертификат на год для имени хоста,
+// localhost и адресов сетевых интерфейсов, чтобы им можно было пользоваться в локальной сети
+func writeSelfSigned(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate TLS key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate TLS certificate: %w", err)
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "todo_app", Organization: []string{"todo_app"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if host, err := os.Hostname(); err == nil && host != "localhost" {
+		tmpl.DNSNames = append(tmpl.DNSNames, host)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ipNet.IP)
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to generate TLS certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode TLS key: %w", err)
+	}
+
+	// Ключ записывается первым: сертификат без ключа при следующем запуске не был бы создан заново
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return fmt.Errorf("failed to write TLS key: %w", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return fmt.Errorf("failed to write TLS certificate: %w", err)
+	}
+	return nil
+}
+
+// redirectHandler перенаправляет запросы по HTTP на тот же адрес по HTTPS
+func redirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/config"
+)
+
+func TestSelfSignedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.TLS{
+		CertFile:   filepath.Join(dir, "tls.crt"),
+		KeyFile:    filepath.Join(dir, "tls.key"),
+		SelfSigned: true,
+	}
+
+	tlsCfg, err := tlsConfig(cfg)
+	require.NoError(t, err)
+	cert, err := tlsCfg.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.NoError(t, leaf.VerifyHostname("localhost"))
+	assert.NoError(t, leaf.VerifyHostname("127.0.0.1"))
+
+	// Созданный сертификат используется и при следующем запуске
+	data, err := os.ReadFile(cfg.CertFile)
+	require.NoError(t, err)
+	_, err = tlsConfig(cfg)
+	require.NoError(t, err)
+	again, err := os.ReadFile(cfg.CertFile)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+}
+
+func TestCertReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, writeSelfSigned(certFile, keyFile))
+
+	r, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	old, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	// checkedAt сбрасывается, чтобы не ждать certCheckInterval
+	reload := func() []byte {
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, later, later))
+		r.checkedAt = time.Time{}
+		cert, err := r.GetCertificate(nil)
+		require.NoError(t, err)
+		return cert.Certificate[0]
+	}
+
+	// Испорченный файл не заменяет рабочий сертификат
+	require.NoError(t, os.WriteFile(certFile, []byte("broken"), 0o644))
+	assert.Equal(t, old.Certificate[0], reload())
+
+	require.NoError(t, writeSelfSigned(certFile, keyFile))
+	assert.False(t, bytes.Equal(old.Certificate[0], reload()))
+}
+
+func TestRedirectHandler(t *testing.T) {
+	for port, want := range map[int]string{
+		8443: "https://example.com:8443/api/tasks?search=1",
+		443:  "https://example.com/api/tasks?search=1",
+	} {
+		rec := httptest.NewRecorder()
+		redirectHandler(port).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com:8080/api/tasks?search=1", nil))
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, want, rec.Header().Get("Location"))
+	}
+}
@@ -16,6 +16,7 @@ type Task struct {
 	Title   string `db:"title"`
 	Comment string `db:"comment"`
 	Repeat  string `db:"repeat"`
+	Owner   int64  `db:"owner"`
 }
 
 func count(db *sqlx.DB) (int, error) {
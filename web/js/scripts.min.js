@@ -1 +1 @@
-var app=function(t){"use strict";function e(){}function n(t){return t()}function l(){return Object.create(null)}function i(t){t.forEach(n)}function o(t){return"function"==typeof t}function c(t,e){return t!=t?e==e:t!==e||t&&"object"==typeof t||"function"==typeof t}function r(t,e,n,l){if(t){const i=s(t,e,n,l);return t[0](i)}}function s(t,e,n,l){return t[1]&&l?function(t,e){for(const n in e)t[n]=e[n];return t}(n.ctx.slice(),t[1](l(e))):n.ctx}function a(t,e,n,l){if(t[2]&&l){const i=t[2](l(n));if(void 0===e.dirty)return i;if("object"==typeof i){const t=[],n=Math.max(e.dirty.length,i.length);for(let l=0;l<n;l+=1)t[l]=e.dirty[l]|i[l];return t}return e.dirty|i}return e.dirty}function u(t,e,n,l,i,o){if(i){const c=s(e,n,l,o);t.p(c,i)}}function d(t){if(t.ctx.length>32){const e=[],n=t.ctx.length/32;for(let t=0;t<n;t++)e[t]=-1;return e}return-1}function f(t,e){t.appendChild(e)}function h(t,e,n){t.insertBefore(e,n||null)}function m(t){t.parentNode&&t.parentNode.removeChild(t)}function p(t,e){for(let n=0;n<t.length;n+=1)t[n]&&t[n].d(e)}function g(t){return document.createElement(t)}function $(t){return document.createElementNS("http://www.w3.org/2000/svg",t)}function v(t){return document.createTextNode(t)}function w(){return v(" ")}function b(){return v("")}function y(t,e,n,l){return t.addEventListener(e,n,l),()=>t.removeEventListener(e,n,l)}function x(t,e,n){null==n?t.removeAttribute(e):t.getAttribute(e)!==n&&t.setAttribute(e,n)}function k(t,e,n){t.setAttributeNS("http://www.w3.org/1999/xlink",e,n)}function z(t){return""===t?null:+t}function _(t,e){e=""+e,t.wholeText!==e&&(t.data=e)}function q(t,e){t.value=null==e?"":e}function D(t,e,n,l){null===n?t.style.removeProperty(e):t.style.setProperty(e,n,l?"important":"")}function j(t,e){for(let n=0;n<t.options.length;n+=1){const l=t.options[n];if(l.__value===e)return void(l.selected=!0)}t.selectedIndex=-1}function E(t,e,n){t.classList[n?"add":"remove"](e)}let M;function A(t){M=t}function N(){if(!M)throw new Error("Function called outside component initialization");return M}function T(t){N().$$.before_update.push(t)}function F(t){N().$$.on_mount.push(t)}function C(t,e){return N().$$.context.set(t,e),e}function L(t){return N().$$.context.get(t)}const O=[],S=[],Y=[],P=[],B=Promise.resolve();let H=!1;function I(t){Y.push(t)}function U(t){P.push(t)}const X=new Set;let Z=0;function G(){const t=M;do{for(;Z<O.length;){const t=O[Z];Z++,A(t),J(t.$$)}for(A(null),O.length=0,Z=0;S.length;)S.pop()();for(let t=0;t<Y.length;t+=1){const e=Y[t];X.has(e)||(X.add(e),e())}Y.length=0}while(O.length);for(;P.length;)P.pop()();H=!1,X.clear(),A(t)}function J(t){if(null!==t.fragment){t.update(),i(t.before_update);const e=t.dirty;t.dirty=[-1],t.fragment&&t.fragment.p(t.ctx,e),t.after_update.forEach(I)}}const K=new Set;let Q;function R(){Q={r:0,c:[],p:Q}}function V(){Q.r||i(Q.c),Q=Q.p}function W(t,e){t&&t.i&&(K.delete(t),t.i(e))}function tt(t,e,n,l){if(t&&t.o){if(K.has(t))return;K.add(t),Q.c.push((()=>{K.delete(t),l&&(n&&t.d(1),l())})),t.o(e)}else l&&l()}function et(t,e,n,l){const i=t.$$.props[e];void 0!==i&&(t.$$.bound[i]=n,void 0===l&&n(t.$$.ctx[i]))}function nt(t){t&&t.c()}function lt(t,e,l,c){const{fragment:r,after_update:s}=t.$$;r&&r.m(e,l),c||I((()=>{const e=t.$$.on_mount.map(n).filter(o);t.$$.on_destroy?t.$$.on_destroy.push(...e):i(e),t.$$.on_mount=[]})),s.forEach(I)}function it(t,e){const n=t.$$;null!==n.fragment&&(i(n.on_destroy),n.fragment&&n.fragment.d(e),n.on_destroy=n.fragment=null,n.ctx=[])}function ot(t,e){-1===t.$$.dirty[0]&&(O.push(t),H||(H=!0,B.then(G)),t.$$.dirty.fill(0)),t.$$.dirty[e/31|0]|=1<<e%31}function ct(t,n,o,c,r,s,a,u=[-1]){const d=M;A(t);const f=t.$$={fragment:null,ctx:[],props:s,update:e,not_equal:r,bound:l(),on_mount:[],on_destroy:[],on_disconnect:[],before_update:[],after_update:[],context:new Map(n.context||(d?d.$$.context:[])),callbacks:l(),dirty:u,skip_bound:!1,root:n.target||d.$$.root};a&&a(f.root);let h=!1;if(f.ctx=o?o(t,n.props||{},((e,n,...l)=>{const i=l.length?l[0]:n;return f.ctx&&r(f.ctx[e],f.ctx[e]=i)&&(!f.skip_bound&&f.bound[e]&&f.bound[e](i),h&&ot(t,e)),n})):[],f.update(),h=!0,i(f.before_update),f.fragment=!!c&&c(f.ctx),n.target){if(n.hydrate){const t=function(t){return Array.from(t.childNodes)}(n.target);f.fragment&&f.fragment.l(t),t.forEach(m)}else f.fragment&&f.fragment.c();n.intro&&W(t.$$.fragment),lt(t,n.target,n.anchor,n.customElement),G()}A(d)}class rt{$destroy(){it(this,1),this.$destroy=e}$on(t,n){if(!o(n))return e;const l=this.$$.callbacks[t]||(this.$$.callbacks[t]=[]);return l.push(n),()=>{const t=l.indexOf(n);-1!==t&&l.splice(t,1)}}$set(t){var e;this.$$set&&(e=t,0!==Object.keys(e).length)&&(this.$$.skip_bound=!0,this.$$set(t),this.$$.skip_bound=!1)}}function st(t,e,n,l){axios.post("api/"+t,e).then((t=>{if(t.data.error)l(t.data.error);else{let e=n(t.data);e&&l(e)}})).catch((t=>{l(t)}))}function at(t,e,n,l){!function(t,e,n,l){axios.post("/"+t,e).then((t=>{if(t.data.error)l(t.data.error);else{let e=n(t.data);e&&l(e)}})).catch((t=>{l(t)}))}("api/"+t,e,n,l)}function ut(t,e,n){axios.get("api/"+t).then((t=>{if(t.data.error)n(t.data.error);else{let l=e(t.data);l&&n(l)}})).catch((t=>{n(t)}))}function dt(t){const e=e=>{!t||t.parentElement.contains(e.target)||e.defaultPrevented||t.dispatchEvent(new CustomEvent("clickoutside",t))};return document.addEventListener("click",e,!0),{destroy(){document.removeEventListener("click",e,!0)}}}function ft(t){let n,l,i,o;return{c(){n=$("svg"),l=$("use"),k(l,"xlink:href",i="#"+t[0]),x(n,"style",o="width:"+t[1]+";height: "+t[1]+";fill:"+t[2]+";"+t[3])},m(t,e){h(t,n,e),f(n,l)},p(t,[e]){1&e&&i!==(i="#"+t[0])&&k(l,"xlink:href",i),14&e&&o!==(o="width:"+t[1]+";height: "+t[1]+";fill:"+t[2]+";"+t[3])&&x(n,"style",o)},i:e,o:e,d(t){t&&m(n)}}}function ht(t,e,n){let{name:l=""}=e,{size:i="1.5em"}=e,{color:o="inherit"}=e,{style:c=""}=e;return t.$$set=t=>{"name"in t&&n(0,l=t.name),"size"in t&&n(1,i=t.size),"color"in t&&n(2,o=t.color),"style"in t&&n(3,c=t.style)},[l,i,o,c]}class mt extends rt{constructor(t){super(),ct(this,t,ht,ft,c,{name:0,size:1,color:2,style:3})}}function pt(t){let e,n,l,i,c;return n=new mt({props:{name:t[1],color:t[3],size:t[2]}}),{c(){e=g("button"),nt(n.$$.fragment),x(e,"class","btnicon")},m(r,s){h(r,e,s),lt(n,e,null),l=!0,i||(c=y(e,"click",(function(){o(t[0])&&t[0].apply(this,arguments)})),i=!0)},p(e,[l]){t=e;const i={};2&l&&(i.name=t[1]),8&l&&(i.color=t[3]),4&l&&(i.size=t[2]),n.$set(i)},i(t){l||(W(n.$$.fragment,t),l=!0)},o(t){tt(n.$$.fragment,t),l=!1},d(t){t&&m(e),it(n),i=!1,c()}}}function gt(t,e,n){let{fn:l}=e,{name:i}=e,{size:o}=e,{color:c}=e;return t.$$set=t=>{"fn"in t&&n(0,l=t.fn),"name"in t&&n(1,i=t.name),"size"in t&&n(2,o=t.size),"color"in t&&n(3,c=t.color)},[l,i,o,c]}class $t extends rt{constructor(t){super(),ct(this,t,gt,pt,c,{fn:0,name:1,size:2,color:3})}}function vt(t){let e,n,l,i,o,c,r,s,a;i=new mt({props:{name:t[4],color:t[5],size:"2.3em"}});let u=!t[2]&&wt(t);return{c(){e=g("div"),n=g("div"),l=g("div"),nt(i.$$.fragment),o=g("span"),c=v(t[1]),r=w(),u&&u.c(),D(o,"padding-left","0.5em"),D(o,"padding-right","3em"),x(l,"class","hflex"),x(n,"class","hflex"),x(e,"class",s="alert "+t[3]),D(e,"max-width","40em")},m(t,s){h(t,e,s),f(e,n),f(n,l),lt(i,l,null),f(l,o),f(o,c),f(n,r),u&&u.m(n,null),a=!0},p(t,l){const o={};16&l&&(o.name=t[4]),32&l&&(o.color=t[5]),i.$set(o),(!a||2&l)&&_(c,t[1]),t[2]?u&&(R(),tt(u,1,1,(()=>{u=null})),V()):u?(u.p(t,l),4&l&&W(u,1)):(u=wt(t),u.c(),W(u,1),u.m(n,null)),(!a||8&l&&s!==(s="alert "+t[3]))&&x(e,"class",s)},i(t){a||(W(i.$$.fragment,t),W(u),a=!0)},o(t){tt(i.$$.fragment,t),tt(u),a=!1},d(t){t&&m(e),it(i),u&&u.d()}}}function wt(t){let e,n;return e=new $t({props:{fn:t[7],name:"close",size:"2em"}}),{c(){nt(e.$$.fragment)},m(t,l){lt(e,t,l),n=!0},p(t,n){const l={};1&n&&(l.fn=t[7]),e.$set(l)},i(t){n||(W(e.$$.fragment,t),n=!0)},o(t){tt(e.$$.fragment,t),n=!1},d(t){it(e,t)}}}function bt(t){let e,n,l=t[0]&&vt(t);return{c(){l&&l.c(),e=b()},m(t,i){l&&l.m(t,i),h(t,e,i),n=!0},p(t,[n]){t[0]?l?(l.p(t,n),1&n&&W(l,1)):(l=vt(t),l.c(),W(l,1),l.m(e.parentNode,e)):l&&(R(),tt(l,1,1,(()=>{l=null})),V())},i(t){n||(W(l),n=!0)},o(t){tt(l),n=!1},d(t){l&&l.d(t),t&&m(e)}}}function yt(t,e,n){let l,i,o,{type:c="success"}=e,{msg:r=""}=e,{show:s=!1}=e,{noclose:a=!1}=e;T((()=>{switch(c){case"success":n(3,l="alert-success"),n(4,i="check-circle"),n(5,o="var(--alert-success-icon)");break;case"warning":n(3,l="alert-warning"),n(4,i="exclamation-circle"),n(5,o="var(--alert-warning-icon)")}}));return t.$$set=t=>{"type"in t&&n(6,c=t.type),"msg"in t&&n(1,r=t.msg),"show"in t&&n(0,s=t.show),"noclose"in t&&n(2,a=t.noclose)},[s,r,a,l,i,o,c,()=>{n(0,s=!1)}]}class xt extends rt{constructor(t){super(),ct(this,t,yt,bt,c,{type:6,msg:1,show:0,noclose:2})}}const kt=t=>({}),zt=t=>({});function _t(t,e,n){const l=t.slice();return l[12]=e[n],l}const qt=t=>({}),Dt=t=>({});function jt(t){let e,n,l,i;return e=new mt({props:{name:t[1].name,color:t[1].color?t[1].color:"var(--contrast)",size:"2.25em"}}),{c(){nt(e.$$.fragment),n=w(),l=g("div"),D(l,"width","0.5em")},m(t,o){lt(e,t,o),h(t,n,o),h(t,l,o),i=!0},p(t,n){const l={};2&n&&(l.name=t[1].name),2&n&&(l.color=t[1].color?t[1].color:"var(--contrast)"),e.$set(l)},i(t){i||(W(e.$$.fragment,t),i=!0)},o(t){tt(e.$$.fragment,t),i=!1},d(t){it(e,t),t&&m(n),t&&m(l)}}}function Et(t){let e,n;const l=t[9].footer,i=r(l,t,t[8],zt);return{c(){e=g("div"),i&&i.c(),x(e,"class","dialog-footer")},m(t,l){h(t,e,l),i&&i.m(e,null),n=!0},p(t,e){i&&i.p&&(!n||256&e)&&u(i,l,t,t[8],n?a(l,t[8],e,kt):d(t[8]),zt)},i(t){n||(W(i,t),n=!0)},o(t){tt(i,t),n=!1},d(t){t&&m(e),i&&i.d(t)}}}function Mt(t){let n,l=t[2],i=[];for(let e=0;e<l.length;e+=1)i[e]=At(_t(t,l,e));return{c(){n=g("div");for(let t=0;t<i.length;t+=1)i[t].c();x(n,"class","dialog-footer")},m(t,e){h(t,n,e);for(let t=0;t<i.length;t+=1)i[t].m(n,null)},p(t,e){if(68&e){let o;for(l=t[2],o=0;o<l.length;o+=1){const c=_t(t,l,o);i[o]?i[o].p(c,e):(i[o]=At(c),i[o].c(),i[o].m(n,null))}for(;o<i.length;o+=1)i[o].d(1);i.length=l.length}},i:e,o:e,d(t){t&&m(n),p(i,t)}}}function At(t){let e,n,l,i,c,r,s=t[12].title+"";return{c(){e=g("button"),n=v(s),l=w(),D(e,"min-width","5em"),D(e,"margin-left","1.5em"),x(e,"class",i="btn "+t[12].class)},m(i,s){h(i,e,s),f(e,n),f(e,l),c||(r=y(e,"click",(function(){o(t[6](t[12].callback))&&t[6](t[12].callback).apply(this,arguments)})),c=!0)},p(l,o){t=l,4&o&&s!==(s=t[12].title+"")&&_(n,s),4&o&&i!==(i="btn "+t[12].class)&&x(e,"class",i)},d(t){t&&m(e),c=!1,r()}}}function Nt(t){let e,n,l,i,o,c,s,p,$,b,k,z,q,j,M,A,N,T=t[1].name&&jt(t);$=new $t({props:{fn:t[4].close,name:"close",size:"2em"}});const F=t[9].content,C=r(F,t,t[8],Dt),L=[Mt,Et],O=[];function S(t,e){return t[2]&&t[2].length>0?0:t[7].footer?1:-1}return~(q=S(t))&&(j=O[q]=L[q](t)),{c(){e=g("div"),n=g("div"),l=g("div"),i=g("div"),T&&T.c(),o=w(),c=g("h4"),s=v(t[0]),p=w(),nt($.$$.fragment),b=w(),k=g("div"),C&&C.c(),z=w(),j&&j.c(),D(i,"display","flex"),D(i,"align-items","center"),D(i,"padding-right","3em"),x(l,"class","dialog-header"),x(k,"class","dialog-content"),E(k,"bottom-radius",!(t[2]&&0!=t[2].length||t[7].footer)),x(n,"class","dialog"),x(e,"class","modal")},m(r,a){var u;h(r,e,a),f(e,n),f(n,l),f(l,i),T&&T.m(i,null),f(i,o),f(i,c),f(c,s),f(l,p),lt($,l,null),f(n,b),f(n,k),C&&C.m(k,null),f(n,z),~q&&O[q].m(n,null),t[10](e),M=!0,A||(N=y(e,"click",(u=t[11],function(t){t.target===this&&u.call(this,t)})),A=!0)},p(t,[e]){t[1].name?T?(T.p(t,e),2&e&&W(T,1)):(T=jt(t),T.c(),W(T,1),T.m(i,o)):T&&(R(),tt(T,1,1,(()=>{T=null})),V()),(!M||1&e)&&_(s,t[0]),C&&C.p&&(!M||256&e)&&u(C,F,t,t[8],M?a(F,t[8],e,qt):d(t[8]),Dt),(!M||132&e)&&E(k,"bottom-radius",!(t[2]&&0!=t[2].length||t[7].footer));let l=q;q=S(t),q===l?~q&&O[q].p(t,e):(j&&(R(),tt(O[l],1,1,(()=>{O[l]=null})),V()),~q?(j=O[q],j?j.p(t,e):(j=O[q]=L[q](t),j.c()),W(j,1),j.m(n,null)):j=null)},i(t){M||(W(T),W($.$$.fragment,t),W(C,t),W(j),M=!0)},o(t){tt(T),tt($.$$.fragment,t),tt(C,t),tt(j),M=!1},d(n){n&&m(e),T&&T.d(),it($),C&&C.d(n),~q&&O[q].d(),t[10](null),A=!1,N()}}}function Tt(t,e,n){let{$$slots:l={},$$scope:i}=e;const o=function(t){const e={};for(const n in t)e[n]=!0;return e}(l);let{header:c=""}=e,{icon:r={}}=e,{btns:s=[]}=e,{outside:a=!1}=e;const u={show(){n(5,d.style.display="flex",d)},close(){n(5,d.style.display="none",d)}};let d;return t.$$set=t=>{"header"in t&&n(0,c=t.header),"icon"in t&&n(1,r=t.icon),"btns"in t&&n(2,s=t.btns),"outside"in t&&n(3,a=t.outside),"$$scope"in t&&n(8,i=t.$$scope)},[c,r,s,a,u,d,function(t){u.close(),t&&t()},o,i,l,function(t){S[t?"unshift":"push"]((()=>{d=t,n(5,d)}))},()=>{a&&u.close()}]}class Ft extends rt{constructor(t){super(),ct(this,t,Tt,Nt,c,{header:0,icon:1,btns:2,outside:3,mod:4})}get mod(){return this.$$.ctx[4]}}function Ct(t){let n,l,c,r;return{c(){n=g("input"),x(n,"class","input"),x(n,"type","text"),x(n,"maxlength",l=t[5]>0?t[5]:null),x(n,"placeholder",t[1]),x(n,"style",t[2]),n.disabled=t[3]},m(e,l){h(e,n,l),q(n,t[0]),c||(r=[y(n,"input",t[6]),y(n,"input",(function(){o(t[4])&&t[4].apply(this,arguments)}))],c=!0)},p(e,[i]){t=e,32&i&&l!==(l=t[5]>0?t[5]:null)&&x(n,"maxlength",l),2&i&&x(n,"placeholder",t[1]),4&i&&x(n,"style",t[2]),8&i&&(n.disabled=t[3]),1&i&&n.value!==t[0]&&q(n,t[0])},i:e,o:e,d(t){t&&m(n),c=!1,i(r)}}}function Lt(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{style:o=null}=e,{disabled:c=!1}=e,{change:r}=e,{size:s=0}=e;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"style"in t&&n(2,o=t.style),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(4,r=t.change),"size"in t&&n(5,s=t.size)},[i,l,o,c,r,s,function(){i=this.value,n(0,i)}]}class Ot extends rt{constructor(t){super(),ct(this,t,Lt,Ct,c,{placeholder:1,value:0,style:2,disabled:3,change:4,size:5})}}function St(t){let n,l,o,c,r,s,a,u,d;return{c(){n=g("div"),l=g("div"),o=g("input"),c=w(),r=g("div"),s=$("svg"),a=$("use"),D(o,"width","100%"),D(o,"min-height","100%"),x(o,"class","input no-right-radius"),x(o,"type","password"),x(o,"placeholder",t[1]),D(l,"flex-grow","1"),k(a,"xlink:href",t[2]),D(s,"width","1.5em"),D(s,"height","1.5em"),D(s,"fill","inherit"),x(r,"class","input-right-btn"),D(n,"display","flex"),D(n,"align-items","stretch")},m(e,i){h(e,n,i),f(n,l),f(l,o),t[5](o),q(o,t[0]),f(n,c),f(n,r),f(r,s),f(s,a),u||(d=[y(o,"input",t[6]),y(r,"click",t[4])],u=!0)},p(t,[e]){2&e&&x(o,"placeholder",t[1]),1&e&&o.value!==t[0]&&q(o,t[0]),4&e&&k(a,"xlink:href",t[2])},i:e,o:e,d(e){e&&m(n),t[5](null),u=!1,i(d)}}}function Yt(t,e,n){let l,{placeholder:i=""}=e,{value:o=""}=e,c="#eye";return t.$$set=t=>{"placeholder"in t&&n(1,i=t.placeholder),"value"in t&&n(0,o=t.value)},[o,i,c,l,function(){"#eye"==c?(n(2,c="#eye-off"),n(3,l.type="text",l)):(n(2,c="#eye"),n(3,l.type="password",l))},function(t){S[t?"unshift":"push"]((()=>{l=t,n(3,l)}))},function(){o=this.value,n(0,o)}]}class Pt extends rt{constructor(t){super(),ct(this,t,Yt,St,c,{placeholder:1,value:0})}}function Bt(t){let n,l,c,r,s,a,u,d,p,v;return{c(){n=g("div"),l=g("div"),c=g("input"),r=w(),s=g("div"),a=$("svg"),u=$("use"),D(c,"width","100%"),D(c,"min-height","100%"),x(c,"class","input no-right-radius"),x(c,"type","text"),x(c,"placeholder",t[1]),D(l,"flex-grow","1"),k(u,"xlink:href",d="#"+t[2]),D(a,"width","1.5em"),D(a,"height","1.5em"),D(a,"fill","inherit"),x(s,"class","input-right-btn"),D(n,"display","flex"),D(n,"align-items","stretch")},m(e,i){h(e,n,i),f(n,l),f(l,c),q(c,t[0]),f(n,r),f(n,s),f(s,a),f(a,u),p||(v=[y(c,"input",t[5]),y(c,"input",(function(){o(t[4])&&t[4].apply(this,arguments)})),y(s,"click",(function(){o(t[3])&&t[3].apply(this,arguments)}))],p=!0)},p(e,[n]){t=e,2&n&&x(c,"placeholder",t[1]),1&n&&c.value!==t[0]&&q(c,t[0]),4&n&&d!==(d="#"+t[2])&&k(u,"xlink:href",d)},i:e,o:e,d(t){t&&m(n),p=!1,i(v)}}}function Ht(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{icon:o}=e,{callback:c}=e,{change:r}=e;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"icon"in t&&n(2,o=t.icon),"callback"in t&&n(3,c=t.callback),"change"in t&&n(4,r=t.change)},[i,l,o,c,r,function(){i=this.value,n(0,i)}]}class It extends rt{constructor(t){super(),ct(this,t,Ht,Bt,c,{placeholder:1,value:0,icon:2,callback:3,change:4})}}function Ut(t,e,n){const l=t.slice();return l[21]=e[n],l}function Xt(t,e,n){const l=t.slice();return l[24]=e[n],l}function Zt(t,e,n){const l=t.slice();return l[24]=e[n],l}function Gt(t){let e,n,l=t[24]+"";return{c(){e=g("th"),n=v(l),x(e,"class","svelte-xxli2i")},m(t,l){h(t,e,l),f(e,n)},p(t,e){1&e&&l!==(l=t[24]+"")&&_(n,l)},d(t){t&&m(e)}}}function Jt(t){let e,n,l,i,o,c=t[24].date+"";function r(){return t[15](t[24])}return{c(){e=g("td"),n=v(c),x(e,"class",l="datebtn "+t[24].class+" svelte-xxli2i")},m(t,l){h(t,e,l),f(e,n),i||(o=y(e,"click",r),i=!0)},p(i,o){t=i,2&o&&c!==(c=t[24].date+"")&&_(n,c),2&o&&l!==(l="datebtn "+t[24].class+" svelte-xxli2i")&&x(e,"class",l)},d(t){t&&m(e),i=!1,o()}}}function Kt(t){let e,n,l=t[21],i=[];for(let e=0;e<l.length;e+=1)i[e]=Jt(Xt(t,l,e));return{c(){e=g("tr");for(let t=0;t<i.length;t+=1)i[t].c();n=w()},m(t,l){h(t,e,l);for(let t=0;t<i.length;t+=1)i[t].m(e,null);f(e,n)},p(t,o){if(34&o){let c;for(l=t[21],c=0;c<l.length;c+=1){const r=Xt(t,l,c);i[c]?i[c].p(r,o):(i[c]=Jt(r),i[c].c(),i[c].m(e,n))}for(;c<i.length;c+=1)i[c].d(1);i.length=l.length}},d(t){t&&m(e),p(i,t)}}}function Qt(t){let e,n,l,i,o,c,r,s,a,u,d,$,b,y,k,z,q;o=new $t({props:{name:"angle-left",fn:t[13]}}),b=new $t({props:{name:"angle-right",fn:t[14]}});let j=t[0],E=[];for(let e=0;e<j.length;e+=1)E[e]=Gt(Zt(t,j,e));let M=t[1],A=[];for(let e=0;e<M.length;e+=1)A[e]=Kt(Ut(t,M,e));return{c(){e=g("div"),n=g("table"),l=g("tr"),i=g("td"),nt(o.$$.fragment),c=w(),r=g("td"),s=v(t[3]),a=w(),u=v(t[2]),d=w(),$=g("td"),nt(b.$$.fragment),y=w(),k=g("tr");for(let t=0;t<E.length;t+=1)E[t].c();z=w();for(let t=0;t<A.length;t+=1)A[t].c();D(i,"vertical-align","middle"),D(i,"line-height","0"),x(i,"class","svelte-xxli2i"),x(r,"colspan","5"),x(r,"class","svelte-xxli2i"),D($,"vertical-align","middle"),D($,"line-height","0"),x($,"class","svelte-xxli2i"),x(n,"class","datepicker svelte-xxli2i")},m(t,m){h(t,e,m),f(e,n),f(n,l),f(l,i),lt(o,i,null),f(l,c),f(l,r),f(r,s),f(r,a),f(r,u),f(l,d),f(l,$),lt(b,$,null),f(n,y),f(n,k);for(let t=0;t<E.length;t+=1)E[t].m(k,null);f(n,z);for(let t=0;t<A.length;t+=1)A[t].m(n,null);q=!0},p(t,[e]){if((!q||8&e)&&_(s,t[3]),(!q||4&e)&&_(u,t[2]),1&e){let n;for(j=t[0],n=0;n<j.length;n+=1){const l=Zt(t,j,n);E[n]?E[n].p(l,e):(E[n]=Gt(l),E[n].c(),E[n].m(k,null))}for(;n<E.length;n+=1)E[n].d(1);E.length=j.length}if(34&e){let l;for(M=t[1],l=0;l<M.length;l+=1){const i=Ut(t,M,l);A[l]?A[l].p(i,e):(A[l]=Kt(i),A[l].c(),A[l].m(n,null))}for(;l<A.length;l+=1)A[l].d(1);A.length=M.length}},i(t){q||(W(o.$$.fragment,t),W(b.$$.fragment,t),q=!0)},o(t){tt(o.$$.fragment,t),tt(b.$$.fragment,t),q=!1},d(t){t&&m(e),it(o),it(b),p(E,t),p(A,t)}}}function Rt(t){const e=t=>t<10?"0"+t:t;return e(t.getDate())+"."+e(t.getMonth()+1)+"."+t.getFullYear()}function Vt(t){const e=t=>t<10?"0"+t:t;return t.getFullYear()+"-"+e(t.getMonth()+1)+"-"+e(t.getDate())}function Wt(t,e,n){let l,i,o,c,{value:r=Rt(new Date)}=e,{days:s="пн|вт|ср|чт|пт|сб|вс".split("|")}=e,{months:a="январь|февраль|март|апрель|май|июнь|июль|август|сентябрь|октябрь|ноябрь|декабрь".split("|")}=e,{start:u=1}=e,{offset:d=0}=e,{callback:f}=e,h=Vt(new Date),m=Vt(new Date);function p(t){n(7,d+=t)}function g(t){n(11,h=t),n(6,r=function(t){let e=t.split("-");switch(e.length){case 1:return r;case 2:return e[1]+"."+e[2]}return e[2]+"."+e[1]+"."+e[0]}(t)),n(7,d=0),f&&f()}return t.$$set=t=>{"value"in t&&n(6,r=t.value),"days"in t&&n(0,s=t.days),"months"in t&&n(8,a=t.months),"start"in t&&n(9,u=t.start),"offset"in t&&n(7,d=t.offset),"callback"in t&&n(10,f=t.callback)},t.$$.update=()=>{64&t.$$.dirty&&function(t){const e=new Date(function(t){let e=t.split(".");switch(e.length){case 1:return t;case 2:return e[1]+"-"+e[0]}return e[2]+"-"+e[1]+"-"+e[0]}(t));n(7,d=0),isNaN(e)||n(11,h=Vt(e))}(r),2176&t.$$.dirty&&n(12,l=function(t,e){var n=new Date(t);return n.setDate(1),n.setMonth(n.getMonth()+e),n}(h,d)),4352&t.$$.dirty&&n(3,i=a[l.getMonth()]),4096&t.$$.dirty&&n(2,o=l.getFullYear()),6656&t.$$.dirty&&n(1,c=function(t,e,n){var l=new Date(t.getTime());l.setDate(1),l.setDate(l.getDate()+(n-l.getDay()-7)%7);var i=new Date(t.getTime());i.setDate(new Date(t.getFullYear(),t.getMonth()+1,0).getDate()),i.setDate(i.getDate()+(n-i.getDay()+6)%7);for(var o=new Date(l.getTime()),c=t.getMonth(),r=t.getFullYear(),s=[],a=[s];o.getTime()<=i.getTime();){var u=o.getDate(),d=o.getMonth(),f=o.getFullYear(),h=Vt(o);s.push({date:u,value:h,class:[e===h?"dateselected":m==h?"today":"",d==c?"":(d>c?f>=r:f>r)?"future":"past"].join(" ")}),(o=new Date(o.getFullYear(),o.getMonth(),o.getDate()+1)).getDay()===n&&(s=[],a.push(s))}return a}(l,h,u))},[s,c,o,i,p,g,r,d,a,u,f,h,l,()=>{p(-1)},()=>{p(1)},t=>g(t.value)]}class te extends rt{constructor(t){super(),ct(this,t,Wt,Qt,c,{value:6,days:0,months:8,start:9,offset:7,callback:10})}}function ee(t,e,n){const l=t.slice();return l[12]=e[n],l}function ne(t){let n,l,c,r,s,a=t[3]&&le(t),u=t[5],d=[];for(let e=0;e<u.length;e+=1)d[e]=se(ee(t,u,e));const $=t=>tt(d[t],1,1,(()=>{d[t]=null}));return{c(){n=g("div"),a&&a.c(),l=w();for(let t=0;t<d.length;t+=1)d[t].c();x(n,"class","dropdown-menu"),E(n,"right",t[1]),E(n,"bottom100",t[2]),E(n,"top100",!t[2]),D(n,"position",t[4]?"absolute":"relative"),D(n,"font-size",t[6]?t[6]:null)},m(i,u){h(i,n,u),a&&a.m(n,null),f(n,l);for(let t=0;t<d.length;t+=1)d[t].m(n,null);var m;c=!0,r||(s=[(m=dt.call(null,n),m&&o(m.destroy)?m.destroy:e),y(n,"clickoutside",t[11])],r=!0)},p(t,e){if(t[3]?a?(a.p(t,e),8&e&&W(a,1)):(a=le(t),a.c(),W(a,1),a.m(n,l)):a&&(R(),tt(a,1,1,(()=>{a=null})),V()),160&e){let l;for(u=t[5],l=0;l<u.length;l+=1){const i=ee(t,u,l);d[l]?(d[l].p(i,e),W(d[l],1)):(d[l]=se(i),d[l].c(),W(d[l],1),d[l].m(n,null))}for(R(),l=u.length;l<d.length;l+=1)$(l);V()}(!c||2&e)&&E(n,"right",t[1]),(!c||4&e)&&E(n,"bottom100",t[2]),(!c||4&e)&&E(n,"top100",!t[2]),16&e&&D(n,"position",t[4]?"absolute":"relative"),64&e&&D(n,"font-size",t[6]?t[6]:null)},i(t){if(!c){W(a);for(let t=0;t<u.length;t+=1)W(d[t]);c=!0}},o(t){tt(a),d=d.filter(Boolean);for(let t=0;t<d.length;t+=1)tt(d[t]);c=!1},d(t){t&&m(n),a&&a.d(),p(d,t),r=!1,i(s)}}}function le(t){let e,n,l;const i=t[9].default,o=r(i,t,t[8],null);let c=t[5].length>0&&ie();return{c(){o&&o.c(),e=w(),c&&c.c(),n=b()},m(t,i){o&&o.m(t,i),h(t,e,i),c&&c.m(t,i),h(t,n,i),l=!0},p(t,e){o&&o.p&&(!l||256&e)&&u(o,i,t,t[8],l?a(i,t[8],e,null):d(t[8]),null),t[5].length>0?c||(c=ie(),c.c(),c.m(n.parentNode,n)):c&&(c.d(1),c=null)},i(t){l||(W(o,t),l=!0)},o(t){tt(o,t),l=!1},d(t){o&&o.d(t),t&&m(e),c&&c.d(t),t&&m(n)}}}function ie(t){let e;return{c(){e=g("hr"),x(e,"class","dropdown-divider")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function oe(t){let e,n,l,i,o,c,r=t[12].title+"",s=t[12].icon&&re(t);function a(){return t[10](t[12])}return{c(){e=g("a"),s&&s.c(),n=g("span"),l=v(r),x(e,"href",null),x(e,"class","dropdown-item")},m(t,r){h(t,e,r),s&&s.m(e,null),f(e,n),f(n,l),i=!0,o||(c=y(e,"click",a),o=!0)},p(o,c){(t=o)[12].icon?s?(s.p(t,c),32&c&&W(s,1)):(s=re(t),s.c(),W(s,1),s.m(e,n)):s&&(R(),tt(s,1,1,(()=>{s=null})),V()),(!i||32&c)&&r!==(r=t[12].title+"")&&_(l,r)},i(t){i||(W(s),i=!0)},o(t){tt(s),i=!1},d(t){t&&m(e),s&&s.d(),o=!1,c()}}}function ce(t){let n;return{c(){n=g("hr"),x(n,"class","dropdown-divider")},m(t,e){h(t,n,e)},p:e,i:e,o:e,d(t){t&&m(n)}}}function re(t){let e,n,l;return e=new mt({props:{name:t[12].icon,size:"1.3em"}}),{c(){nt(e.$$.fragment),n=w()},m(t,i){lt(e,t,i),h(t,n,i),l=!0},p(t,n){const l={};32&n&&(l.name=t[12].icon),e.$set(l)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t),t&&m(n)}}}function se(t){let e,n,l,i;const o=[ce,oe],c=[];function r(t,e){return"hr"==t[12].title?0:1}return e=r(t),n=c[e]=o[e](t),{c(){n.c(),l=b()},m(t,n){c[e].m(t,n),h(t,l,n),i=!0},p(t,i){let s=e;e=r(t),e===s?c[e].p(t,i):(R(),tt(c[s],1,1,(()=>{c[s]=null})),V(),n=c[e],n?n.p(t,i):(n=c[e]=o[e](t),n.c()),W(n,1),n.m(l.parentNode,l))},i(t){i||(W(n),i=!0)},o(t){tt(n),i=!1},d(t){c[e].d(t),t&&m(l)}}}function ae(t){let e,n,l=t[0]&&ne(t);return{c(){l&&l.c(),e=b()},m(t,i){l&&l.m(t,i),h(t,e,i),n=!0},p(t,[n]){t[0]?l?(l.p(t,n),1&n&&W(l,1)):(l=ne(t),l.c(),W(l,1),l.m(e.parentNode,e)):l&&(R(),tt(l,1,1,(()=>{l=null})),V())},i(t){n||(W(l),n=!0)},o(t){tt(l),n=!1},d(t){l&&l.d(t),t&&m(e)}}}function ue(t,e,n){let{$$slots:l={},$$scope:i}=e,{show:o=!1}=e,{right:c=!1}=e,{bottom:r=!1}=e,{slot:s=!1}=e,{absolute:a=!1}=e,{items:u=[]}=e,{size:d=""}=e;function f(t){n(0,o=!1),t.action&&t.action(t)}return t.$$set=t=>{"show"in t&&n(0,o=t.show),"right"in t&&n(1,c=t.right),"bottom"in t&&n(2,r=t.bottom),"slot"in t&&n(3,s=t.slot),"absolute"in t&&n(4,a=t.absolute),"items"in t&&n(5,u=t.items),"size"in t&&n(6,d=t.size),"$$scope"in t&&n(8,i=t.$$scope)},[o,c,r,s,a,u,d,f,i,l,t=>f(t),()=>n(0,o=!1)]}class de extends rt{constructor(t){super(),ct(this,t,ue,ae,c,{show:0,right:1,bottom:2,slot:3,absolute:4,items:5,size:6})}}function fe(t){let e,n,l,i;function o(e){t[7](e)}let c={callback:t[6]};return void 0!==t[0]&&(c.value=t[0]),n=new te({props:c}),S.push((()=>et(n,"value",o,t[0]))),{c(){e=g("div"),nt(n.$$.fragment),D(e,"padding","0.5em 0.5em"),D(e,"font-size","1.1em")},m(t,l){h(t,e,l),lt(n,e,null),i=!0},p(t,e){const i={};12&e&&(i.callback=t[6]),!l&&1&e&&(l=!0,i.value=t[0],U((()=>l=!1))),n.$set(i)},i(t){i||(W(n.$$.fragment,t),i=!0)},o(t){tt(n.$$.fragment,t),i=!1},d(t){t&&m(e),it(n)}}}function he(t){let e,n,l,i,o,c,r;function s(e){t[5](e)}let a={placeholder:t[1],icon:"calendar-month",callback:t[4],change:t[2]};function u(e){t[8](e)}void 0!==t[0]&&(a.value=t[0]),n=new It({props:a}),S.push((()=>et(n,"value",s,t[0])));let d={absolute:!0,right:!0,slot:!0,$$slots:{default:[fe]},$$scope:{ctx:t}};return void 0!==t[3]&&(d.show=t[3]),o=new de({props:d}),S.push((()=>et(o,"show",u,t[3]))),{c(){e=g("div"),nt(n.$$.fragment),i=w(),nt(o.$$.fragment),D(e,"position","relative"),D(e,"max-width","15em")},m(t,l){h(t,e,l),lt(n,e,null),f(e,i),lt(o,e,null),r=!0},p(t,[e]){const i={};2&e&&(i.placeholder=t[1]),4&e&&(i.change=t[2]),!l&&1&e&&(l=!0,i.value=t[0],U((()=>l=!1))),n.$set(i);const r={};525&e&&(r.$$scope={dirty:e,ctx:t}),!c&&8&e&&(c=!0,r.show=t[3],U((()=>c=!1))),o.$set(r)},i(t){r||(W(n.$$.fragment,t),W(o.$$.fragment,t),r=!0)},o(t){tt(n.$$.fragment,t),tt(o.$$.fragment,t),r=!1},d(t){t&&m(e),it(n),it(o)}}}function me(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{change:o}=e,c=!1;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"change"in t&&n(2,o=t.change)},[i,l,o,c,function(){n(3,c=!c)},function(t){i=t,n(0,i)},()=>{o&&o(),n(3,c=!1)},function(t){i=t,n(0,i)},function(t){c=t,n(3,c)}]}class pe extends rt{constructor(t){super(),ct(this,t,me,he,c,{placeholder:1,value:0,change:2})}}function ge(t){let e,n,l;function i(e){t[7](e)}let o={class:"input",type:"text",placeholder:t[1],style:t[2],disabled:t[3],change:t[4]};return void 0!==t[0]&&(o.value=t[0]),e=new Ot({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};2&l&&(i.placeholder=t[1]),4&l&&(i.style=t[2]),8&l&&(i.disabled=t[3]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function $e(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{style:o=""}=e,{disabled:c=!1}=e,{change:r}=e,{float:s=0}=e;function a(t){let e="",l=["",""],o=0,c=-1;for(let t=0;t<i.length;t++){let e=i[t];0==t&&"0"==e||(e>="0"&&e<="9"?l[o]+=e:","==e||"."==e?-1==c&&(o=1,c=t):"-"==e&&0==o&&0==l[o].length&&(l[0]="-"))}l[0].length>13&&(l[0]=l[0].substring(0,13)),l[0]||(l[0]="0"),e=l[0],s>0&&(l[1].length>s?l[1]=l[1].substring(0,s):l[1]+="0".repeat(s-l[1].length),e+="."+l[1]),e!==i&&n(0,i=e),r&&!t&&r()}return T((()=>{a(!0)})),t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"style"in t&&n(2,o=t.style),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(5,r=t.change),"float"in t&&n(6,s=t.float)},[i,l,o,c,function(){a(!1)},r,s,function(t){i=t,n(0,i)}]}class ve extends rt{constructor(t){super(),ct(this,t,$e,ge,c,{placeholder:1,value:0,style:2,disabled:3,change:5,float:6})}}function we(t){let e,n,l;function i(t,e){return t[0]?xe:ye}let o=i(t),c=o(t);return{c(){e=g("div"),c.c(),x(e,"class","input no-right-radius"),D(e,"cursor","pointer"),D(e,"min-height","100%"),D(e,"width","100%")},m(i,o){h(i,e,o),c.m(e,null),n||(l=y(e,"click",t[5]),n=!0)},p(t,n){o===(o=i(t))&&c?c.p(t,n):(c.d(1),c=o(t),c&&(c.c(),c.m(e,null)))},d(t){t&&m(e),c.d(),n=!1,l()}}}function be(t){let e,n,l;return{c(){e=g("input"),D(e,"width","100%"),D(e,"min-height","100%"),x(e,"class","input no-right-radius"),x(e,"type","text"),x(e,"placeholder",t[1])},m(i,c){h(i,e,c),q(e,t[0]),n||(l=[y(e,"input",t[10]),y(e,"input",(function(){o(t[2])&&t[2].apply(this,arguments)}))],n=!0)},p(n,l){t=n,2&l&&x(e,"placeholder",t[1]),1&l&&e.value!==t[0]&&q(e,t[0])},d(t){t&&m(e),n=!1,i(l)}}}function ye(t){let n,l,i=t[4].selectitem+"";return{c(){n=v(i),l=v("...")},m(t,e){h(t,n,e),h(t,l,e)},p:e,d(t){t&&m(n),t&&m(l)}}}function xe(t){let e;return{c(){e=v(t[0])},m(t,n){h(t,e,n)},p(t,n){1&n&&_(e,t[0])},d(t){t&&m(e)}}}function ke(t){let n,l,i,o,c,r;function s(t,e){return t[3]?be:we}let a=s(t),u=a(t);return{c(){n=g("div"),l=g("div"),u.c(),i=w(),o=g("div"),o.innerHTML='<svg style="width:1.5em;height: 1.5em;fill:inherit"><use xlink:href="#list-alt"></use></svg>',D(l,"flex-grow","1"),x(o,"class","input-right-btn"),D(n,"display","flex"),D(n,"align-items","stretch")},m(e,s){h(e,n,s),f(n,l),u.m(l,null),f(n,i),f(n,o),c||(r=y(o,"click",t[5]),c=!0)},p(t,[e]){a===(a=s(t))&&u?u.p(t,e):(u.d(1),u=a(t),u&&(u.c(),u.m(l,null)))},i:e,o:e,d(t){t&&m(n),u.d(),c=!1,r()}}}function ze(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{callback:o}=e,{change:c}=e,{table:r}=e,{title:s}=e,{editable:a}=e,{fromid:u=""}=e,d=L("ctx"),f=d.lng;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"callback"in t&&n(6,o=t.callback),"change"in t&&n(2,c=t.change),"table"in t&&n(7,r=t.table),"title"in t&&n(8,s=t.title),"editable"in t&&n(3,a=t.editable),"fromid"in t&&n(9,u=t.fromid)},[i,l,c,a,f,function(){d.fn.selectInTable({table:r,fromid:u,title:s||f.selectitem,callback:(t,e)=>{"select"==t&&o(t,e)}})},o,r,s,u,function(){i=this.value,n(0,i)}]}class _e extends rt{constructor(t){super(),ct(this,t,ze,ke,c,{placeholder:1,value:0,callback:6,change:2,table:7,title:8,editable:3,fromid:9})}}function qe(t){let e,n,l=t[10]&&De();return{c(){e=g("div"),n=v(t[1]),l&&l.c(),x(e,"class","form-label")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){2&i&&_(n,t[1]),t[10]?l||(l=De(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function De(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function je(t){let e,n,l;function i(e){t[16](e)}let o={placeholder:t[2],disabled:t[3],change:t[4],size:t[9]};return void 0!==t[0]&&(o.value=t[0]),e=new Ot({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),8&l&&(i.disabled=t[3]),16&l&&(i.change=t[4]),512&l&&(i.size=t[9]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Ee(t){let e,n,l;function i(e){t[15](e)}let o={placeholder:t[2],change:t[4],table:t[6],callback:t[11],fromid:t[9],editable:t[7]};return void 0!==t[0]&&(o.value=t[0]),e=new _e({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),16&l&&(i.change=t[4]),64&l&&(i.table=t[6]),2048&l&&(i.callback=t[11]),512&l&&(i.fromid=t[9]),128&l&&(i.editable=t[7]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Me(t){let e,n,l;function i(e){t[14](e)}let o={placeholder:t[2],float:t[9],change:t[4]};return void 0!==t[0]&&(o.value=t[0]),e=new ve({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),512&l&&(i.float=t[9]),16&l&&(i.change=t[4]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Ae(t){let e,n,l;function i(e){t[13](e)}let o={placeholder:t[2],change:t[4]};return void 0!==t[0]&&(o.value=t[0]),e=new pe({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),16&l&&(i.change=t[4]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Ne(t){let e,n,l;function i(e){t[12](e)}let o={placeholder:t[2]};return void 0!==t[0]&&(o.value=t[0]),e=new Pt({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Te(t){let e,n,l,i,o,c,r=t[1]&&qe(t);const s=[Ne,Ae,Me,Ee,je],a=[];function u(t,e){return"password"==t[5]?0:"date"==t[5]?1:"money"==t[5]?2:"link"==t[5]?3:4}return l=u(t),i=a[l]=s[l](t),{c(){e=g("div"),r&&r.c(),n=w(),i.c(),x(e,"class","form-input"),x(e,"style",o=t[8]>0?"max-width:"+t[8]+"em":"")},m(t,i){h(t,e,i),r&&r.m(e,null),f(e,n),a[l].m(e,null),c=!0},p(t,[d]){t[1]?r?r.p(t,d):(r=qe(t),r.c(),r.m(e,n)):r&&(r.d(1),r=null);let f=l;l=u(t),l===f?a[l].p(t,d):(R(),tt(a[f],1,1,(()=>{a[f]=null})),V(),i=a[l],i?i.p(t,d):(i=a[l]=s[l](t),i.c()),W(i,1),i.m(e,null)),(!c||256&d&&o!==(o=t[8]>0?"max-width:"+t[8]+"em":""))&&x(e,"style",o)},i(t){c||(W(i),c=!0)},o(t){tt(i),c=!1},d(t){t&&m(e),r&&r.d(),a[l].d()}}}function Fe(t,e,n){let{title:l}=e,{value:i=""}=e,{placeholder:o=""}=e,{disabled:c=!1}=e,{change:r}=e,{type:s}=e,{link:a}=e,{linkedit:u}=e,{width:d=0}=e,{size:f=0}=e,{req:h=!1}=e,{callback:m}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"placeholder"in t&&n(2,o=t.placeholder),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(4,r=t.change),"type"in t&&n(5,s=t.type),"link"in t&&n(6,a=t.link),"linkedit"in t&&n(7,u=t.linkedit),"width"in t&&n(8,d=t.width),"size"in t&&n(9,f=t.size),"req"in t&&n(10,h=t.req),"callback"in t&&n(11,m=t.callback)},[i,l,o,c,r,s,a,u,d,f,h,m,function(t){i=t,n(0,i)},function(t){i=t,n(0,i)},function(t){i=t,n(0,i)},function(t){i=t,n(0,i)},function(t){i=t,n(0,i)}]}class Ce extends rt{constructor(t){super(),ct(this,t,Fe,Te,c,{title:1,value:0,placeholder:2,disabled:3,change:4,type:5,link:6,linkedit:7,width:8,size:9,req:10,callback:11})}}function Le(t){let e,n,l=t[3]&&Oe();return{c(){e=g("div"),n=v(t[1]),l&&l.c(),x(e,"class","form-label")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){2&i&&_(n,t[1]),t[3]?l||(l=Oe(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function Oe(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function Se(t){let n,l,c,r,s,a=t[1]&&Le(t);return{c(){n=g("div"),a&&a.c(),l=w(),c=g("textarea"),x(c,"rows",t[2]),x(c,"contenteditable",""),x(c,"class","input"),x(c,"placeholder",t[4]),D(c,"width","100%"),x(n,"class","form-input"),D(n,"width","100%")},m(e,i){h(e,n,i),a&&a.m(n,null),f(n,l),f(n,c),q(c,t[0]),r||(s=[y(c,"input",t[6]),y(c,"input",(function(){o(t[5])&&t[5].apply(this,arguments)}))],r=!0)},p(e,[i]){(t=e)[1]?a?a.p(t,i):(a=Le(t),a.c(),a.m(n,l)):a&&(a.d(1),a=null),4&i&&x(c,"rows",t[2]),16&i&&x(c,"placeholder",t[4]),1&i&&q(c,t[0])},i:e,o:e,d(t){t&&m(n),a&&a.d(),r=!1,i(s)}}}function Ye(t,e,n){let{title:l}=e,{value:i=""}=e,{size:o=5}=e,{req:c=!1}=e,{placeholder:r=""}=e,{change:s}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"size"in t&&n(2,o=t.size),"req"in t&&n(3,c=t.req),"placeholder"in t&&n(4,r=t.placeholder),"change"in t&&n(5,s=t.change)},[i,l,o,c,r,s,function(){i=this.value,n(0,i)}]}class Pe extends rt{constructor(t){super(),ct(this,t,Ye,Se,c,{title:1,value:0,size:2,req:3,placeholder:4,change:5})}}function Be(t,e,n){const l=t.slice();return l[7]=e[n],l}function He(t){let e,n,l=t[5]&&Ie();return{c(){e=g("div"),n=v(t[3]),l&&l.c(),x(e,"class","form-label")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){8&i&&_(n,t[3]),t[5]?l||(l=Ie(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function Ie(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function Ue(t){let e,n,l,i=t[7].title+"";return{c(){e=g("option"),n=v(i),e.__value=l=t[7].id,e.value=e.__value},m(t,l){h(t,e,l),f(e,n)},p(t,o){2&o&&i!==(i=t[7].title+"")&&_(n,i),2&o&&l!==(l=t[7].id)&&(e.__value=l,e.value=e.__value)},d(t){t&&m(e)}}}function Xe(t){let n,l,c,r,s,a,u=t[3]&&He(t),d=t[1],$=[];for(let e=0;e<d.length;e+=1)$[e]=Ue(Be(t,d,e));return{c(){n=g("div"),u&&u.c(),l=w(),c=g("select");for(let t=0;t<$.length;t+=1)$[t].c();x(c,"class","form-select"),D(c,"font-size","1em"),void 0===t[0]&&I((()=>t[6].call(c))),x(n,"class","form-input"),x(n,"style",r=t[2]>0?"max-width:"+t[2]+"em":"")},m(e,i){h(e,n,i),u&&u.m(n,null),f(n,l),f(n,c);for(let t=0;t<$.length;t+=1)$[t].m(c,null);j(c,t[0]),s||(a=[y(c,"change",t[6]),y(c,"change",(function(){o(t[4])&&t[4].apply(this,arguments)}))],s=!0)},p(e,[i]){if((t=e)[3]?u?u.p(t,i):(u=He(t),u.c(),u.m(n,l)):u&&(u.d(1),u=null),2&i){let e;for(d=t[1],e=0;e<d.length;e+=1){const n=Be(t,d,e);$[e]?$[e].p(n,i):($[e]=Ue(n),$[e].c(),$[e].m(c,null))}for(;e<$.length;e+=1)$[e].d(1);$.length=d.length}3&i&&j(c,t[0]),4&i&&r!==(r=t[2]>0?"max-width:"+t[2]+"em":"")&&x(n,"style",r)},i:e,o:e,d(t){t&&m(n),u&&u.d(),p($,t),s=!1,i(a)}}}function Ze(t,e,n){let{value:l=0}=e,{list:i=[]}=e,{width:o=0}=e,{title:c=""}=e,{change:r}=e,{req:s=!1}=e;return F((()=>{if("string"==typeof l)for(let t=0;t<i.length;t++){if(l==i[t].id){n(0,l=i[t].id);break}if(l==i[t].title){n(0,l=i[t].id);break}}})),t.$$set=t=>{"value"in t&&n(0,l=t.value),"list"in t&&n(1,i=t.list),"width"in t&&n(2,o=t.width),"title"in t&&n(3,c=t.title),"change"in t&&n(4,r=t.change),"req"in t&&n(5,s=t.req)},[l,i,o,c,r,s,function(){l=function(t){const e=t.querySelector(":checked")||t.options[0];return e&&e.__value}(this),n(0,l),n(1,i)}]}class Ge extends rt{constructor(t){super(),ct(this,t,Ze,Xe,c,{value:0,list:1,width:2,title:3,change:4,req:5})}}function Je(t){let e,n,l=t[7]&&Ke();return{c(){e=g("div"),n=v(t[1]),l&&l.c(),x(e,"class","form-label"),D(e,"margin","0"),D(e,"padding-right","1em")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){2&i&&_(n,t[1]),t[7]?l||(l=Ke(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function Ke(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function Qe(t){let n,l,c,r,s,a,u,d,p=t[1]&&Je(t);return{c(){n=g("div"),l=g("div"),p&&p.c(),c=w(),r=g("input"),x(r,"class","input"),x(r,"type","number"),x(r,"placeholder",t[2]),r.disabled=t[3],x(r,"style",s=t[6]>0?"max-width:"+t[6]+"em":""),D(l,"display","flex"),D(l,"justify-content","start"),D(l,"align-items","center"),x(n,"class","form-input"),x(n,"style",a=t[5]>0?"max-width:"+t[5]+"em":"")},m(e,i){h(e,n,i),f(n,l),p&&p.m(l,null),f(l,c),f(l,r),q(r,t[0]),u||(d=[y(r,"input",t[8]),y(r,"input",(function(){o(t[4])&&t[4].apply(this,arguments)}))],u=!0)},p(e,[i]){(t=e)[1]?p?p.p(t,i):(p=Je(t),p.c(),p.m(l,c)):p&&(p.d(1),p=null),4&i&&x(r,"placeholder",t[2]),8&i&&(r.disabled=t[3]),64&i&&s!==(s=t[6]>0?"max-width:"+t[6]+"em":"")&&x(r,"style",s),1&i&&z(r.value)!==t[0]&&q(r,t[0]),32&i&&a!==(a=t[5]>0?"max-width:"+t[5]+"em":"")&&x(n,"style",a)},i:e,o:e,d(t){t&&m(n),p&&p.d(),u=!1,i(d)}}}function Re(t,e,n){let{title:l}=e,{value:i=""}=e,{placeholder:o=""}=e,{disabled:c=!1}=e,{change:r}=e,{width:s=0}=e,{size:a=0}=e,{req:u=!1}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"placeholder"in t&&n(2,o=t.placeholder),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(4,r=t.change),"width"in t&&n(5,s=t.width),"size"in t&&n(6,a=t.size),"req"in t&&n(7,u=t.req)},[i,l,o,c,r,s,a,u,function(){i=z(this.value),n(0,i)}]}class Ve extends rt{constructor(t){super(),ct(this,t,Re,Qe,c,{title:1,value:0,placeholder:2,disabled:3,change:4,width:5,size:6,req:7})}}function We(t,e,n){const l=t.slice();return l[8]=e[n],l[9]=e,l[10]=n,l}function tn(t){let e,n,l,c,r,s=t[8].title+"";function a(){t[5].call(n,t[8])}return{c(){e=g("label"),n=g("input"),l=v(s),x(n,"class","form-check-input"),x(n,"type","checkbox"),x(e,"class","form-label"),D(e,"margin-right","1em")},m(i,s){h(i,e,s),f(e,n),n.checked=t[0][t[8].id],f(e,l),c||(r=[y(n,"change",a),y(n,"input",(function(){o(t[3])&&t[3].apply(this,arguments)}))],c=!0)},p(e,i){t=e,17&i&&(n.checked=t[0][t[8].id]),16&i&&s!==(s=t[8].title+"")&&_(l,s)},d(t){t&&m(e),c=!1,i(r)}}}function en(t){let n,l,i,o,c,r,s=t[4],a=[];for(let e=0;e<s.length;e+=1)a[e]=tn(We(t,s,e));return{c(){n=g("div"),l=g("div"),i=v(t[1]),o=w(),c=g("div");for(let t=0;t<a.length;t+=1)a[t].c();x(l,"class","form-label"),D(c,"display","flex"),D(c,"flex-wrap","wrap"),x(n,"class","form-input"),x(n,"style",r=t[2]>0?"max-width:"+t[2]+"em":"")},m(t,e){h(t,n,e),f(n,l),f(l,i),f(n,o),f(n,c);for(let t=0;t<a.length;t+=1)a[t].m(c,null)},p(t,[e]){if(2&e&&_(i,t[1]),25&e){let n;for(s=t[4],n=0;n<s.length;n+=1){const l=We(t,s,n);a[n]?a[n].p(l,e):(a[n]=tn(l),a[n].c(),a[n].m(c,null))}for(;n<a.length;n+=1)a[n].d(1);a.length=s.length}4&e&&r!==(r=t[2]>0?"max-width:"+t[2]+"em":"")&&x(n,"style",r)},i:e,o:e,d(t){t&&m(n),p(a,t)}}}function nn(t,e,n){let{title:l}=e,{values:i={}}=e,{width:o=0}=e,{change:c}=e,{list:r=[]}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"values"in t&&n(0,i=t.values),"width"in t&&n(2,o=t.width),"change"in t&&n(3,c=t.change),"list"in t&&n(4,r=t.list)},[i,l,o,c,r,function(t){i[t.id]=this.checked,n(0,i)}]}class ln extends rt{constructor(t){super(),ct(this,t,nn,en,c,{title:1,values:0,width:2,change:3,list:4})}}function on(t){let n,l,c,r,s,a,u;return{c(){n=g("div"),l=g("label"),c=g("input"),r=v(t[1]),x(c,"class","form-check-input"),x(c,"type","checkbox"),x(l,"class","form-label"),x(n,"class","form-input"),x(n,"style",s=t[2]>0?"max-width:"+t[2]+"em":"")},m(e,i){h(e,n,i),f(n,l),f(l,c),c.checked=t[0],f(l,r),a||(u=[y(c,"change",t[4]),y(c,"input",(function(){o(t[3])&&t[3].apply(this,arguments)}))],a=!0)},p(e,[l]){t=e,1&l&&(c.checked=t[0]),2&l&&_(r,t[1]),4&l&&s!==(s=t[2]>0?"max-width:"+t[2]+"em":"")&&x(n,"style",s)},i:e,o:e,d(t){t&&m(n),a=!1,i(u)}}}function cn(t,e,n){let{title:l}=e,{value:i=""}=e,{width:o=0}=e,{change:c}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"width"in t&&n(2,o=t.width),"change"in t&&n(3,c=t.change)},[i,l,o,c,function(){i=this.checked,n(0,i)}]}class rn extends rt{constructor(t){super(),ct(this,t,cn,on,c,{title:1,value:0,width:2,change:3})}}function sn(t){let e,n,l,i,o,c,r,s,a,u,d,f;function p(e){t[30](e)}let g={type:"text",title:"Дни месяца (через запятую)",width:"20",req:!0,change:t[29]};function $(e){t[32](e)}void 0!==t[6]&&(g.value=t[6]),e=new Ce({props:g}),S.push((()=>et(e,"value",p,t[6])));let v={title:"Предпоследний день месяца",width:"20",change:t[31]};function b(e){t[34](e)}void 0!==t[9]&&(v.value=t[9]),i=new rn({props:v}),S.push((()=>et(i,"value",$,t[9])));let y={title:"Последний день месяца",width:"20",change:t[33]};function x(e){t[36](e)}void 0!==t[10]&&(y.value=t[10]),r=new rn({props:y}),S.push((()=>et(r,"value",b,t[10])));let k={width:"25",title:"Месяцы",list:t[13],change:t[35]};return void 0!==t[7]&&(k.values=t[7]),u=new ln({props:k}),S.push((()=>et(u,"values",x,t[7]))),{c(){nt(e.$$.fragment),l=w(),nt(i.$$.fragment),c=w(),nt(r.$$.fragment),a=w(),nt(u.$$.fragment)},m(t,n){lt(e,t,n),h(t,l,n),lt(i,t,n),h(t,c,n),lt(r,t,n),h(t,a,n),lt(u,t,n),f=!0},p(t,l){const c={};!n&&64&l[0]&&(n=!0,c.value=t[6],U((()=>n=!1))),e.$set(c);const a={};!o&&512&l[0]&&(o=!0,a.value=t[9],U((()=>o=!1))),i.$set(a);const f={};!s&&1024&l[0]&&(s=!0,f.value=t[10],U((()=>s=!1))),r.$set(f);const h={};!d&&128&l[0]&&(d=!0,h.values=t[7],U((()=>d=!1))),u.$set(h)},i(t){f||(W(e.$$.fragment,t),W(i.$$.fragment,t),W(r.$$.fragment,t),W(u.$$.fragment,t),f=!0)},o(t){tt(e.$$.fragment,t),tt(i.$$.fragment,t),tt(r.$$.fragment,t),tt(u.$$.fragment,t),f=!1},d(t){it(e,t),t&&m(l),it(i,t),t&&m(c),it(r,t),t&&m(a),it(u,t)}}}function an(t){let e,n,l;function i(e){t[28](e)}let o={width:"25",title:"Дни недели",list:t[12],change:t[27]};return void 0!==t[8]&&(o.values=t[8]),e=new ln({props:o}),S.push((()=>et(e,"values",i,t[8]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};!n&&256&l[0]&&(n=!0,i.values=t[8],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function un(t){let e,n,l;function i(e){t[26](e)}let o={title:"Каждые X дней",change:t[25],size:"5",width:"20"};return void 0!==t[5]&&(o.value=t[5]),e=new Ve({props:o}),S.push((()=>et(e,"value",i,t[5]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};!n&&32&l[0]&&(n=!0,i.value=t[5],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function dn(t){let e,n,l,i,o,c,r,s,a,u,d,p,$,v,b,y,k,z;function _(e){t[18](e)}let q={type:"date",title:"Дата",width:"13",req:!0,change:t[17]};function j(e){t[20](e)}void 0!==t[3].date&&(q.value=t[3].date),l=new Ce({props:q}),S.push((()=>et(l,"value",_,t[3].date)));let E={type:"text",title:"Задача",width:"20",req:!0,change:t[19]};function M(e){t[22](e)}void 0!==t[3].title&&(E.value=t[3].title),c=new Ce({props:E}),S.push((()=>et(c,"value",j,t[3].title)));let A={title:"Комментарий",size:"3",change:t[21]};function N(e){t[24](e)}void 0!==t[3].comment&&(A.value=t[3].comment),a=new Pe({props:A}),S.push((()=>et(a,"value",M,t[3].comment)));let T={title:"Правило повторения",width:"20",change:t[23],list:t[11]};void 0!==t[4]&&(T.value=t[4]),$=new Ge({props:T}),S.push((()=>et($,"value",N,t[4])));const F=[un,an,sn],C=[];function L(t,e){return 1==t[4]?0:2==t[4]?1:3==t[4]?2:-1}return~(y=L(t))&&(k=C[y]=F[y](t)),{c(){e=g("div"),n=g("div"),nt(l.$$.fragment),o=w(),nt(c.$$.fragment),s=w(),nt(a.$$.fragment),d=w(),p=g("div"),nt($.$$.fragment),b=w(),k&&k.c(),D(n,"padding-right","1em"),D(p,"padding-left","1em"),x(e,"slot","content"),D(e,"padding-bottom","1em"),D(e,"min-width","20em"),D(e,"display","flex ")},m(t,i){h(t,e,i),f(e,n),lt(l,n,null),f(n,o),lt(c,n,null),f(n,s),lt(a,n,null),f(e,d),f(e,p),lt($,p,null),f(p,b),~y&&C[y].m(p,null),z=!0},p(t,e){const n={};!i&&8&e[0]&&(i=!0,n.value=t[3].date,U((()=>i=!1))),l.$set(n);const o={};!r&&8&e[0]&&(r=!0,o.value=t[3].title,U((()=>r=!1))),c.$set(o);const s={};!u&&8&e[0]&&(u=!0,s.value=t[3].comment,U((()=>u=!1))),a.$set(s);const d={};!v&&16&e[0]&&(v=!0,d.value=t[4],U((()=>v=!1))),$.$set(d);let f=y;y=L(t),y===f?~y&&C[y].p(t,e):(k&&(R(),tt(C[f],1,1,(()=>{C[f]=null})),V()),~y?(k=C[y],k?k.p(t,e):(k=C[y]=F[y](t),k.c()),W(k,1),k.m(p,null)):k=null)},i(t){z||(W(l.$$.fragment,t),W(c.$$.fragment,t),W(a.$$.fragment,t),W($.$$.fragment,t),W(k),z=!0)},o(t){tt(l.$$.fragment,t),tt(c.$$.fragment,t),tt(a.$$.fragment,t),tt($.$$.fragment,t),tt(k),z=!1},d(t){t&&m(e),it(l),it(c),it(a),it($),~y&&C[y].d()}}}function fn(t){let n,l,i;return{c(){n=g("button"),n.textContent="Сохранить",x(n,"class","btn primary"),D(n,"font-size","0.9em")},m(e,o){h(e,n,o),l||(i=y(n,"click",t[14]),l=!0)},p:e,d(t){t&&m(n),l=!1,i()}}}function hn(t){let e,n,l,i,c,r,s=t[2]?"Отменить":"Закрыть",a=t[2]&&fn(t);return{c(){e=g("div"),a&&a.c(),n=w(),l=g("button"),i=v(s),x(l,"class","btn secondary"),D(l,"font-size","0.9em"),x(e,"slot","footer"),D(e,"flex-grow","1"),D(e,"display","flex"),D(e,"column-gap","0.5em"),D(e,"justify-content","end")},m(s,u){h(s,e,u),a&&a.m(e,null),f(e,n),f(e,l),f(l,i),c||(r=y(l,"click",(function(){o(t[1].close)&&t[1].close.apply(this,arguments)})),c=!0)},p(l,o){(t=l)[2]?a?a.p(t,o):(a=fn(t),a.c(),a.m(e,n)):a&&(a.d(1),a=null),4&o[0]&&s!==(s=t[2]?"Отменить":"Закрыть")&&_(i,s)},d(t){t&&m(e),a&&a.d(),c=!1,r()}}}function mn(t){let e,n,l;function i(e){t[37](e)}let o={header:t[0].title,icon:t[0].icon,$$slots:{footer:[hn],content:[dn]},$$scope:{ctx:t}};return void 0!==t[1]&&(o.mod=t[1]),e=new Ft({props:o}),S.push((()=>et(e,"mod",i,t[1]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};1&l[0]&&(i.header=t[0].title),1&l[0]&&(i.icon=t[0].icon),2046&l[0]|32768&l[1]&&(i.$$scope={dirty:l,ctx:t}),!n&&2&l[0]&&(n=!0,i.mod=t[1],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function pn(t,e,n){const l={show(t){n(2,r=null),n(0,c=t),c.icon||n(0,c.icon={},c),c.param||n(0,c.param={},c),n(3,u={}),o={},""!=c.id?ut("task?id="+c.id,(t=>{n(3,u={...t});let e=u.date;n(3,u.date=e.substr(6)+"."+e.substr(4,2)+"."+e.substr(0,4),u),function(t){for(let t=0;t<h.length;t++)n(7,$[h[t].id]=!0,$);for(let t=0;t<f.length;t++)n(8,v[f[t].id]=!1,v);if(!t)return void n(4,m=0);let e=t.split(" ");switch(t[0]){case"d":n(4,m=1),e.length>1&&n(5,p=e[1]);break;case"w":if(n(4,m=2),e.length>1){let t=e[1].split(",");for(let e=0;e<t.length;e++){let l=t[e];l>0&&l<8&&n(8,v[l]=!0,v)}}break;case"m":if(n(4,m=3),e.length>1){let t=e[1].split(","),l=[];for(let e=0;e<t.length;e++){let i=t[e];i>0&&i<32?l.push(i):-1==i?n(10,b=!0):-2==i&&n(9,w=!0)}n(6,g=l.join(","))}if(e.length>2){for(let t=0;t<h.length;t++)n(7,$[h[t].id]=!1,$);let t=e[2].split(",");for(let e=0;e<t.length;e++){let l=t[e];l>0&&l<13&&n(7,$[l]=!0,$)}}break;case"y":n(4,m=4);break;default:n(4,m=0)}}(u.repeat)}),s.fn.error):n(3,u={date:"",title:""}),i.show()}};let i,o={},c={},r=null;const s=L("ctx");let a=s.lng,u={},d={date:"Дата",title:"Задача"},f=[{id:1,title:"пн"},{id:2,title:"вт"},{id:3,title:"ср"},{id:4,title:"чт"},{id:5,title:"пт"},{id:6,title:"сб"},{id:7,title:"вс"}],h=[{id:1,title:"январь"},{id:2,title:"февраль"},{id:3,title:"март"},{id:4,title:"апрель"},{id:5,title:"май"},{id:6,title:"июнь"},{id:7,title:"июль"},{id:8,title:"август"},{id:9,title:"сентябрь"},{id:10,title:"октябрь"},{id:11,title:"ноябрь"},{id:12,title:"декабрь"}],m=0,p=1,g="",$={},v={},w=!1,b=!1;function y(t,e){n(2,r=null),c.callback("update",t,e),i.close()}function x(t){r||n(2,r={}),n(2,r[t]=!0,r)}return[c,i,r,u,m,p,g,$,v,w,b,[{id:0,title:"Не повторять"},{id:1,title:"Повторять с интервалом"},{id:2,title:"Еженедельно"},{id:3,title:"Ежемесячно"},{id:4,title:"Ежегодно"}],f,h,function(){let t={};t=(c.id,{...u}),Object.keys(o).forEach((function(e){t[e]=o[e]}));let e=[];for(const n in t)t.hasOwnProperty(n)&&!t[n]&&d[n]&&e.push(d[n]);if(e.length>0)return void s.fn.error((1==e.length?a.ereq:a.ereqs)+e.join(", "));t.repeat=function(){let t="";switch(m){case 1:p<=0&&n(5,p=1),t+="d "+p;break;case 2:t+="w";let e=[];Object.keys(v).forEach((function(t){v[t]&&e.push(t)})),e.length>0&&(t+=" "+e.join(","));break;case 3:t+="m ";let l=[];if(g){let t=g.split(",");for(let e=0;e<t.length;e++){let n=t[e].trim();n>=1&&n<=31&&l.push(n)}}b&&l.push("-1"),w&&l.push("-2"),0==l.length?t+="x":t+=l.join(",");let i=[];Object.keys($).forEach((function(t){$[t]&&i.push(t)})),i.length>0&&12!=i.length&&(t+=" "+i.join(","));break;case 4:t+="y"}return t}();let l=t.date;t.date=l.substr(6)+l.substr(3,2)+l.substr(0,2);let i=t;t.id?function(t,e,n,l){axios.put("api/"+t,e).then((t=>{if(t.data.error)l(t.data.error);else{let e=n(t.data);e&&l(e)}})).catch((t=>{l(t)}))}("task",i,(t=>{y(t,i)}),s.fn.error):st("task",i,(t=>{y(t,i)}),s.fn.error)},x,l,()=>{x("date")},function(e){t.$$.not_equal(u.date,e)&&(u.date=e,n(3,u))},()=>{x("title")},function(e){t.$$.not_equal(u.title,e)&&(u.title=e,n(3,u))},()=>{x("comment")},function(e){t.$$.not_equal(u.comment,e)&&(u.comment=e,n(3,u))},()=>{x("repeat")},function(t){m=t,n(4,m)},()=>{x("repeat")},function(t){p=t,n(5,p)},()=>{x("repeat")},function(t){v=t,n(8,v)},()=>{x("repeat")},function(t){g=t,n(6,g)},()=>{x("repeat")},function(t){w=t,n(9,w)},()=>{x("repeat")},function(t){b=t,n(10,b)},()=>{x("month")},function(t){$=t,n(7,$)},function(t){i=t,n(1,i)}]}class gn extends rt{constructor(t){super(),ct(this,t,pn,mn,c,{showForm:16},null,[-1,-1])}get showForm(){return this.$$.ctx[16]}}function $n(t){let e,n,l;return{c(){e=g("div"),n=g("p"),l=v(t[3]),x(e,"slot","content"),D(e,"padding","1em 0em")},m(t,i){h(t,e,i),f(e,n),f(n,l)},p(t,e){8&e&&_(l,t[3])},d(t){t&&m(e)}}}function vn(t){let e,n,l;function i(e){t[6](e)}let o={header:t[0],icon:t[1],btns:t[2],$$slots:{content:[$n]},$$scope:{ctx:t}};return void 0!==t[4]&&(o.mod=t[4]),e=new Ft({props:o}),S.push((()=>et(e,"mod",i,t[4]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0]),2&l&&(i.icon=t[1]),4&l&&(i.btns=t[2]),136&l&&(i.$$scope={dirty:l,ctx:t}),!n&&16&l&&(n=!0,i.mod=t[4],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function wn(t,e,n){let{header:l=""}=e,{icon:i={}}=e,{btns:o=[]}=e;const c={show(t){n(3,r=t),s.show()}};let r,s;return t.$$set=t=>{"header"in t&&n(0,l=t.header),"icon"in t&&n(1,i=t.icon),"btns"in t&&n(2,o=t.btns)},[l,i,o,r,s,c,function(t){s=t,n(4,s)}]}class bn extends rt{constructor(t){super(),ct(this,t,wn,vn,c,{header:0,icon:1,btns:2,msg:5})}get msg(){return this.$$.ctx[5]}}function yn(t){let e,n,l;function i(e){t[3](e)}let o={header:t[0].error,icon:{name:"close-circle",color:"var(--red)"},btns:[{title:t[0].close,class:"secondary"}]};return void 0!==t[1]&&(o.msg=t[1]),e=new bn({props:o}),S.push((()=>et(e,"msg",i,t[1]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0].error),1&l&&(i.btns=[{title:t[0].close,class:"secondary"}]),!n&&2&l&&(n=!0,i.msg=t[1],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function xn(t,e,n){let{lng:l={}}=e;const i={show(t){o.show(t)}};let o;return t.$$set=t=>{"lng"in t&&n(0,l=t.lng)},[l,o,i,function(t){o=t,n(1,o)}]}class kn extends rt{constructor(t){super(),ct(this,t,xn,yn,c,{lng:0,err:2})}get err(){return this.$$.ctx[2]}}function zn(t){let e,n,l;function i(e){t[7](e)}let o={header:t[0].confirm,icon:{name:"help-circle"},btns:[{title:t[0].yes,class:"primary",callback:t[5]},{title:t[0].no,class:"secondary",callback:t[6]}]};return void 0!==t[3]&&(o.msg=t[3]),e=new bn({props:o}),S.push((()=>et(e,"msg",i,t[3]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0].confirm),7&l&&(i.btns=[{title:t[0].yes,class:"primary",callback:t[5]},{title:t[0].no,class:"secondary",callback:t[6]}]),!n&&8&l&&(n=!0,i.msg=t[3],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function _n(t,e,n){let{lng:l={}}=e;const i={show(t,e,l){n(1,o=e),n(2,c=l),r.show(t)}};let o,c,r;return t.$$set=t=>{"lng"in t&&n(0,l=t.lng)},[l,o,c,r,i,()=>{o&&o()},()=>{c&&c()},function(t){r=t,n(3,r)}]}class qn extends rt{constructor(t){super(),ct(this,t,_n,zn,c,{lng:0,confirm:4})}get confirm(){return this.$$.ctx[4]}}function Dn(t){let e,n,l;function i(e){t[5](e)}let o={header:t[0].info,icon:{name:"information-slab-circle"},btns:[{title:t[0].ok,class:"primary",callback:t[4]}]};return void 0!==t[1]&&(o.msg=t[1]),e=new bn({props:o}),S.push((()=>et(e,"msg",i,t[1]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0].info),5&l&&(i.btns=[{title:t[0].ok,class:"primary",callback:t[4]}]),!n&&2&l&&(n=!0,i.msg=t[1],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function jn(t,e,n){let{lng:l={}}=e;const i={show(t,e){n(2,c=e),o.show(t)}};let o,c;return t.$$set=t=>{"lng"in t&&n(0,l=t.lng)},[l,o,c,i,()=>{c&&c()},function(t){o=t,n(1,o)}]}class En extends rt{constructor(t){super(),ct(this,t,jn,Dn,c,{lng:0,info:3})}get info(){return this.$$.ctx[3]}}function Mn(t,e,n){const l=t.slice();return l[28]=e[n],l}function An(t,e,n){const l=t.slice();return l[31]=e[n],l}function Nn(t){let n,l,i;return{c(){n=g("button"),n.textContent="Отмена",x(n,"class","btn secondary")},m(e,o){h(e,n,o),l||(i=y(n,"click",t[15]),l=!0)},p:e,d(t){t&&m(n),l=!1,i()}}}function Tn(t){let e,n,l,i=t[4],o=[];for(let e=0;e<i.length;e+=1)o[e]=On(Mn(t,i,e));const c=t=>tt(o[t],1,1,(()=>{o[t]=null}));let r=0==t[4].length&&t[6]&&Sn();return{c(){for(let t=0;t<o.length;t+=1)o[t].c();e=w(),r&&r.c(),n=b()},m(t,i){for(let e=0;e<o.length;e+=1)o[e].m(t,i);h(t,e,i),r&&r.m(t,i),h(t,n,i),l=!0},p(t,l){if(7184&l[0]){let n;for(i=t[4],n=0;n<i.length;n+=1){const c=Mn(t,i,n);o[n]?(o[n].p(c,l),W(o[n],1)):(o[n]=On(c),o[n].c(),W(o[n],1),o[n].m(e.parentNode,e))}for(R(),n=i.length;n<o.length;n+=1)c(n);V()}0==t[4].length&&t[6]?r?80&l[0]&&W(r,1):(r=Sn(),r.c(),W(r,1),r.m(n.parentNode,n)):r&&(R(),tt(r,1,1,(()=>{r=null})),V())},i(t){if(!l){for(let t=0;t<i.length;t+=1)W(o[t]);W(r),l=!0}},o(t){o=o.filter(Boolean);for(let t=0;t<o.length;t+=1)tt(o[t]);tt(r),l=!1},d(t){p(o,t),t&&m(e),r&&r.d(t),t&&m(n)}}}function Fn(t){let e,n,l;return n=new mt({props:{color:"var(--gray-500)",name:"repeat-variant",size:"1.25em"}}),{c(){e=g("div"),nt(n.$$.fragment),x(e,"class","fav")},m(t,i){h(t,e,i),lt(n,e,null),l=!0},i(t){l||(W(n.$$.fragment,t),l=!0)},o(t){tt(n.$$.fragment,t),l=!1},d(t){t&&m(e),it(n)}}}function Cn(t){let e,n,l=t[31].comment+"";return{c(){e=g("div"),n=v(l),x(e,"class","notetext")},m(t,l){h(t,e,l),f(e,n)},p(t,e){16&e[0]&&l!==(l=t[31].comment+"")&&_(n,l)},d(t){t&&m(e)}}}function Ln(t){let e,n,l,o,c,r,s,a,u,d,p,$,b,k,z,q,j,E,M,A,N,T,F=t[31].title+"",C=t[31].repeat&&Fn();function L(){return t[16](t[31])}let O=t[31].comment&&Cn(t);function S(){return t[17](t[31])}function Y(){return t[18](t[31])}function P(...e){return t[19](t[31],...e)}return b=new mt({props:{name:"check-bold",size:"1.4em;"}}),q=new mt({props:{name:"edit",size:"1.4em;"}}),M=new mt({props:{name:"trash-can-outline"}}),{c(){e=g("div"),n=g("div"),l=g("div"),C&&C.c(),o=w(),c=g("button"),c.innerHTML='<svg class="tocheck svelte-6zk4ms" viewBox="0 0 24 24"><path d="M12,20A8,8 0 0,1 4,12A8,8 0 0,1 12,4A8,8 0 0,1 20,12A8,8 0 0,1 12,20M12,2A10,10 0 0,0 2,12A10,10 0 0,0 12,22A10,10 0 0,0 22,12A10,10 0 0,0 12,2Z" class="svelte-6zk4ms"></path></svg>',r=w(),s=g("div"),a=v(F),u=w(),O&&O.c(),d=w(),p=g("div"),$=g("a"),nt(b.$$.fragment),k=w(),z=g("a"),nt(q.$$.fragment),j=w(),E=g("a"),nt(M.$$.fragment),x(c,"class","btnicon"),D(c,"padding-bottom","0.5em"),x(s,"class","notetitle"),x(l,"class","todo svelte-6zk4ms"),x($,"href",null),x($,"title","Выполнить"),x(z,"href",null),x(z,"title","Редактировать"),x(E,"href",null),x(E,"title","Удалить"),x(p,"class","notebtns"),x(n,"class","panel note"),D(n,"position","relative"),x(e,"class","notecard")},m(t,i){h(t,e,i),f(e,n),f(n,l),C&&C.m(l,null),f(l,o),f(l,c),f(l,r),f(l,s),f(s,a),f(n,u),O&&O.m(n,null),f(n,d),f(n,p),f(p,$),lt(b,$,null),f(p,k),f(p,z),lt(q,z,null),f(p,j),f(p,E),lt(M,E,null),A=!0,N||(T=[y(c,"click",L),y($,"click",S),y(z,"click",Y),y(E,"click",P)],N=!0)},p(e,i){(t=e)[31].repeat?C?16&i[0]&&W(C,1):(C=Fn(),C.c(),W(C,1),C.m(l,o)):C&&(R(),tt(C,1,1,(()=>{C=null})),V()),(!A||16&i[0])&&F!==(F=t[31].title+"")&&_(a,F),t[31].comment?O?O.p(t,i):(O=Cn(t),O.c(),O.m(n,d)):O&&(O.d(1),O=null)},i(t){A||(W(C),W(b.$$.fragment,t),W(q.$$.fragment,t),W(M.$$.fragment,t),A=!0)},o(t){tt(C),tt(b.$$.fragment,t),tt(q.$$.fragment,t),tt(M.$$.fragment,t),A=!1},d(t){t&&m(e),C&&C.d(),O&&O.d(),it(b),it(q),it(M),N=!1,i(T)}}}function On(t){let e,n,l,i,o,c=t[28].date+"",r=t[28].list,s=[];for(let e=0;e<r.length;e+=1)s[e]=Ln(An(t,r,e));const a=t=>tt(s[t],1,1,(()=>{s[t]=null}));return{c(){e=g("div"),n=v(c),l=w(),i=g("div");for(let t=0;t<s.length;t+=1)s[t].c();x(e,"class","day svelte-6zk4ms"),x(i,"class","notelist")},m(t,c){h(t,e,c),f(e,n),h(t,l,c),h(t,i,c);for(let t=0;t<s.length;t+=1)s[t].m(i,null);o=!0},p(t,e){if((!o||16&e[0])&&c!==(c=t[28].date+"")&&_(n,c),7184&e[0]){let n;for(r=t[28].list,n=0;n<r.length;n+=1){const l=An(t,r,n);s[n]?(s[n].p(l,e),W(s[n],1)):(s[n]=Ln(l),s[n].c(),W(s[n],1),s[n].m(i,null))}for(R(),n=r.length;n<s.length;n+=1)a(n);V()}},i(t){if(!o){for(let t=0;t<r.length;t+=1)W(s[t]);o=!0}},o(t){s=s.filter(Boolean);for(let t=0;t<s.length;t+=1)tt(s[t]);o=!1},d(t){t&&m(e),t&&m(l),t&&m(i),p(s,t)}}}function Sn(t){let e,n,l;return n=new xt({props:{type:"warning",msg:"Задачи не найдены",show:!0,noclose:!0}}),{c(){e=g("div"),nt(n.$$.fragment),D(e,"display","flex"),D(e,"justify-content","center")},m(t,i){h(t,e,i),lt(n,e,null),l=!0},i(t){l||(W(n.$$.fragment,t),l=!0)},o(t){tt(n.$$.fragment,t),l=!1},d(t){t&&m(e),it(n)}}}function Yn(t){let e,n,l,o,c,r,s,a,u,d,p,$,b,k,z,_,q,j,E,M,A,N,T,F,C,L,O,Y,P,B;function H(e){t[14](e)}let I={style:"width: 10em;",placeholder:"Поиск..."};void 0!==t[5]&&(I.value=t[5]),r=new Ot({props:I}),S.push((()=>et(r,"value",H,t[5])));let X=t[5]&&Nn(t),Z=t[4]&&Tn(t);function G(e){t[20](e)}let J={};function K(e){t[21](e)}void 0!==t[3]&&(J.showForm=t[3]),q=new gn({props:J}),S.push((()=>et(q,"showForm",G,t[3])));let Q={lng:t[7]};function ot(e){t[22](e)}void 0!==t[0]&&(Q.err=t[0]),M=new kn({props:Q}),S.push((()=>et(M,"err",K,t[0])));let ct={lng:t[7]};function rt(e){t[23](e)}void 0!==t[1]&&(ct.confirm=t[1]),T=new qn({props:ct}),S.push((()=>et(T,"confirm",ot,t[1])));let st={lng:t[7]};return void 0!==t[2]&&(st.info=t[2]),L=new En({props:st}),S.push((()=>et(L,"info",rt,t[2]))),{c(){e=g("div"),n=g("div"),l=g("button"),l.textContent="Добавить задачу",o=w(),c=g("div"),nt(r.$$.fragment),a=w(),u=g("button"),d=v("Найти"),$=w(),X&&X.c(),b=w(),k=g("div"),z=g("div"),Z&&Z.c(),_=w(),nt(q.$$.fragment),E=w(),nt(M.$$.fragment),N=w(),nt(T.$$.fragment),C=w(),nt(L.$$.fragment),x(l,"class","btn primary"),x(u,"class","btn primary"),u.disabled=p=!t[5],D(c,"display","flex"),D(c,"column-gap","0.5em"),x(n,"class","topnav svelte-6zk4ms"),D(z,"flex-grow","1"),D(z,"overflow","auto"),D(z,"flex-direction","column"),D(z,"padding","1em 2em"),x(k,"class","body svelte-6zk4ms"),x(e,"class","app svelte-6zk4ms")},m(i,s){h(i,e,s),f(e,n),f(n,l),f(n,o),f(n,c),lt(r,c,null),f(c,a),f(c,u),f(u,d),f(c,$),X&&X.m(c,null),f(e,b),f(e,k),f(k,z),Z&&Z.m(z,null),h(i,_,s),lt(q,i,s),h(i,E,s),lt(M,i,s),h(i,N,s),lt(T,i,s),h(i,C,s),lt(L,i,s),Y=!0,P||(B=[y(l,"click",t[9]),y(u,"click",t[13])],P=!0)},p(t,e){const n={};!s&&32&e[0]&&(s=!0,n.value=t[5],U((()=>s=!1))),r.$set(n),(!Y||32&e[0]&&p!==(p=!t[5]))&&(u.disabled=p),t[5]?X?X.p(t,e):(X=Nn(t),X.c(),X.m(c,null)):X&&(X.d(1),X=null),t[4]?Z?(Z.p(t,e),16&e[0]&&W(Z,1)):(Z=Tn(t),Z.c(),W(Z,1),Z.m(z,null)):Z&&(R(),tt(Z,1,1,(()=>{Z=null})),V());const l={};!j&&8&e[0]&&(j=!0,l.showForm=t[3],U((()=>j=!1))),q.$set(l);const i={};!A&&1&e[0]&&(A=!0,i.err=t[0],U((()=>A=!1))),M.$set(i);const o={};!F&&2&e[0]&&(F=!0,o.confirm=t[1],U((()=>F=!1))),T.$set(o);const a={};!O&&4&e[0]&&(O=!0,a.info=t[2],U((()=>O=!1))),L.$set(a)},i(t){Y||(W(r.$$.fragment,t),W(Z),W(q.$$.fragment,t),W(M.$$.fragment,t),W(T.$$.fragment,t),W(L.$$.fragment,t),Y=!0)},o(t){tt(r.$$.fragment,t),tt(Z),tt(q.$$.fragment,t),tt(M.$$.fragment,t),tt(T.$$.fragment,t),tt(L.$$.fragment,t),Y=!1},d(t){t&&m(e),it(r),X&&X.d(),Z&&Z.d(),t&&m(_),it(q,t),t&&m(E),it(M,t),t&&m(N),it(T,t),t&&m(C),it(L,t),P=!1,i(B)}}}function Pn(t,e,n){let l,i,o,c,r,s={close:"Закрыть",confirm:"Подтверждение",ereq:"Заполните поле: ",ereqs:"Заполните поля: ",error:"Ошибка",info:"Информация",no:"Нет",ok:"ОК",qdelete:"Вы действительно хотите удалить задачу?",yes:"Да"},a="",u={info:(t,e)=>o.show(t,e),error:t=>{String(t).includes("401")?window.location="/login.html":l.show(t)},confirm:(t,e,n)=>i.show(t,e,n)};C("ctx",{fn:u,lng:s});let d="",f=!1;function h(t){ut("tasks"+(t?"?search="+t:""),(t=>{if(a="",n(4,r=[]),n(6,f=!0),t.tasks)for(let e=0;e<t.tasks.length;e++){let n=t.tasks[e];if(a!=n.date){let t=n.date.substr(6)+"."+n.date.substr(4,2)+"."+n.date.substr(0,4);r.push({date:t,list:[n]}),a=n.date}else r[r.length-1].list.push(n)}}),(t=>{String(t).includes("404")||u.error(t)}))}function m(t,e,n){if("update"===t)h()}function p(t,e){let n=""==t?"Добавить новую задачу":"Карточка задачи";c.show({title:n,param:e&&e.param?e.param:null,id:t,callback:m})}function g(t){st("task/done?id="+t,{},(t=>{h()}),u.error)}function $(t){p(t.id)}function v(t){u.confirm(s.qdelete,(()=>{var e,n,l,i;e="task?id="+t.id,n={},l=t=>{h()},i=u.error,axios.delete("api/"+e,n).then((t=>{if(t.data.error)i(t.data.error);else{let e=l(t.data);e&&i(e)}})).catch((t=>{i(t)}))}))}F((()=>{h()}));return[l,i,o,c,r,d,f,s,h,function(){p("")},g,$,v,function(){h(d)},function(t){d=t,n(5,d)},()=>{n(5,d=""),h()},t=>{g(t.id)},t=>{g(t.id)},t=>{$(t)},(t,e)=>{v(t)},function(t){c=t,n(3,c)},function(t){l=t,n(0,l)},function(t){i=t,n(1,i)},function(t){o=t,n(2,o)}]}function Bn(t){let e,n,l,i,o,c,r,s,a,u,d,p,$,v,b,k;function z(e){t[4](e)}let _={placeholder:"Пароль"};function q(e){t[5](e)}void 0!==t[0]&&(_.value=t[0]),r=new Pt({props:_}),S.push((()=>et(r,"value",z,t[0])));let j={lng:t[2]};return void 0!==t[1]&&(j.err=t[1]),p=new kn({props:j}),S.push((()=>et(p,"err",q,t[1]))),{c(){e=g("div"),n=g("div"),l=g("div"),i=g("div"),i.innerHTML='<div style="display: flex; align-items: center; justify-content: space-between;margin-bottom: 0.5em"><h5>Введите пароль</h5></div>',o=w(),c=g("div"),nt(r.$$.fragment),a=w(),u=g("button"),u.textContent="Войти",d=w(),nt(p.$$.fragment),x(i,"class","smaller"),x(c,"style","margin: 1em 0;width; 100%"),x(u,"class","btn primary"),x(l,"class","bigger"),D(l,"width","100%"),D(l,"display","flex"),D(l,"flex-direction","column"),D(l,"align-items","stretch"),D(l,"padding-bottom","1em"),x(n,"class","card"),D(n,"width","21em"),D(n,"align-items","center"),D(e,"min-height","100vh"),D(e,"display","flex"),D(e,"align-items","center"),D(e,"justify-content","center"),D(e,"overflow","auto")},m(s,m){h(s,e,m),f(e,n),f(n,l),f(l,i),f(l,o),f(l,c),lt(r,c,null),f(l,a),f(l,u),h(s,d,m),lt(p,s,m),v=!0,b||(k=y(u,"click",t[3]),b=!0)},p(t,[e]){const n={};!s&&1&e&&(s=!0,n.value=t[0],U((()=>s=!1))),r.$set(n);const l={};!$&&2&e&&($=!0,l.err=t[1],U((()=>$=!1))),p.$set(l)},i(t){v||(W(r.$$.fragment,t),W(p.$$.fragment,t),v=!0)},o(t){tt(r.$$.fragment,t),tt(p.$$.fragment,t),v=!1},d(t){t&&m(e),it(r),t&&m(d),it(p,t),b=!1,k()}}}function Hn(t,e,n){let l,i="",o={close:"Закрыть",confirm:"Подтверждение",ereq:"Заполните поле: ",ereqs:"Заполните поля: ",error:"Ошибка",info:"Информация",no:"Нет",ok:"ОК",qdelete:"Вы действительно хотите удалить задачу?",yes:"Да"};return C("ctx",{lng:o}),[i,l,o,function(){at("signin",{password:i},(t=>{if(t.token){let e=new Date;e.setTime(e.getTime()+288e5);let n="expires="+e.toUTCString();document.cookie="token="+t.token+";"+n+";path=/",window.location="/"}}),(t=>l.show(t)))},function(t){i=t,n(0,i)},function(t){l=t,n(1,l)}]}return t.App=class extends rt{constructor(t){super(),ct(this,t,Pn,Yn,c,{},null,[-1,-1])}},t.Login=class extends rt{constructor(t){super(),ct(this,t,Hn,Bn,c,{})}},Object.defineProperty(t,"__esModule",{value:!0}),t}({});
+var app=function(t){"use strict";function e(){}function n(t){return t()}function l(){return Object.create(null)}function i(t){t.forEach(n)}function o(t){return"function"==typeof t}function c(t,e){return t!=t?e==e:t!==e||t&&"object"==typeof t||"function"==typeof t}function r(t,e,n,l){if(t){const i=s(t,e,n,l);return t[0](i)}}function s(t,e,n,l){return t[1]&&l?function(t,e){for(const n in e)t[n]=e[n];return t}(n.ctx.slice(),t[1](l(e))):n.ctx}function a(t,e,n,l){if(t[2]&&l){const i=t[2](l(n));if(void 0===e.dirty)return i;if("object"==typeof i){const t=[],n=Math.max(e.dirty.length,i.length);for(let l=0;l<n;l+=1)t[l]=e.dirty[l]|i[l];return t}return e.dirty|i}return e.dirty}function u(t,e,n,l,i,o){if(i){const c=s(e,n,l,o);t.p(c,i)}}function d(t){if(t.ctx.length>32){const e=[],n=t.ctx.length/32;for(let t=0;t<n;t++)e[t]=-1;return e}return-1}function f(t,e){t.appendChild(e)}function h(t,e,n){t.insertBefore(e,n||null)}function m(t){t.parentNode&&t.parentNode.removeChild(t)}function p(t,e){for(let n=0;n<t.length;n+=1)t[n]&&t[n].d(e)}function g(t){return document.createElement(t)}function $(t){return document.createElementNS("http://www.w3.org/2000/svg",t)}function v(t){return document.createTextNode(t)}function w(){return v(" ")}function b(){return v("")}function y(t,e,n,l){return t.addEventListener(e,n,l),()=>t.removeEventListener(e,n,l)}function x(t,e,n){null==n?t.removeAttribute(e):t.getAttribute(e)!==n&&t.setAttribute(e,n)}function k(t,e,n){t.setAttributeNS("http://www.w3.org/1999/xlink",e,n)}function z(t){return""===t?null:+t}function _(t,e){e=""+e,t.wholeText!==e&&(t.data=e)}function q(t,e){t.value=null==e?"":e}function D(t,e,n,l){null===n?t.style.removeProperty(e):t.style.setProperty(e,n,l?"important":"")}function j(t,e){for(let n=0;n<t.options.length;n+=1){const l=t.options[n];if(l.__value===e)return void(l.selected=!0)}t.selectedIndex=-1}function E(t,e,n){t.classList[n?"add":"remove"](e)}let M;function A(t){M=t}function N(){if(!M)throw new Error("Function called outside component initialization");return M}function T(t){N().$$.before_update.push(t)}function F(t){N().$$.on_mount.push(t)}function C(t,e){return N().$$.context.set(t,e),e}function L(t){return N().$$.context.get(t)}const O=[],S=[],Y=[],P=[],B=Promise.resolve();let H=!1;function I(t){Y.push(t)}function U(t){P.push(t)}const X=new Set;let Z=0;function G(){const t=M;do{for(;Z<O.length;){const t=O[Z];Z++,A(t),J(t.$$)}for(A(null),O.length=0,Z=0;S.length;)S.pop()();for(let t=0;t<Y.length;t+=1){const e=Y[t];X.has(e)||(X.add(e),e())}Y.length=0}while(O.length);for(;P.length;)P.pop()();H=!1,X.clear(),A(t)}function J(t){if(null!==t.fragment){t.update(),i(t.before_update);const e=t.dirty;t.dirty=[-1],t.fragment&&t.fragment.p(t.ctx,e),t.after_update.forEach(I)}}const K=new Set;let Q;function R(){Q={r:0,c:[],p:Q}}function V(){Q.r||i(Q.c),Q=Q.p}function W(t,e){t&&t.i&&(K.delete(t),t.i(e))}function tt(t,e,n,l){if(t&&t.o){if(K.has(t))return;K.add(t),Q.c.push((()=>{K.delete(t),l&&(n&&t.d(1),l())})),t.o(e)}else l&&l()}function et(t,e,n,l){const i=t.$$.props[e];void 0!==i&&(t.$$.bound[i]=n,void 0===l&&n(t.$$.ctx[i]))}function nt(t){t&&t.c()}function lt(t,e,l,c){const{fragment:r,after_update:s}=t.$$;r&&r.m(e,l),c||I((()=>{const e=t.$$.on_mount.map(n).filter(o);t.$$.on_destroy?t.$$.on_destroy.push(...e):i(e),t.$$.on_mount=[]})),s.forEach(I)}function it(t,e){const n=t.$$;null!==n.fragment&&(i(n.on_destroy),n.fragment&&n.fragment.d(e),n.on_destroy=n.fragment=null,n.ctx=[])}function ot(t,e){-1===t.$$.dirty[0]&&(O.push(t),H||(H=!0,B.then(G)),t.$$.dirty.fill(0)),t.$$.dirty[e/31|0]|=1<<e%31}function ct(t,n,o,c,r,s,a,u=[-1]){const d=M;A(t);const f=t.$$={fragment:null,ctx:[],props:s,update:e,not_equal:r,bound:l(),on_mount:[],on_destroy:[],on_disconnect:[],before_update:[],after_update:[],context:new Map(n.context||(d?d.$$.context:[])),callbacks:l(),dirty:u,skip_bound:!1,root:n.target||d.$$.root};a&&a(f.root);let h=!1;if(f.ctx=o?o(t,n.props||{},((e,n,...l)=>{const i=l.length?l[0]:n;return f.ctx&&r(f.ctx[e],f.ctx[e]=i)&&(!f.skip_bound&&f.bound[e]&&f.bound[e](i),h&&ot(t,e)),n})):[],f.update(),h=!0,i(f.before_update),f.fragment=!!c&&c(f.ctx),n.target){if(n.hydrate){const t=function(t){return Array.from(t.childNodes)}(n.target);f.fragment&&f.fragment.l(t),t.forEach(m)}else f.fragment&&f.fragment.c();n.intro&&W(t.$$.fragment),lt(t,n.target,n.anchor,n.customElement),G()}A(d)}class rt{$destroy(){it(this,1),this.$destroy=e}$on(t,n){if(!o(n))return e;const l=this.$$.callbacks[t]||(this.$$.callbacks[t]=[]);return l.push(n),()=>{const t=l.indexOf(n);-1!==t&&l.splice(t,1)}}$set(t){var e;this.$$set&&(e=t,0!==Object.keys(e).length)&&(this.$$.skip_bound=!0,this.$$set(t),this.$$.skip_bound=!1)}}function st(t,e,n,l){axios.post("api/"+t,e).then((t=>{if(t.data.error)l(t.data.error);else{let e=n(t.data);e&&l(e)}})).catch((t=>{l(t)}))}function at(t,e,n,l){!function(t,e,n,l){axios.post("/"+t,e).then((t=>{if(t.data.error)l(t.data.error);else{let e=n(t.data);e&&l(e)}})).catch((t=>{l(t)}))}("api/"+t,e,n,l)}function ut(t,e,n){axios.get("api/"+t).then((t=>{if(t.data.error)n(t.data.error);else{let l=e(t.data);l&&n(l)}})).catch((t=>{n(t)}))}function dt(t){const e=e=>{!t||t.parentElement.contains(e.target)||e.defaultPrevented||t.dispatchEvent(new CustomEvent("clickoutside",t))};return document.addEventListener("click",e,!0),{destroy(){document.removeEventListener("click",e,!0)}}}function ft(t){let n,l,i,o;return{c(){n=$("svg"),l=$("use"),k(l,"xlink:href",i="#"+t[0]),x(n,"style",o="width:"+t[1]+";height: "+t[1]+";fill:"+t[2]+";"+t[3])},m(t,e){h(t,n,e),f(n,l)},p(t,[e]){1&e&&i!==(i="#"+t[0])&&k(l,"xlink:href",i),14&e&&o!==(o="width:"+t[1]+";height: "+t[1]+";fill:"+t[2]+";"+t[3])&&x(n,"style",o)},i:e,o:e,d(t){t&&m(n)}}}function ht(t,e,n){let{name:l=""}=e,{size:i="1.5em"}=e,{color:o="inherit"}=e,{style:c=""}=e;return t.$$set=t=>{"name"in t&&n(0,l=t.name),"size"in t&&n(1,i=t.size),"color"in t&&n(2,o=t.color),"style"in t&&n(3,c=t.style)},[l,i,o,c]}class mt extends rt{constructor(t){super(),ct(this,t,ht,ft,c,{name:0,size:1,color:2,style:3})}}function pt(t){let e,n,l,i,c;return n=new mt({props:{name:t[1],color:t[3],size:t[2]}}),{c(){e=g("button"),nt(n.$$.fragment),x(e,"class","btnicon")},m(r,s){h(r,e,s),lt(n,e,null),l=!0,i||(c=y(e,"click",(function(){o(t[0])&&t[0].apply(this,arguments)})),i=!0)},p(e,[l]){t=e;const i={};2&l&&(i.name=t[1]),8&l&&(i.color=t[3]),4&l&&(i.size=t[2]),n.$set(i)},i(t){l||(W(n.$$.fragment,t),l=!0)},o(t){tt(n.$$.fragment,t),l=!1},d(t){t&&m(e),it(n),i=!1,c()}}}function gt(t,e,n){let{fn:l}=e,{name:i}=e,{size:o}=e,{color:c}=e;return t.$$set=t=>{"fn"in t&&n(0,l=t.fn),"name"in t&&n(1,i=t.name),"size"in t&&n(2,o=t.size),"color"in t&&n(3,c=t.color)},[l,i,o,c]}class $t extends rt{constructor(t){super(),ct(this,t,gt,pt,c,{fn:0,name:1,size:2,color:3})}}function vt(t){let e,n,l,i,o,c,r,s,a;i=new mt({props:{name:t[4],color:t[5],size:"2.3em"}});let u=!t[2]&&wt(t);return{c(){e=g("div"),n=g("div"),l=g("div"),nt(i.$$.fragment),o=g("span"),c=v(t[1]),r=w(),u&&u.c(),D(o,"padding-left","0.5em"),D(o,"padding-right","3em"),x(l,"class","hflex"),x(n,"class","hflex"),x(e,"class",s="alert "+t[3]),D(e,"max-width","40em")},m(t,s){h(t,e,s),f(e,n),f(n,l),lt(i,l,null),f(l,o),f(o,c),f(n,r),u&&u.m(n,null),a=!0},p(t,l){const o={};16&l&&(o.name=t[4]),32&l&&(o.color=t[5]),i.$set(o),(!a||2&l)&&_(c,t[1]),t[2]?u&&(R(),tt(u,1,1,(()=>{u=null})),V()):u?(u.p(t,l),4&l&&W(u,1)):(u=wt(t),u.c(),W(u,1),u.m(n,null)),(!a||8&l&&s!==(s="alert "+t[3]))&&x(e,"class",s)},i(t){a||(W(i.$$.fragment,t),W(u),a=!0)},o(t){tt(i.$$.fragment,t),tt(u),a=!1},d(t){t&&m(e),it(i),u&&u.d()}}}function wt(t){let e,n;return e=new $t({props:{fn:t[7],name:"close",size:"2em"}}),{c(){nt(e.$$.fragment)},m(t,l){lt(e,t,l),n=!0},p(t,n){const l={};1&n&&(l.fn=t[7]),e.$set(l)},i(t){n||(W(e.$$.fragment,t),n=!0)},o(t){tt(e.$$.fragment,t),n=!1},d(t){it(e,t)}}}function bt(t){let e,n,l=t[0]&&vt(t);return{c(){l&&l.c(),e=b()},m(t,i){l&&l.m(t,i),h(t,e,i),n=!0},p(t,[n]){t[0]?l?(l.p(t,n),1&n&&W(l,1)):(l=vt(t),l.c(),W(l,1),l.m(e.parentNode,e)):l&&(R(),tt(l,1,1,(()=>{l=null})),V())},i(t){n||(W(l),n=!0)},o(t){tt(l),n=!1},d(t){l&&l.d(t),t&&m(e)}}}function yt(t,e,n){let l,i,o,{type:c="success"}=e,{msg:r=""}=e,{show:s=!1}=e,{noclose:a=!1}=e;T((()=>{switch(c){case"success":n(3,l="alert-success"),n(4,i="check-circle"),n(5,o="var(--alert-success-icon)");break;case"warning":n(3,l="alert-warning"),n(4,i="exclamation-circle"),n(5,o="var(--alert-warning-icon)")}}));return t.$$set=t=>{"type"in t&&n(6,c=t.type),"msg"in t&&n(1,r=t.msg),"show"in t&&n(0,s=t.show),"noclose"in t&&n(2,a=t.noclose)},[s,r,a,l,i,o,c,()=>{n(0,s=!1)}]}class xt extends rt{constructor(t){super(),ct(this,t,yt,bt,c,{type:6,msg:1,show:0,noclose:2})}}const kt=t=>({}),zt=t=>({});function _t(t,e,n){const l=t.slice();return l[12]=e[n],l}const qt=t=>({}),Dt=t=>({});function jt(t){let e,n,l,i;return e=new mt({props:{name:t[1].name,color:t[1].color?t[1].color:"var(--contrast)",size:"2.25em"}}),{c(){nt(e.$$.fragment),n=w(),l=g("div"),D(l,"width","0.5em")},m(t,o){lt(e,t,o),h(t,n,o),h(t,l,o),i=!0},p(t,n){const l={};2&n&&(l.name=t[1].name),2&n&&(l.color=t[1].color?t[1].color:"var(--contrast)"),e.$set(l)},i(t){i||(W(e.$$.fragment,t),i=!0)},o(t){tt(e.$$.fragment,t),i=!1},d(t){it(e,t),t&&m(n),t&&m(l)}}}function Et(t){let e,n;const l=t[9].footer,i=r(l,t,t[8],zt);return{c(){e=g("div"),i&&i.c(),x(e,"class","dialog-footer")},m(t,l){h(t,e,l),i&&i.m(e,null),n=!0},p(t,e){i&&i.p&&(!n||256&e)&&u(i,l,t,t[8],n?a(l,t[8],e,kt):d(t[8]),zt)},i(t){n||(W(i,t),n=!0)},o(t){tt(i,t),n=!1},d(t){t&&m(e),i&&i.d(t)}}}function Mt(t){let n,l=t[2],i=[];for(let e=0;e<l.length;e+=1)i[e]=At(_t(t,l,e));return{c(){n=g("div");for(let t=0;t<i.length;t+=1)i[t].c();x(n,"class","dialog-footer")},m(t,e){h(t,n,e);for(let t=0;t<i.length;t+=1)i[t].m(n,null)},p(t,e){if(68&e){let o;for(l=t[2],o=0;o<l.length;o+=1){const c=_t(t,l,o);i[o]?i[o].p(c,e):(i[o]=At(c),i[o].c(),i[o].m(n,null))}for(;o<i.length;o+=1)i[o].d(1);i.length=l.length}},i:e,o:e,d(t){t&&m(n),p(i,t)}}}function At(t){let e,n,l,i,c,r,s=t[12].title+"";return{c(){e=g("button"),n=v(s),l=w(),D(e,"min-width","5em"),D(e,"margin-left","1.5em"),x(e,"class",i="btn "+t[12].class)},m(i,s){h(i,e,s),f(e,n),f(e,l),c||(r=y(e,"click",(function(){o(t[6](t[12].callback))&&t[6](t[12].callback).apply(this,arguments)})),c=!0)},p(l,o){t=l,4&o&&s!==(s=t[12].title+"")&&_(n,s),4&o&&i!==(i="btn "+t[12].class)&&x(e,"class",i)},d(t){t&&m(e),c=!1,r()}}}function Nt(t){let e,n,l,i,o,c,s,p,$,b,k,z,q,j,M,A,N,T=t[1].name&&jt(t);$=new $t({props:{fn:t[4].close,name:"close",size:"2em"}});const F=t[9].content,C=r(F,t,t[8],Dt),L=[Mt,Et],O=[];function S(t,e){return t[2]&&t[2].length>0?0:t[7].footer?1:-1}return~(q=S(t))&&(j=O[q]=L[q](t)),{c(){e=g("div"),n=g("div"),l=g("div"),i=g("div"),T&&T.c(),o=w(),c=g("h4"),s=v(t[0]),p=w(),nt($.$$.fragment),b=w(),k=g("div"),C&&C.c(),z=w(),j&&j.c(),D(i,"display","flex"),D(i,"align-items","center"),D(i,"padding-right","3em"),x(l,"class","dialog-header"),x(k,"class","dialog-content"),E(k,"bottom-radius",!(t[2]&&0!=t[2].length||t[7].footer)),x(n,"class","dialog"),x(e,"class","modal")},m(r,a){var u;h(r,e,a),f(e,n),f(n,l),f(l,i),T&&T.m(i,null),f(i,o),f(i,c),f(c,s),f(l,p),lt($,l,null),f(n,b),f(n,k),C&&C.m(k,null),f(n,z),~q&&O[q].m(n,null),t[10](e),M=!0,A||(N=y(e,"click",(u=t[11],function(t){t.target===this&&u.call(this,t)})),A=!0)},p(t,[e]){t[1].name?T?(T.p(t,e),2&e&&W(T,1)):(T=jt(t),T.c(),W(T,1),T.m(i,o)):T&&(R(),tt(T,1,1,(()=>{T=null})),V()),(!M||1&e)&&_(s,t[0]),C&&C.p&&(!M||256&e)&&u(C,F,t,t[8],M?a(F,t[8],e,qt):d(t[8]),Dt),(!M||132&e)&&E(k,"bottom-radius",!(t[2]&&0!=t[2].length||t[7].footer));let l=q;q=S(t),q===l?~q&&O[q].p(t,e):(j&&(R(),tt(O[l],1,1,(()=>{O[l]=null})),V()),~q?(j=O[q],j?j.p(t,e):(j=O[q]=L[q](t),j.c()),W(j,1),j.m(n,null)):j=null)},i(t){M||(W(T),W($.$$.fragment,t),W(C,t),W(j),M=!0)},o(t){tt(T),tt($.$$.fragment,t),tt(C,t),tt(j),M=!1},d(n){n&&m(e),T&&T.d(),it($),C&&C.d(n),~q&&O[q].d(),t[10](null),A=!1,N()}}}function Tt(t,e,n){let{$$slots:l={},$$scope:i}=e;const o=function(t){const e={};for(const n in t)e[n]=!0;return e}(l);let{header:c=""}=e,{icon:r={}}=e,{btns:s=[]}=e,{outside:a=!1}=e;const u={show(){n(5,d.style.display="flex",d)},close(){n(5,d.style.display="none",d)}};let d;return t.$$set=t=>{"header"in t&&n(0,c=t.header),"icon"in t&&n(1,r=t.icon),"btns"in t&&n(2,s=t.btns),"outside"in t&&n(3,a=t.outside),"$$scope"in t&&n(8,i=t.$$scope)},[c,r,s,a,u,d,function(t){u.close(),t&&t()},o,i,l,function(t){S[t?"unshift":"push"]((()=>{d=t,n(5,d)}))},()=>{a&&u.close()}]}class Ft extends rt{constructor(t){super(),ct(this,t,Tt,Nt,c,{header:0,icon:1,btns:2,outside:3,mod:4})}get mod(){return this.$$.ctx[4]}}function Ct(t){let n,l,c,r;return{c(){n=g("input"),x(n,"class","input"),x(n,"type","text"),x(n,"maxlength",l=t[5]>0?t[5]:null),x(n,"placeholder",t[1]),x(n,"style",t[2]),n.disabled=t[3]},m(e,l){h(e,n,l),q(n,t[0]),c||(r=[y(n,"input",t[6]),y(n,"input",(function(){o(t[4])&&t[4].apply(this,arguments)}))],c=!0)},p(e,[i]){t=e,32&i&&l!==(l=t[5]>0?t[5]:null)&&x(n,"maxlength",l),2&i&&x(n,"placeholder",t[1]),4&i&&x(n,"style",t[2]),8&i&&(n.disabled=t[3]),1&i&&n.value!==t[0]&&q(n,t[0])},i:e,o:e,d(t){t&&m(n),c=!1,i(r)}}}function Lt(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{style:o=null}=e,{disabled:c=!1}=e,{change:r}=e,{size:s=0}=e;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"style"in t&&n(2,o=t.style),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(4,r=t.change),"size"in t&&n(5,s=t.size)},[i,l,o,c,r,s,function(){i=this.value,n(0,i)}]}class Ot extends rt{constructor(t){super(),ct(this,t,Lt,Ct,c,{placeholder:1,value:0,style:2,disabled:3,change:4,size:5})}}function St(t){let n,l,o,c,r,s,a,u,d;return{c(){n=g("div"),l=g("div"),o=g("input"),c=w(),r=g("div"),s=$("svg"),a=$("use"),D(o,"width","100%"),D(o,"min-height","100%"),x(o,"class","input no-right-radius"),x(o,"type","password"),x(o,"placeholder",t[1]),D(l,"flex-grow","1"),k(a,"xlink:href",t[2]),D(s,"width","1.5em"),D(s,"height","1.5em"),D(s,"fill","inherit"),x(r,"class","input-right-btn"),D(n,"display","flex"),D(n,"align-items","stretch")},m(e,i){h(e,n,i),f(n,l),f(l,o),t[5](o),q(o,t[0]),f(n,c),f(n,r),f(r,s),f(s,a),u||(d=[y(o,"input",t[6]),y(r,"click",t[4])],u=!0)},p(t,[e]){2&e&&x(o,"placeholder",t[1]),1&e&&o.value!==t[0]&&q(o,t[0]),4&e&&k(a,"xlink:href",t[2])},i:e,o:e,d(e){e&&m(n),t[5](null),u=!1,i(d)}}}function Yt(t,e,n){let l,{placeholder:i=""}=e,{value:o=""}=e,c="#eye";return t.$$set=t=>{"placeholder"in t&&n(1,i=t.placeholder),"value"in t&&n(0,o=t.value)},[o,i,c,l,function(){"#eye"==c?(n(2,c="#eye-off"),n(3,l.type="text",l)):(n(2,c="#eye"),n(3,l.type="password",l))},function(t){S[t?"unshift":"push"]((()=>{l=t,n(3,l)}))},function(){o=this.value,n(0,o)}]}class Pt extends rt{constructor(t){super(),ct(this,t,Yt,St,c,{placeholder:1,value:0})}}function Bt(t){let n,l,c,r,s,a,u,d,p,v;return{c(){n=g("div"),l=g("div"),c=g("input"),r=w(),s=g("div"),a=$("svg"),u=$("use"),D(c,"width","100%"),D(c,"min-height","100%"),x(c,"class","input no-right-radius"),x(c,"type","text"),x(c,"placeholder",t[1]),D(l,"flex-grow","1"),k(u,"xlink:href",d="#"+t[2]),D(a,"width","1.5em"),D(a,"height","1.5em"),D(a,"fill","inherit"),x(s,"class","input-right-btn"),D(n,"display","flex"),D(n,"align-items","stretch")},m(e,i){h(e,n,i),f(n,l),f(l,c),q(c,t[0]),f(n,r),f(n,s),f(s,a),f(a,u),p||(v=[y(c,"input",t[5]),y(c,"input",(function(){o(t[4])&&t[4].apply(this,arguments)})),y(s,"click",(function(){o(t[3])&&t[3].apply(this,arguments)}))],p=!0)},p(e,[n]){t=e,2&n&&x(c,"placeholder",t[1]),1&n&&c.value!==t[0]&&q(c,t[0]),4&n&&d!==(d="#"+t[2])&&k(u,"xlink:href",d)},i:e,o:e,d(t){t&&m(n),p=!1,i(v)}}}function Ht(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{icon:o}=e,{callback:c}=e,{change:r}=e;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"icon"in t&&n(2,o=t.icon),"callback"in t&&n(3,c=t.callback),"change"in t&&n(4,r=t.change)},[i,l,o,c,r,function(){i=this.value,n(0,i)}]}class It extends rt{constructor(t){super(),ct(this,t,Ht,Bt,c,{placeholder:1,value:0,icon:2,callback:3,change:4})}}function Ut(t,e,n){const l=t.slice();return l[21]=e[n],l}function Xt(t,e,n){const l=t.slice();return l[24]=e[n],l}function Zt(t,e,n){const l=t.slice();return l[24]=e[n],l}function Gt(t){let e,n,l=t[24]+"";return{c(){e=g("th"),n=v(l),x(e,"class","svelte-xxli2i")},m(t,l){h(t,e,l),f(e,n)},p(t,e){1&e&&l!==(l=t[24]+"")&&_(n,l)},d(t){t&&m(e)}}}function Jt(t){let e,n,l,i,o,c=t[24].date+"";function r(){return t[15](t[24])}return{c(){e=g("td"),n=v(c),x(e,"class",l="datebtn "+t[24].class+" svelte-xxli2i")},m(t,l){h(t,e,l),f(e,n),i||(o=y(e,"click",r),i=!0)},p(i,o){t=i,2&o&&c!==(c=t[24].date+"")&&_(n,c),2&o&&l!==(l="datebtn "+t[24].class+" svelte-xxli2i")&&x(e,"class",l)},d(t){t&&m(e),i=!1,o()}}}function Kt(t){let e,n,l=t[21],i=[];for(let e=0;e<l.length;e+=1)i[e]=Jt(Xt(t,l,e));return{c(){e=g("tr");for(let t=0;t<i.length;t+=1)i[t].c();n=w()},m(t,l){h(t,e,l);for(let t=0;t<i.length;t+=1)i[t].m(e,null);f(e,n)},p(t,o){if(34&o){let c;for(l=t[21],c=0;c<l.length;c+=1){const r=Xt(t,l,c);i[c]?i[c].p(r,o):(i[c]=Jt(r),i[c].c(),i[c].m(e,n))}for(;c<i.length;c+=1)i[c].d(1);i.length=l.length}},d(t){t&&m(e),p(i,t)}}}function Qt(t){let e,n,l,i,o,c,r,s,a,u,d,$,b,y,k,z,q;o=new $t({props:{name:"angle-left",fn:t[13]}}),b=new $t({props:{name:"angle-right",fn:t[14]}});let j=t[0],E=[];for(let e=0;e<j.length;e+=1)E[e]=Gt(Zt(t,j,e));let M=t[1],A=[];for(let e=0;e<M.length;e+=1)A[e]=Kt(Ut(t,M,e));return{c(){e=g("div"),n=g("table"),l=g("tr"),i=g("td"),nt(o.$$.fragment),c=w(),r=g("td"),s=v(t[3]),a=w(),u=v(t[2]),d=w(),$=g("td"),nt(b.$$.fragment),y=w(),k=g("tr");for(let t=0;t<E.length;t+=1)E[t].c();z=w();for(let t=0;t<A.length;t+=1)A[t].c();D(i,"vertical-align","middle"),D(i,"line-height","0"),x(i,"class","svelte-xxli2i"),x(r,"colspan","5"),x(r,"class","svelte-xxli2i"),D($,"vertical-align","middle"),D($,"line-height","0"),x($,"class","svelte-xxli2i"),x(n,"class","datepicker svelte-xxli2i")},m(t,m){h(t,e,m),f(e,n),f(n,l),f(l,i),lt(o,i,null),f(l,c),f(l,r),f(r,s),f(r,a),f(r,u),f(l,d),f(l,$),lt(b,$,null),f(n,y),f(n,k);for(let t=0;t<E.length;t+=1)E[t].m(k,null);f(n,z);for(let t=0;t<A.length;t+=1)A[t].m(n,null);q=!0},p(t,[e]){if((!q||8&e)&&_(s,t[3]),(!q||4&e)&&_(u,t[2]),1&e){let n;for(j=t[0],n=0;n<j.length;n+=1){const l=Zt(t,j,n);E[n]?E[n].p(l,e):(E[n]=Gt(l),E[n].c(),E[n].m(k,null))}for(;n<E.length;n+=1)E[n].d(1);E.length=j.length}if(34&e){let l;for(M=t[1],l=0;l<M.length;l+=1){const i=Ut(t,M,l);A[l]?A[l].p(i,e):(A[l]=Kt(i),A[l].c(),A[l].m(n,null))}for(;l<A.length;l+=1)A[l].d(1);A.length=M.length}},i(t){q||(W(o.$$.fragment,t),W(b.$$.fragment,t),q=!0)},o(t){tt(o.$$.fragment,t),tt(b.$$.fragment,t),q=!1},d(t){t&&m(e),it(o),it(b),p(E,t),p(A,t)}}}function Rt(t){const e=t=>t<10?"0"+t:t;return e(t.getDate())+"."+e(t.getMonth()+1)+"."+t.getFullYear()}function Vt(t){const e=t=>t<10?"0"+t:t;return t.getFullYear()+"-"+e(t.getMonth()+1)+"-"+e(t.getDate())}function Wt(t,e,n){let l,i,o,c,{value:r=Rt(new Date)}=e,{days:s="пн|вт|ср|чт|пт|сб|вс".split("|")}=e,{months:a="январь|февраль|март|апрель|май|июнь|июль|август|сентябрь|октябрь|ноябрь|декабрь".split("|")}=e,{start:u=1}=e,{offset:d=0}=e,{callback:f}=e,h=Vt(new Date),m=Vt(new Date);function p(t){n(7,d+=t)}function g(t){n(11,h=t),n(6,r=function(t){let e=t.split("-");switch(e.length){case 1:return r;case 2:return e[1]+"."+e[2]}return e[2]+"."+e[1]+"."+e[0]}(t)),n(7,d=0),f&&f()}return t.$$set=t=>{"value"in t&&n(6,r=t.value),"days"in t&&n(0,s=t.days),"months"in t&&n(8,a=t.months),"start"in t&&n(9,u=t.start),"offset"in t&&n(7,d=t.offset),"callback"in t&&n(10,f=t.callback)},t.$$.update=()=>{64&t.$$.dirty&&function(t){const e=new Date(function(t){let e=t.split(".");switch(e.length){case 1:return t;case 2:return e[1]+"-"+e[0]}return e[2]+"-"+e[1]+"-"+e[0]}(t));n(7,d=0),isNaN(e)||n(11,h=Vt(e))}(r),2176&t.$$.dirty&&n(12,l=function(t,e){var n=new Date(t);return n.setDate(1),n.setMonth(n.getMonth()+e),n}(h,d)),4352&t.$$.dirty&&n(3,i=a[l.getMonth()]),4096&t.$$.dirty&&n(2,o=l.getFullYear()),6656&t.$$.dirty&&n(1,c=function(t,e,n){var l=new Date(t.getTime());l.setDate(1),l.setDate(l.getDate()+(n-l.getDay()-7)%7);var i=new Date(t.getTime());i.setDate(new Date(t.getFullYear(),t.getMonth()+1,0).getDate()),i.setDate(i.getDate()+(n-i.getDay()+6)%7);for(var o=new Date(l.getTime()),c=t.getMonth(),r=t.getFullYear(),s=[],a=[s];o.getTime()<=i.getTime();){var u=o.getDate(),d=o.getMonth(),f=o.getFullYear(),h=Vt(o);s.push({date:u,value:h,class:[e===h?"dateselected":m==h?"today":"",d==c?"":(d>c?f>=r:f>r)?"future":"past"].join(" ")}),(o=new Date(o.getFullYear(),o.getMonth(),o.getDate()+1)).getDay()===n&&(s=[],a.push(s))}return a}(l,h,u))},[s,c,o,i,p,g,r,d,a,u,f,h,l,()=>{p(-1)},()=>{p(1)},t=>g(t.value)]}class te extends rt{constructor(t){super(),ct(this,t,Wt,Qt,c,{value:6,days:0,months:8,start:9,offset:7,callback:10})}}function ee(t,e,n){const l=t.slice();return l[12]=e[n],l}function ne(t){let n,l,c,r,s,a=t[3]&&le(t),u=t[5],d=[];for(let e=0;e<u.length;e+=1)d[e]=se(ee(t,u,e));const $=t=>tt(d[t],1,1,(()=>{d[t]=null}));return{c(){n=g("div"),a&&a.c(),l=w();for(let t=0;t<d.length;t+=1)d[t].c();x(n,"class","dropdown-menu"),E(n,"right",t[1]),E(n,"bottom100",t[2]),E(n,"top100",!t[2]),D(n,"position",t[4]?"absolute":"relative"),D(n,"font-size",t[6]?t[6]:null)},m(i,u){h(i,n,u),a&&a.m(n,null),f(n,l);for(let t=0;t<d.length;t+=1)d[t].m(n,null);var m;c=!0,r||(s=[(m=dt.call(null,n),m&&o(m.destroy)?m.destroy:e),y(n,"clickoutside",t[11])],r=!0)},p(t,e){if(t[3]?a?(a.p(t,e),8&e&&W(a,1)):(a=le(t),a.c(),W(a,1),a.m(n,l)):a&&(R(),tt(a,1,1,(()=>{a=null})),V()),160&e){let l;for(u=t[5],l=0;l<u.length;l+=1){const i=ee(t,u,l);d[l]?(d[l].p(i,e),W(d[l],1)):(d[l]=se(i),d[l].c(),W(d[l],1),d[l].m(n,null))}for(R(),l=u.length;l<d.length;l+=1)$(l);V()}(!c||2&e)&&E(n,"right",t[1]),(!c||4&e)&&E(n,"bottom100",t[2]),(!c||4&e)&&E(n,"top100",!t[2]),16&e&&D(n,"position",t[4]?"absolute":"relative"),64&e&&D(n,"font-size",t[6]?t[6]:null)},i(t){if(!c){W(a);for(let t=0;t<u.length;t+=1)W(d[t]);c=!0}},o(t){tt(a),d=d.filter(Boolean);for(let t=0;t<d.length;t+=1)tt(d[t]);c=!1},d(t){t&&m(n),a&&a.d(),p(d,t),r=!1,i(s)}}}function le(t){let e,n,l;const i=t[9].default,o=r(i,t,t[8],null);let c=t[5].length>0&&ie();return{c(){o&&o.c(),e=w(),c&&c.c(),n=b()},m(t,i){o&&o.m(t,i),h(t,e,i),c&&c.m(t,i),h(t,n,i),l=!0},p(t,e){o&&o.p&&(!l||256&e)&&u(o,i,t,t[8],l?a(i,t[8],e,null):d(t[8]),null),t[5].length>0?c||(c=ie(),c.c(),c.m(n.parentNode,n)):c&&(c.d(1),c=null)},i(t){l||(W(o,t),l=!0)},o(t){tt(o,t),l=!1},d(t){o&&o.d(t),t&&m(e),c&&c.d(t),t&&m(n)}}}function ie(t){let e;return{c(){e=g("hr"),x(e,"class","dropdown-divider")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function oe(t){let e,n,l,i,o,c,r=t[12].title+"",s=t[12].icon&&re(t);function a(){return t[10](t[12])}return{c(){e=g("a"),s&&s.c(),n=g("span"),l=v(r),x(e,"href",null),x(e,"class","dropdown-item")},m(t,r){h(t,e,r),s&&s.m(e,null),f(e,n),f(n,l),i=!0,o||(c=y(e,"click",a),o=!0)},p(o,c){(t=o)[12].icon?s?(s.p(t,c),32&c&&W(s,1)):(s=re(t),s.c(),W(s,1),s.m(e,n)):s&&(R(),tt(s,1,1,(()=>{s=null})),V()),(!i||32&c)&&r!==(r=t[12].title+"")&&_(l,r)},i(t){i||(W(s),i=!0)},o(t){tt(s),i=!1},d(t){t&&m(e),s&&s.d(),o=!1,c()}}}function ce(t){let n;return{c(){n=g("hr"),x(n,"class","dropdown-divider")},m(t,e){h(t,n,e)},p:e,i:e,o:e,d(t){t&&m(n)}}}function re(t){let e,n,l;return e=new mt({props:{name:t[12].icon,size:"1.3em"}}),{c(){nt(e.$$.fragment),n=w()},m(t,i){lt(e,t,i),h(t,n,i),l=!0},p(t,n){const l={};32&n&&(l.name=t[12].icon),e.$set(l)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t),t&&m(n)}}}function se(t){let e,n,l,i;const o=[ce,oe],c=[];function r(t,e){return"hr"==t[12].title?0:1}return e=r(t),n=c[e]=o[e](t),{c(){n.c(),l=b()},m(t,n){c[e].m(t,n),h(t,l,n),i=!0},p(t,i){let s=e;e=r(t),e===s?c[e].p(t,i):(R(),tt(c[s],1,1,(()=>{c[s]=null})),V(),n=c[e],n?n.p(t,i):(n=c[e]=o[e](t),n.c()),W(n,1),n.m(l.parentNode,l))},i(t){i||(W(n),i=!0)},o(t){tt(n),i=!1},d(t){c[e].d(t),t&&m(l)}}}function ae(t){let e,n,l=t[0]&&ne(t);return{c(){l&&l.c(),e=b()},m(t,i){l&&l.m(t,i),h(t,e,i),n=!0},p(t,[n]){t[0]?l?(l.p(t,n),1&n&&W(l,1)):(l=ne(t),l.c(),W(l,1),l.m(e.parentNode,e)):l&&(R(),tt(l,1,1,(()=>{l=null})),V())},i(t){n||(W(l),n=!0)},o(t){tt(l),n=!1},d(t){l&&l.d(t),t&&m(e)}}}function ue(t,e,n){let{$$slots:l={},$$scope:i}=e,{show:o=!1}=e,{right:c=!1}=e,{bottom:r=!1}=e,{slot:s=!1}=e,{absolute:a=!1}=e,{items:u=[]}=e,{size:d=""}=e;function f(t){n(0,o=!1),t.action&&t.action(t)}return t.$$set=t=>{"show"in t&&n(0,o=t.show),"right"in t&&n(1,c=t.right),"bottom"in t&&n(2,r=t.bottom),"slot"in t&&n(3,s=t.slot),"absolute"in t&&n(4,a=t.absolute),"items"in t&&n(5,u=t.items),"size"in t&&n(6,d=t.size),"$$scope"in t&&n(8,i=t.$$scope)},[o,c,r,s,a,u,d,f,i,l,t=>f(t),()=>n(0,o=!1)]}class de extends rt{constructor(t){super(),ct(this,t,ue,ae,c,{show:0,right:1,bottom:2,slot:3,absolute:4,items:5,size:6})}}function fe(t){let e,n,l,i;function o(e){t[7](e)}let c={callback:t[6]};return void 0!==t[0]&&(c.value=t[0]),n=new te({props:c}),S.push((()=>et(n,"value",o,t[0]))),{c(){e=g("div"),nt(n.$$.fragment),D(e,"padding","0.5em 0.5em"),D(e,"font-size","1.1em")},m(t,l){h(t,e,l),lt(n,e,null),i=!0},p(t,e){const i={};12&e&&(i.callback=t[6]),!l&&1&e&&(l=!0,i.value=t[0],U((()=>l=!1))),n.$set(i)},i(t){i||(W(n.$$.fragment,t),i=!0)},o(t){tt(n.$$.fragment,t),i=!1},d(t){t&&m(e),it(n)}}}function he(t){let e,n,l,i,o,c,r;function s(e){t[5](e)}let a={placeholder:t[1],icon:"calendar-month",callback:t[4],change:t[2]};function u(e){t[8](e)}void 0!==t[0]&&(a.value=t[0]),n=new It({props:a}),S.push((()=>et(n,"value",s,t[0])));let d={absolute:!0,right:!0,slot:!0,$$slots:{default:[fe]},$$scope:{ctx:t}};return void 0!==t[3]&&(d.show=t[3]),o=new de({props:d}),S.push((()=>et(o,"show",u,t[3]))),{c(){e=g("div"),nt(n.$$.fragment),i=w(),nt(o.$$.fragment),D(e,"position","relative"),D(e,"max-width","15em")},m(t,l){h(t,e,l),lt(n,e,null),f(e,i),lt(o,e,null),r=!0},p(t,[e]){const i={};2&e&&(i.placeholder=t[1]),4&e&&(i.change=t[2]),!l&&1&e&&(l=!0,i.value=t[0],U((()=>l=!1))),n.$set(i);const r={};525&e&&(r.$$scope={dirty:e,ctx:t}),!c&&8&e&&(c=!0,r.show=t[3],U((()=>c=!1))),o.$set(r)},i(t){r||(W(n.$$.fragment,t),W(o.$$.fragment,t),r=!0)},o(t){tt(n.$$.fragment,t),tt(o.$$.fragment,t),r=!1},d(t){t&&m(e),it(n),it(o)}}}function me(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{change:o}=e,c=!1;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"change"in t&&n(2,o=t.change)},[i,l,o,c,function(){n(3,c=!c)},function(t){i=t,n(0,i)},()=>{o&&o(),n(3,c=!1)},function(t){i=t,n(0,i)},function(t){c=t,n(3,c)}]}class pe extends rt{constructor(t){super(),ct(this,t,me,he,c,{placeholder:1,value:0,change:2})}}function ge(t){let e,n,l;function i(e){t[7](e)}let o={class:"input",type:"text",placeholder:t[1],style:t[2],disabled:t[3],change:t[4]};return void 0!==t[0]&&(o.value=t[0]),e=new Ot({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};2&l&&(i.placeholder=t[1]),4&l&&(i.style=t[2]),8&l&&(i.disabled=t[3]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function $e(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{style:o=""}=e,{disabled:c=!1}=e,{change:r}=e,{float:s=0}=e;function a(t){let e="",l=["",""],o=0,c=-1;for(let t=0;t<i.length;t++){let e=i[t];0==t&&"0"==e||(e>="0"&&e<="9"?l[o]+=e:","==e||"."==e?-1==c&&(o=1,c=t):"-"==e&&0==o&&0==l[o].length&&(l[0]="-"))}l[0].length>13&&(l[0]=l[0].substring(0,13)),l[0]||(l[0]="0"),e=l[0],s>0&&(l[1].length>s?l[1]=l[1].substring(0,s):l[1]+="0".repeat(s-l[1].length),e+="."+l[1]),e!==i&&n(0,i=e),r&&!t&&r()}return T((()=>{a(!0)})),t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"style"in t&&n(2,o=t.style),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(5,r=t.change),"float"in t&&n(6,s=t.float)},[i,l,o,c,function(){a(!1)},r,s,function(t){i=t,n(0,i)}]}class ve extends rt{constructor(t){super(),ct(this,t,$e,ge,c,{placeholder:1,value:0,style:2,disabled:3,change:5,float:6})}}function we(t){let e,n,l;function i(t,e){return t[0]?xe:ye}let o=i(t),c=o(t);return{c(){e=g("div"),c.c(),x(e,"class","input no-right-radius"),D(e,"cursor","pointer"),D(e,"min-height","100%"),D(e,"width","100%")},m(i,o){h(i,e,o),c.m(e,null),n||(l=y(e,"click",t[5]),n=!0)},p(t,n){o===(o=i(t))&&c?c.p(t,n):(c.d(1),c=o(t),c&&(c.c(),c.m(e,null)))},d(t){t&&m(e),c.d(),n=!1,l()}}}function be(t){let e,n,l;return{c(){e=g("input"),D(e,"width","100%"),D(e,"min-height","100%"),x(e,"class","input no-right-radius"),x(e,"type","text"),x(e,"placeholder",t[1])},m(i,c){h(i,e,c),q(e,t[0]),n||(l=[y(e,"input",t[10]),y(e,"input",(function(){o(t[2])&&t[2].apply(this,arguments)}))],n=!0)},p(n,l){t=n,2&l&&x(e,"placeholder",t[1]),1&l&&e.value!==t[0]&&q(e,t[0])},d(t){t&&m(e),n=!1,i(l)}}}function ye(t){let n,l,i=t[4].selectitem+"";return{c(){n=v(i),l=v("...")},m(t,e){h(t,n,e),h(t,l,e)},p:e,d(t){t&&m(n),t&&m(l)}}}function xe(t){let e;return{c(){e=v(t[0])},m(t,n){h(t,e,n)},p(t,n){1&n&&_(e,t[0])},d(t){t&&m(e)}}}function ke(t){let n,l,i,o,c,r;function s(t,e){return t[3]?be:we}let a=s(t),u=a(t);return{c(){n=g("div"),l=g("div"),u.c(),i=w(),o=g("div"),o.innerHTML='<svg style="width:1.5em;height: 1.5em;fill:inherit"><use xlink:href="#list-alt"></use></svg>',D(l,"flex-grow","1"),x(o,"class","input-right-btn"),D(n,"display","flex"),D(n,"align-items","stretch")},m(e,s){h(e,n,s),f(n,l),u.m(l,null),f(n,i),f(n,o),c||(r=y(o,"click",t[5]),c=!0)},p(t,[e]){a===(a=s(t))&&u?u.p(t,e):(u.d(1),u=a(t),u&&(u.c(),u.m(l,null)))},i:e,o:e,d(t){t&&m(n),u.d(),c=!1,r()}}}function ze(t,e,n){let{placeholder:l=""}=e,{value:i=""}=e,{callback:o}=e,{change:c}=e,{table:r}=e,{title:s}=e,{editable:a}=e,{fromid:u=""}=e,d=L("ctx"),f=d.lng;return t.$$set=t=>{"placeholder"in t&&n(1,l=t.placeholder),"value"in t&&n(0,i=t.value),"callback"in t&&n(6,o=t.callback),"change"in t&&n(2,c=t.change),"table"in t&&n(7,r=t.table),"title"in t&&n(8,s=t.title),"editable"in t&&n(3,a=t.editable),"fromid"in t&&n(9,u=t.fromid)},[i,l,c,a,f,function(){d.fn.selectInTable({table:r,fromid:u,title:s||f.selectitem,callback:(t,e)=>{"select"==t&&o(t,e)}})},o,r,s,u,function(){i=this.value,n(0,i)}]}class _e extends rt{constructor(t){super(),ct(this,t,ze,ke,c,{placeholder:1,value:0,callback:6,change:2,table:7,title:8,editable:3,fromid:9})}}function qe(t){let e,n,l=t[10]&&De();return{c(){e=g("div"),n=v(t[1]),l&&l.c(),x(e,"class","form-label")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){2&i&&_(n,t[1]),t[10]?l||(l=De(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function De(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function je(t){let e,n,l;function i(e){t[16](e)}let o={placeholder:t[2],disabled:t[3],change:t[4],size:t[9]};return void 0!==t[0]&&(o.value=t[0]),e=new Ot({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),8&l&&(i.disabled=t[3]),16&l&&(i.change=t[4]),512&l&&(i.size=t[9]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Ee(t){let e,n,l;function i(e){t[15](e)}let o={placeholder:t[2],change:t[4],table:t[6],callback:t[11],fromid:t[9],editable:t[7]};return void 0!==t[0]&&(o.value=t[0]),e=new _e({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),16&l&&(i.change=t[4]),64&l&&(i.table=t[6]),2048&l&&(i.callback=t[11]),512&l&&(i.fromid=t[9]),128&l&&(i.editable=t[7]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Me(t){let e,n,l;function i(e){t[14](e)}let o={placeholder:t[2],float:t[9],change:t[4]};return void 0!==t[0]&&(o.value=t[0]),e=new ve({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),512&l&&(i.float=t[9]),16&l&&(i.change=t[4]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Ae(t){let e,n,l;function i(e){t[13](e)}let o={placeholder:t[2],change:t[4]};return void 0!==t[0]&&(o.value=t[0]),e=new pe({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),16&l&&(i.change=t[4]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Ne(t){let e,n,l;function i(e){t[12](e)}let o={placeholder:t[2]};return void 0!==t[0]&&(o.value=t[0]),e=new Pt({props:o}),S.push((()=>et(e,"value",i,t[0]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};4&l&&(i.placeholder=t[2]),!n&&1&l&&(n=!0,i.value=t[0],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function Te(t){let e,n,l,i,o,c,r=t[1]&&qe(t);const s=[Ne,Ae,Me,Ee,je],a=[];function u(t,e){return"password"==t[5]?0:"date"==t[5]?1:"money"==t[5]?2:"link"==t[5]?3:4}return l=u(t),i=a[l]=s[l](t),{c(){e=g("div"),r&&r.c(),n=w(),i.c(),x(e,"class","form-input"),x(e,"style",o=t[8]>0?"max-width:"+t[8]+"em":"")},m(t,i){h(t,e,i),r&&r.m(e,null),f(e,n),a[l].m(e,null),c=!0},p(t,[d]){t[1]?r?r.p(t,d):(r=qe(t),r.c(),r.m(e,n)):r&&(r.d(1),r=null);let f=l;l=u(t),l===f?a[l].p(t,d):(R(),tt(a[f],1,1,(()=>{a[f]=null})),V(),i=a[l],i?i.p(t,d):(i=a[l]=s[l](t),i.c()),W(i,1),i.m(e,null)),(!c||256&d&&o!==(o=t[8]>0?"max-width:"+t[8]+"em":""))&&x(e,"style",o)},i(t){c||(W(i),c=!0)},o(t){tt(i),c=!1},d(t){t&&m(e),r&&r.d(),a[l].d()}}}function Fe(t,e,n){let{title:l}=e,{value:i=""}=e,{placeholder:o=""}=e,{disabled:c=!1}=e,{change:r}=e,{type:s}=e,{link:a}=e,{linkedit:u}=e,{width:d=0}=e,{size:f=0}=e,{req:h=!1}=e,{callback:m}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"placeholder"in t&&n(2,o=t.placeholder),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(4,r=t.change),"type"in t&&n(5,s=t.type),"link"in t&&n(6,a=t.link),"linkedit"in t&&n(7,u=t.linkedit),"width"in t&&n(8,d=t.width),"size"in t&&n(9,f=t.size),"req"in t&&n(10,h=t.req),"callback"in t&&n(11,m=t.callback)},[i,l,o,c,r,s,a,u,d,f,h,m,function(t){i=t,n(0,i)},function(t){i=t,n(0,i)},function(t){i=t,n(0,i)},function(t){i=t,n(0,i)},function(t){i=t,n(0,i)}]}class Ce extends rt{constructor(t){super(),ct(this,t,Fe,Te,c,{title:1,value:0,placeholder:2,disabled:3,change:4,type:5,link:6,linkedit:7,width:8,size:9,req:10,callback:11})}}function Le(t){let e,n,l=t[3]&&Oe();return{c(){e=g("div"),n=v(t[1]),l&&l.c(),x(e,"class","form-label")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){2&i&&_(n,t[1]),t[3]?l||(l=Oe(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function Oe(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function Se(t){let n,l,c,r,s,a=t[1]&&Le(t);return{c(){n=g("div"),a&&a.c(),l=w(),c=g("textarea"),x(c,"rows",t[2]),x(c,"contenteditable",""),x(c,"class","input"),x(c,"placeholder",t[4]),D(c,"width","100%"),x(n,"class","form-input"),D(n,"width","100%")},m(e,i){h(e,n,i),a&&a.m(n,null),f(n,l),f(n,c),q(c,t[0]),r||(s=[y(c,"input",t[6]),y(c,"input",(function(){o(t[5])&&t[5].apply(this,arguments)}))],r=!0)},p(e,[i]){(t=e)[1]?a?a.p(t,i):(a=Le(t),a.c(),a.m(n,l)):a&&(a.d(1),a=null),4&i&&x(c,"rows",t[2]),16&i&&x(c,"placeholder",t[4]),1&i&&q(c,t[0])},i:e,o:e,d(t){t&&m(n),a&&a.d(),r=!1,i(s)}}}function Ye(t,e,n){let{title:l}=e,{value:i=""}=e,{size:o=5}=e,{req:c=!1}=e,{placeholder:r=""}=e,{change:s}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"size"in t&&n(2,o=t.size),"req"in t&&n(3,c=t.req),"placeholder"in t&&n(4,r=t.placeholder),"change"in t&&n(5,s=t.change)},[i,l,o,c,r,s,function(){i=this.value,n(0,i)}]}class Pe extends rt{constructor(t){super(),ct(this,t,Ye,Se,c,{title:1,value:0,size:2,req:3,placeholder:4,change:5})}}function Be(t,e,n){const l=t.slice();return l[7]=e[n],l}function He(t){let e,n,l=t[5]&&Ie();return{c(){e=g("div"),n=v(t[3]),l&&l.c(),x(e,"class","form-label")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){8&i&&_(n,t[3]),t[5]?l||(l=Ie(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function Ie(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function Ue(t){let e,n,l,i=t[7].title+"";return{c(){e=g("option"),n=v(i),e.__value=l=t[7].id,e.value=e.__value},m(t,l){h(t,e,l),f(e,n)},p(t,o){2&o&&i!==(i=t[7].title+"")&&_(n,i),2&o&&l!==(l=t[7].id)&&(e.__value=l,e.value=e.__value)},d(t){t&&m(e)}}}function Xe(t){let n,l,c,r,s,a,u=t[3]&&He(t),d=t[1],$=[];for(let e=0;e<d.length;e+=1)$[e]=Ue(Be(t,d,e));return{c(){n=g("div"),u&&u.c(),l=w(),c=g("select");for(let t=0;t<$.length;t+=1)$[t].c();x(c,"class","form-select"),D(c,"font-size","1em"),void 0===t[0]&&I((()=>t[6].call(c))),x(n,"class","form-input"),x(n,"style",r=t[2]>0?"max-width:"+t[2]+"em":"")},m(e,i){h(e,n,i),u&&u.m(n,null),f(n,l),f(n,c);for(let t=0;t<$.length;t+=1)$[t].m(c,null);j(c,t[0]),s||(a=[y(c,"change",t[6]),y(c,"change",(function(){o(t[4])&&t[4].apply(this,arguments)}))],s=!0)},p(e,[i]){if((t=e)[3]?u?u.p(t,i):(u=He(t),u.c(),u.m(n,l)):u&&(u.d(1),u=null),2&i){let e;for(d=t[1],e=0;e<d.length;e+=1){const n=Be(t,d,e);$[e]?$[e].p(n,i):($[e]=Ue(n),$[e].c(),$[e].m(c,null))}for(;e<$.length;e+=1)$[e].d(1);$.length=d.length}3&i&&j(c,t[0]),4&i&&r!==(r=t[2]>0?"max-width:"+t[2]+"em":"")&&x(n,"style",r)},i:e,o:e,d(t){t&&m(n),u&&u.d(),p($,t),s=!1,i(a)}}}function Ze(t,e,n){let{value:l=0}=e,{list:i=[]}=e,{width:o=0}=e,{title:c=""}=e,{change:r}=e,{req:s=!1}=e;return F((()=>{if("string"==typeof l)for(let t=0;t<i.length;t++){if(l==i[t].id){n(0,l=i[t].id);break}if(l==i[t].title){n(0,l=i[t].id);break}}})),t.$$set=t=>{"value"in t&&n(0,l=t.value),"list"in t&&n(1,i=t.list),"width"in t&&n(2,o=t.width),"title"in t&&n(3,c=t.title),"change"in t&&n(4,r=t.change),"req"in t&&n(5,s=t.req)},[l,i,o,c,r,s,function(){l=function(t){const e=t.querySelector(":checked")||t.options[0];return e&&e.__value}(this),n(0,l),n(1,i)}]}class Ge extends rt{constructor(t){super(),ct(this,t,Ze,Xe,c,{value:0,list:1,width:2,title:3,change:4,req:5})}}function Je(t){let e,n,l=t[7]&&Ke();return{c(){e=g("div"),n=v(t[1]),l&&l.c(),x(e,"class","form-label"),D(e,"margin","0"),D(e,"padding-right","1em")},m(t,i){h(t,e,i),f(e,n),l&&l.m(e,null)},p(t,i){2&i&&_(n,t[1]),t[7]?l||(l=Ke(),l.c(),l.m(e,null)):l&&(l.d(1),l=null)},d(t){t&&m(e),l&&l.d()}}}function Ke(t){let e;return{c(){e=g("span"),e.textContent="*",x(e,"class","star")},m(t,n){h(t,e,n)},d(t){t&&m(e)}}}function Qe(t){let n,l,c,r,s,a,u,d,p=t[1]&&Je(t);return{c(){n=g("div"),l=g("div"),p&&p.c(),c=w(),r=g("input"),x(r,"class","input"),x(r,"type","number"),x(r,"placeholder",t[2]),r.disabled=t[3],x(r,"style",s=t[6]>0?"max-width:"+t[6]+"em":""),D(l,"display","flex"),D(l,"justify-content","start"),D(l,"align-items","center"),x(n,"class","form-input"),x(n,"style",a=t[5]>0?"max-width:"+t[5]+"em":"")},m(e,i){h(e,n,i),f(n,l),p&&p.m(l,null),f(l,c),f(l,r),q(r,t[0]),u||(d=[y(r,"input",t[8]),y(r,"input",(function(){o(t[4])&&t[4].apply(this,arguments)}))],u=!0)},p(e,[i]){(t=e)[1]?p?p.p(t,i):(p=Je(t),p.c(),p.m(l,c)):p&&(p.d(1),p=null),4&i&&x(r,"placeholder",t[2]),8&i&&(r.disabled=t[3]),64&i&&s!==(s=t[6]>0?"max-width:"+t[6]+"em":"")&&x(r,"style",s),1&i&&z(r.value)!==t[0]&&q(r,t[0]),32&i&&a!==(a=t[5]>0?"max-width:"+t[5]+"em":"")&&x(n,"style",a)},i:e,o:e,d(t){t&&m(n),p&&p.d(),u=!1,i(d)}}}function Re(t,e,n){let{title:l}=e,{value:i=""}=e,{placeholder:o=""}=e,{disabled:c=!1}=e,{change:r}=e,{width:s=0}=e,{size:a=0}=e,{req:u=!1}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"placeholder"in t&&n(2,o=t.placeholder),"disabled"in t&&n(3,c=t.disabled),"change"in t&&n(4,r=t.change),"width"in t&&n(5,s=t.width),"size"in t&&n(6,a=t.size),"req"in t&&n(7,u=t.req)},[i,l,o,c,r,s,a,u,function(){i=z(this.value),n(0,i)}]}class Ve extends rt{constructor(t){super(),ct(this,t,Re,Qe,c,{title:1,value:0,placeholder:2,disabled:3,change:4,width:5,size:6,req:7})}}function We(t,e,n){const l=t.slice();return l[8]=e[n],l[9]=e,l[10]=n,l}function tn(t){let e,n,l,c,r,s=t[8].title+"";function a(){t[5].call(n,t[8])}return{c(){e=g("label"),n=g("input"),l=v(s),x(n,"class","form-check-input"),x(n,"type","checkbox"),x(e,"class","form-label"),D(e,"margin-right","1em")},m(i,s){h(i,e,s),f(e,n),n.checked=t[0][t[8].id],f(e,l),c||(r=[y(n,"change",a),y(n,"input",(function(){o(t[3])&&t[3].apply(this,arguments)}))],c=!0)},p(e,i){t=e,17&i&&(n.checked=t[0][t[8].id]),16&i&&s!==(s=t[8].title+"")&&_(l,s)},d(t){t&&m(e),c=!1,i(r)}}}function en(t){let n,l,i,o,c,r,s=t[4],a=[];for(let e=0;e<s.length;e+=1)a[e]=tn(We(t,s,e));return{c(){n=g("div"),l=g("div"),i=v(t[1]),o=w(),c=g("div");for(let t=0;t<a.length;t+=1)a[t].c();x(l,"class","form-label"),D(c,"display","flex"),D(c,"flex-wrap","wrap"),x(n,"class","form-input"),x(n,"style",r=t[2]>0?"max-width:"+t[2]+"em":"")},m(t,e){h(t,n,e),f(n,l),f(l,i),f(n,o),f(n,c);for(let t=0;t<a.length;t+=1)a[t].m(c,null)},p(t,[e]){if(2&e&&_(i,t[1]),25&e){let n;for(s=t[4],n=0;n<s.length;n+=1){const l=We(t,s,n);a[n]?a[n].p(l,e):(a[n]=tn(l),a[n].c(),a[n].m(c,null))}for(;n<a.length;n+=1)a[n].d(1);a.length=s.length}4&e&&r!==(r=t[2]>0?"max-width:"+t[2]+"em":"")&&x(n,"style",r)},i:e,o:e,d(t){t&&m(n),p(a,t)}}}function nn(t,e,n){let{title:l}=e,{values:i={}}=e,{width:o=0}=e,{change:c}=e,{list:r=[]}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"values"in t&&n(0,i=t.values),"width"in t&&n(2,o=t.width),"change"in t&&n(3,c=t.change),"list"in t&&n(4,r=t.list)},[i,l,o,c,r,function(t){i[t.id]=this.checked,n(0,i)}]}class ln extends rt{constructor(t){super(),ct(this,t,nn,en,c,{title:1,values:0,width:2,change:3,list:4})}}function on(t){let n,l,c,r,s,a,u;return{c(){n=g("div"),l=g("label"),c=g("input"),r=v(t[1]),x(c,"class","form-check-input"),x(c,"type","checkbox"),x(l,"class","form-label"),x(n,"class","form-input"),x(n,"style",s=t[2]>0?"max-width:"+t[2]+"em":"")},m(e,i){h(e,n,i),f(n,l),f(l,c),c.checked=t[0],f(l,r),a||(u=[y(c,"change",t[4]),y(c,"input",(function(){o(t[3])&&t[3].apply(this,arguments)}))],a=!0)},p(e,[l]){t=e,1&l&&(c.checked=t[0]),2&l&&_(r,t[1]),4&l&&s!==(s=t[2]>0?"max-width:"+t[2]+"em":"")&&x(n,"style",s)},i:e,o:e,d(t){t&&m(n),a=!1,i(u)}}}function cn(t,e,n){let{title:l}=e,{value:i=""}=e,{width:o=0}=e,{change:c}=e;return t.$$set=t=>{"title"in t&&n(1,l=t.title),"value"in t&&n(0,i=t.value),"width"in t&&n(2,o=t.width),"change"in t&&n(3,c=t.change)},[i,l,o,c,function(){i=this.checked,n(0,i)}]}class rn extends rt{constructor(t){super(),ct(this,t,cn,on,c,{title:1,value:0,width:2,change:3})}}function sn(t){let e,n,l,i,o,c,r,s,a,u,d,f;function p(e){t[30](e)}let g={type:"text",title:"Дни месяца (через запятую)",width:"20",req:!0,change:t[29]};function $(e){t[32](e)}void 0!==t[6]&&(g.value=t[6]),e=new Ce({props:g}),S.push((()=>et(e,"value",p,t[6])));let v={title:"Предпоследний день месяца",width:"20",change:t[31]};function b(e){t[34](e)}void 0!==t[9]&&(v.value=t[9]),i=new rn({props:v}),S.push((()=>et(i,"value",$,t[9])));let y={title:"Последний день месяца",width:"20",change:t[33]};function x(e){t[36](e)}void 0!==t[10]&&(y.value=t[10]),r=new rn({props:y}),S.push((()=>et(r,"value",b,t[10])));let k={width:"25",title:"Месяцы",list:t[13],change:t[35]};return void 0!==t[7]&&(k.values=t[7]),u=new ln({props:k}),S.push((()=>et(u,"values",x,t[7]))),{c(){nt(e.$$.fragment),l=w(),nt(i.$$.fragment),c=w(),nt(r.$$.fragment),a=w(),nt(u.$$.fragment)},m(t,n){lt(e,t,n),h(t,l,n),lt(i,t,n),h(t,c,n),lt(r,t,n),h(t,a,n),lt(u,t,n),f=!0},p(t,l){const c={};!n&&64&l[0]&&(n=!0,c.value=t[6],U((()=>n=!1))),e.$set(c);const a={};!o&&512&l[0]&&(o=!0,a.value=t[9],U((()=>o=!1))),i.$set(a);const f={};!s&&1024&l[0]&&(s=!0,f.value=t[10],U((()=>s=!1))),r.$set(f);const h={};!d&&128&l[0]&&(d=!0,h.values=t[7],U((()=>d=!1))),u.$set(h)},i(t){f||(W(e.$$.fragment,t),W(i.$$.fragment,t),W(r.$$.fragment,t),W(u.$$.fragment,t),f=!0)},o(t){tt(e.$$.fragment,t),tt(i.$$.fragment,t),tt(r.$$.fragment,t),tt(u.$$.fragment,t),f=!1},d(t){it(e,t),t&&m(l),it(i,t),t&&m(c),it(r,t),t&&m(a),it(u,t)}}}function an(t){let e,n,l;function i(e){t[28](e)}let o={width:"25",title:"Дни недели",list:t[12],change:t[27]};return void 0!==t[8]&&(o.values=t[8]),e=new ln({props:o}),S.push((()=>et(e,"values",i,t[8]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};!n&&256&l[0]&&(n=!0,i.values=t[8],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function un(t){let e,n,l;function i(e){t[26](e)}let o={title:"Каждые X дней",change:t[25],size:"5",width:"20"};return void 0!==t[5]&&(o.value=t[5]),e=new Ve({props:o}),S.push((()=>et(e,"value",i,t[5]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};!n&&32&l[0]&&(n=!0,i.value=t[5],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function dn(t){let e,n,l,i,o,c,r,s,a,u,d,p,$,v,b,y,k,z;function _(e){t[18](e)}let q={type:"date",title:"Дата",width:"13",req:!0,change:t[17]};function j(e){t[20](e)}void 0!==t[3].date&&(q.value=t[3].date),l=new Ce({props:q}),S.push((()=>et(l,"value",_,t[3].date)));let E={type:"text",title:"Задача",width:"20",req:!0,change:t[19]};function M(e){t[22](e)}void 0!==t[3].title&&(E.value=t[3].title),c=new Ce({props:E}),S.push((()=>et(c,"value",j,t[3].title)));let A={title:"Комментарий",size:"3",change:t[21]};function N(e){t[24](e)}void 0!==t[3].comment&&(A.value=t[3].comment),a=new Pe({props:A}),S.push((()=>et(a,"value",M,t[3].comment)));let T={title:"Правило повторения",width:"20",change:t[23],list:t[11]};void 0!==t[4]&&(T.value=t[4]),$=new Ge({props:T}),S.push((()=>et($,"value",N,t[4])));const F=[un,an,sn],C=[];function L(t,e){return 1==t[4]?0:2==t[4]?1:3==t[4]?2:-1}return~(y=L(t))&&(k=C[y]=F[y](t)),{c(){e=g("div"),n=g("div"),nt(l.$$.fragment),o=w(),nt(c.$$.fragment),s=w(),nt(a.$$.fragment),d=w(),p=g("div"),nt($.$$.fragment),b=w(),k&&k.c(),D(n,"padding-right","1em"),D(p,"padding-left","1em"),x(e,"slot","content"),D(e,"padding-bottom","1em"),D(e,"min-width","20em"),D(e,"display","flex ")},m(t,i){h(t,e,i),f(e,n),lt(l,n,null),f(n,o),lt(c,n,null),f(n,s),lt(a,n,null),f(e,d),f(e,p),lt($,p,null),f(p,b),~y&&C[y].m(p,null),z=!0},p(t,e){const n={};!i&&8&e[0]&&(i=!0,n.value=t[3].date,U((()=>i=!1))),l.$set(n);const o={};!r&&8&e[0]&&(r=!0,o.value=t[3].title,U((()=>r=!1))),c.$set(o);const s={};!u&&8&e[0]&&(u=!0,s.value=t[3].comment,U((()=>u=!1))),a.$set(s);const d={};!v&&16&e[0]&&(v=!0,d.value=t[4],U((()=>v=!1))),$.$set(d);let f=y;y=L(t),y===f?~y&&C[y].p(t,e):(k&&(R(),tt(C[f],1,1,(()=>{C[f]=null})),V()),~y?(k=C[y],k?k.p(t,e):(k=C[y]=F[y](t),k.c()),W(k,1),k.m(p,null)):k=null)},i(t){z||(W(l.$$.fragment,t),W(c.$$.fragment,t),W(a.$$.fragment,t),W($.$$.fragment,t),W(k),z=!0)},o(t){tt(l.$$.fragment,t),tt(c.$$.fragment,t),tt(a.$$.fragment,t),tt($.$$.fragment,t),tt(k),z=!1},d(t){t&&m(e),it(l),it(c),it(a),it($),~y&&C[y].d()}}}function fn(t){let n,l,i;return{c(){n=g("button"),n.textContent="Сохранить",x(n,"class","btn primary"),D(n,"font-size","0.9em")},m(e,o){h(e,n,o),l||(i=y(n,"click",t[14]),l=!0)},p:e,d(t){t&&m(n),l=!1,i()}}}function hn(t){let e,n,l,i,c,r,s=t[2]?"Отменить":"Закрыть",a=t[2]&&fn(t);return{c(){e=g("div"),a&&a.c(),n=w(),l=g("button"),i=v(s),x(l,"class","btn secondary"),D(l,"font-size","0.9em"),x(e,"slot","footer"),D(e,"flex-grow","1"),D(e,"display","flex"),D(e,"column-gap","0.5em"),D(e,"justify-content","end")},m(s,u){h(s,e,u),a&&a.m(e,null),f(e,n),f(e,l),f(l,i),c||(r=y(l,"click",(function(){o(t[1].close)&&t[1].close.apply(this,arguments)})),c=!0)},p(l,o){(t=l)[2]?a?a.p(t,o):(a=fn(t),a.c(),a.m(e,n)):a&&(a.d(1),a=null),4&o[0]&&s!==(s=t[2]?"Отменить":"Закрыть")&&_(i,s)},d(t){t&&m(e),a&&a.d(),c=!1,r()}}}function mn(t){let e,n,l;function i(e){t[37](e)}let o={header:t[0].title,icon:t[0].icon,$$slots:{footer:[hn],content:[dn]},$$scope:{ctx:t}};return void 0!==t[1]&&(o.mod=t[1]),e=new Ft({props:o}),S.push((()=>et(e,"mod",i,t[1]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,l){const i={};1&l[0]&&(i.header=t[0].title),1&l[0]&&(i.icon=t[0].icon),2046&l[0]|32768&l[1]&&(i.$$scope={dirty:l,ctx:t}),!n&&2&l[0]&&(n=!0,i.mod=t[1],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function pn(t,e,n){const l={show(t){n(2,r=null),n(0,c=t),c.icon||n(0,c.icon={},c),c.param||n(0,c.param={},c),n(3,u={}),o={},""!=c.id?ut("task?id="+c.id,(t=>{n(3,u={...t});let e=u.date;n(3,u.date=e.substr(6)+"."+e.substr(4,2)+"."+e.substr(0,4),u),function(t){for(let t=0;t<h.length;t++)n(7,$[h[t].id]=!0,$);for(let t=0;t<f.length;t++)n(8,v[f[t].id]=!1,v);if(!t)return void n(4,m=0);let e=t.split(" ");switch(t[0]){case"d":n(4,m=1),e.length>1&&n(5,p=e[1]);break;case"w":if(n(4,m=2),e.length>1){let t=e[1].split(",");for(let e=0;e<t.length;e++){let l=t[e];l>0&&l<8&&n(8,v[l]=!0,v)}}break;case"m":if(n(4,m=3),e.length>1){let t=e[1].split(","),l=[];for(let e=0;e<t.length;e++){let i=t[e];i>0&&i<32?l.push(i):-1==i?n(10,b=!0):-2==i&&n(9,w=!0)}n(6,g=l.join(","))}if(e.length>2){for(let t=0;t<h.length;t++)n(7,$[h[t].id]=!1,$);let t=e[2].split(",");for(let e=0;e<t.length;e++){let l=t[e];l>0&&l<13&&n(7,$[l]=!0,$)}}break;case"y":n(4,m=4);break;default:n(4,m=0)}}(u.repeat)}),s.fn.error):n(3,u={date:"",title:""}),i.show()}};let i,o={},c={},r=null;const s=L("ctx");let a=s.lng,u={},d={date:"Дата",title:"Задача"},f=[{id:1,title:"пн"},{id:2,title:"вт"},{id:3,title:"ср"},{id:4,title:"чт"},{id:5,title:"пт"},{id:6,title:"сб"},{id:7,title:"вс"}],h=[{id:1,title:"январь"},{id:2,title:"февраль"},{id:3,title:"март"},{id:4,title:"апрель"},{id:5,title:"май"},{id:6,title:"июнь"},{id:7,title:"июль"},{id:8,title:"август"},{id:9,title:"сентябрь"},{id:10,title:"октябрь"},{id:11,title:"ноябрь"},{id:12,title:"декабрь"}],m=0,p=1,g="",$={},v={},w=!1,b=!1;function y(t,e){n(2,r=null),c.callback("update",t,e),i.close()}function x(t){r||n(2,r={}),n(2,r[t]=!0,r)}return[c,i,r,u,m,p,g,$,v,w,b,[{id:0,title:"Не повторять"},{id:1,title:"Повторять с интервалом"},{id:2,title:"Еженедельно"},{id:3,title:"Ежемесячно"},{id:4,title:"Ежегодно"}],f,h,function(){let t={};t=(c.id,{...u}),Object.keys(o).forEach((function(e){t[e]=o[e]}));let e=[];for(const n in t)t.hasOwnProperty(n)&&!t[n]&&d[n]&&e.push(d[n]);if(e.length>0)return void s.fn.error((1==e.length?a.ereq:a.ereqs)+e.join(", "));t.repeat=function(){let t="";switch(m){case 1:p<=0&&n(5,p=1),t+="d "+p;break;case 2:t+="w";let e=[];Object.keys(v).forEach((function(t){v[t]&&e.push(t)})),e.length>0&&(t+=" "+e.join(","));break;case 3:t+="m ";let l=[];if(g){let t=g.split(",");for(let e=0;e<t.length;e++){let n=t[e].trim();n>=1&&n<=31&&l.push(n)}}b&&l.push("-1"),w&&l.push("-2"),0==l.length?t+="x":t+=l.join(",");let i=[];Object.keys($).forEach((function(t){$[t]&&i.push(t)})),i.length>0&&12!=i.length&&(t+=" "+i.join(","));break;case 4:t+="y"}return t}();let l=t.date;t.date=l.substr(6)+l.substr(3,2)+l.substr(0,2);let i=t;t.id?function(t,e,n,l){axios.put("api/"+t,e).then((t=>{if(t.data.error)l(t.data.error);else{let e=n(t.data);e&&l(e)}})).catch((t=>{l(t)}))}("task",i,(t=>{y(t,i)}),s.fn.error):st("task",i,(t=>{y(t,i)}),s.fn.error)},x,l,()=>{x("date")},function(e){t.$$.not_equal(u.date,e)&&(u.date=e,n(3,u))},()=>{x("title")},function(e){t.$$.not_equal(u.title,e)&&(u.title=e,n(3,u))},()=>{x("comment")},function(e){t.$$.not_equal(u.comment,e)&&(u.comment=e,n(3,u))},()=>{x("repeat")},function(t){m=t,n(4,m)},()=>{x("repeat")},function(t){p=t,n(5,p)},()=>{x("repeat")},function(t){v=t,n(8,v)},()=>{x("repeat")},function(t){g=t,n(6,g)},()=>{x("repeat")},function(t){w=t,n(9,w)},()=>{x("repeat")},function(t){b=t,n(10,b)},()=>{x("month")},function(t){$=t,n(7,$)},function(t){i=t,n(1,i)}]}class gn extends rt{constructor(t){super(),ct(this,t,pn,mn,c,{showForm:16},null,[-1,-1])}get showForm(){return this.$$.ctx[16]}}function $n(t){let e,n,l;return{c(){e=g("div"),n=g("p"),l=v(t[3]),x(e,"slot","content"),D(e,"padding","1em 0em")},m(t,i){h(t,e,i),f(e,n),f(n,l)},p(t,e){8&e&&_(l,t[3])},d(t){t&&m(e)}}}function vn(t){let e,n,l;function i(e){t[6](e)}let o={header:t[0],icon:t[1],btns:t[2],$$slots:{content:[$n]},$$scope:{ctx:t}};return void 0!==t[4]&&(o.mod=t[4]),e=new Ft({props:o}),S.push((()=>et(e,"mod",i,t[4]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0]),2&l&&(i.icon=t[1]),4&l&&(i.btns=t[2]),136&l&&(i.$$scope={dirty:l,ctx:t}),!n&&16&l&&(n=!0,i.mod=t[4],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function wn(t,e,n){let{header:l=""}=e,{icon:i={}}=e,{btns:o=[]}=e;const c={show(t){n(3,r=t),s.show()}};let r,s;return t.$$set=t=>{"header"in t&&n(0,l=t.header),"icon"in t&&n(1,i=t.icon),"btns"in t&&n(2,o=t.btns)},[l,i,o,r,s,c,function(t){s=t,n(4,s)}]}class bn extends rt{constructor(t){super(),ct(this,t,wn,vn,c,{header:0,icon:1,btns:2,msg:5})}get msg(){return this.$$.ctx[5]}}function yn(t){let e,n,l;function i(e){t[3](e)}let o={header:t[0].error,icon:{name:"close-circle",color:"var(--red)"},btns:[{title:t[0].close,class:"secondary"}]};return void 0!==t[1]&&(o.msg=t[1]),e=new bn({props:o}),S.push((()=>et(e,"msg",i,t[1]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0].error),1&l&&(i.btns=[{title:t[0].close,class:"secondary"}]),!n&&2&l&&(n=!0,i.msg=t[1],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function xn(t,e,n){let{lng:l={}}=e;const i={show(t){o.show(t)}};let o;return t.$$set=t=>{"lng"in t&&n(0,l=t.lng)},[l,o,i,function(t){o=t,n(1,o)}]}class kn extends rt{constructor(t){super(),ct(this,t,xn,yn,c,{lng:0,err:2})}get err(){return this.$$.ctx[2]}}function zn(t){let e,n,l;function i(e){t[7](e)}let o={header:t[0].confirm,icon:{name:"help-circle"},btns:[{title:t[0].yes,class:"primary",callback:t[5]},{title:t[0].no,class:"secondary",callback:t[6]}]};return void 0!==t[3]&&(o.msg=t[3]),e=new bn({props:o}),S.push((()=>et(e,"msg",i,t[3]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0].confirm),7&l&&(i.btns=[{title:t[0].yes,class:"primary",callback:t[5]},{title:t[0].no,class:"secondary",callback:t[6]}]),!n&&8&l&&(n=!0,i.msg=t[3],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function _n(t,e,n){let{lng:l={}}=e;const i={show(t,e,l){n(1,o=e),n(2,c=l),r.show(t)}};let o,c,r;return t.$$set=t=>{"lng"in t&&n(0,l=t.lng)},[l,o,c,r,i,()=>{o&&o()},()=>{c&&c()},function(t){r=t,n(3,r)}]}class qn extends rt{constructor(t){super(),ct(this,t,_n,zn,c,{lng:0,confirm:4})}get confirm(){return this.$$.ctx[4]}}function Dn(t){let e,n,l;function i(e){t[5](e)}let o={header:t[0].info,icon:{name:"information-slab-circle"},btns:[{title:t[0].ok,class:"primary",callback:t[4]}]};return void 0!==t[1]&&(o.msg=t[1]),e=new bn({props:o}),S.push((()=>et(e,"msg",i,t[1]))),{c(){nt(e.$$.fragment)},m(t,n){lt(e,t,n),l=!0},p(t,[l]){const i={};1&l&&(i.header=t[0].info),5&l&&(i.btns=[{title:t[0].ok,class:"primary",callback:t[4]}]),!n&&2&l&&(n=!0,i.msg=t[1],U((()=>n=!1))),e.$set(i)},i(t){l||(W(e.$$.fragment,t),l=!0)},o(t){tt(e.$$.fragment,t),l=!1},d(t){it(e,t)}}}function jn(t,e,n){let{lng:l={}}=e;const i={show(t,e){n(2,c=e),o.show(t)}};let o,c;return t.$$set=t=>{"lng"in t&&n(0,l=t.lng)},[l,o,c,i,()=>{c&&c()},function(t){o=t,n(1,o)}]}class En extends rt{constructor(t){super(),ct(this,t,jn,Dn,c,{lng:0,info:3})}get info(){return this.$$.ctx[3]}}function Mn(t,e,n){const l=t.slice();return l[28]=e[n],l}function An(t,e,n){const l=t.slice();return l[31]=e[n],l}function Nn(t){let n,l,i;return{c(){n=g("button"),n.textContent="Отмена",x(n,"class","btn secondary")},m(e,o){h(e,n,o),l||(i=y(n,"click",t[15]),l=!0)},p:e,d(t){t&&m(n),l=!1,i()}}}function Tn(t){let e,n,l,i=t[4],o=[];for(let e=0;e<i.length;e+=1)o[e]=On(Mn(t,i,e));const c=t=>tt(o[t],1,1,(()=>{o[t]=null}));let r=0==t[4].length&&t[6]&&Sn();return{c(){for(let t=0;t<o.length;t+=1)o[t].c();e=w(),r&&r.c(),n=b()},m(t,i){for(let e=0;e<o.length;e+=1)o[e].m(t,i);h(t,e,i),r&&r.m(t,i),h(t,n,i),l=!0},p(t,l){if(7184&l[0]){let n;for(i=t[4],n=0;n<i.length;n+=1){const c=Mn(t,i,n);o[n]?(o[n].p(c,l),W(o[n],1)):(o[n]=On(c),o[n].c(),W(o[n],1),o[n].m(e.parentNode,e))}for(R(),n=i.length;n<o.length;n+=1)c(n);V()}0==t[4].length&&t[6]?r?80&l[0]&&W(r,1):(r=Sn(),r.c(),W(r,1),r.m(n.parentNode,n)):r&&(R(),tt(r,1,1,(()=>{r=null})),V())},i(t){if(!l){for(let t=0;t<i.length;t+=1)W(o[t]);W(r),l=!0}},o(t){o=o.filter(Boolean);for(let t=0;t<o.length;t+=1)tt(o[t]);tt(r),l=!1},d(t){p(o,t),t&&m(e),r&&r.d(t),t&&m(n)}}}function Fn(t){let e,n,l;return n=new mt({props:{color:"var(--gray-500)",name:"repeat-variant",size:"1.25em"}}),{c(){e=g("div"),nt(n.$$.fragment),x(e,"class","fav")},m(t,i){h(t,e,i),lt(n,e,null),l=!0},i(t){l||(W(n.$$.fragment,t),l=!0)},o(t){tt(n.$$.fragment,t),l=!1},d(t){t&&m(e),it(n)}}}function Cn(t){let e,n,l=t[31].comment+"";return{c(){e=g("div"),n=v(l),x(e,"class","notetext")},m(t,l){h(t,e,l),f(e,n)},p(t,e){16&e[0]&&l!==(l=t[31].comment+"")&&_(n,l)},d(t){t&&m(e)}}}function Ln(t){let e,n,l,o,c,r,s,a,u,d,p,$,b,k,z,q,j,E,M,A,N,T,F=t[31].title+"",C=t[31].repeat&&Fn();function L(){return t[16](t[31])}let O=t[31].comment&&Cn(t);function S(){return t[17](t[31])}function Y(){return t[18](t[31])}function P(...e){return t[19](t[31],...e)}return b=new mt({props:{name:"check-bold",size:"1.4em;"}}),q=new mt({props:{name:"edit",size:"1.4em;"}}),M=new mt({props:{name:"trash-can-outline"}}),{c(){e=g("div"),n=g("div"),l=g("div"),C&&C.c(),o=w(),c=g("button"),c.innerHTML='<svg class="tocheck svelte-6zk4ms" viewBox="0 0 24 24"><path d="M12,20A8,8 0 0,1 4,12A8,8 0 0,1 12,4A8,8 0 0,1 20,12A8,8 0 0,1 12,20M12,2A10,10 0 0,0 2,12A10,10 0 0,0 12,22A10,10 0 0,0 22,12A10,10 0 0,0 12,2Z" class="svelte-6zk4ms"></path></svg>',r=w(),s=g("div"),a=v(F),u=w(),O&&O.c(),d=w(),p=g("div"),$=g("a"),nt(b.$$.fragment),k=w(),z=g("a"),nt(q.$$.fragment),j=w(),E=g("a"),nt(M.$$.fragment),x(c,"class","btnicon"),D(c,"padding-bottom","0.5em"),x(s,"class","notetitle"),x(l,"class","todo svelte-6zk4ms"),x($,"href",null),x($,"title","Выполнить"),x(z,"href",null),x(z,"title","Редактировать"),x(E,"href",null),x(E,"title","Удалить"),x(p,"class","notebtns"),x(n,"class","panel note"),D(n,"position","relative"),x(e,"class","notecard")},m(t,i){h(t,e,i),f(e,n),f(n,l),C&&C.m(l,null),f(l,o),f(l,c),f(l,r),f(l,s),f(s,a),f(n,u),O&&O.m(n,null),f(n,d),f(n,p),f(p,$),lt(b,$,null),f(p,k),f(p,z),lt(q,z,null),f(p,j),f(p,E),lt(M,E,null),A=!0,N||(T=[y(c,"click",L),y($,"click",S),y(z,"click",Y),y(E,"click",P)],N=!0)},p(e,i){(t=e)[31].repeat?C?16&i[0]&&W(C,1):(C=Fn(),C.c(),W(C,1),C.m(l,o)):C&&(R(),tt(C,1,1,(()=>{C=null})),V()),(!A||16&i[0])&&F!==(F=t[31].title+"")&&_(a,F),t[31].comment?O?O.p(t,i):(O=Cn(t),O.c(),O.m(n,d)):O&&(O.d(1),O=null)},i(t){A||(W(C),W(b.$$.fragment,t),W(q.$$.fragment,t),W(M.$$.fragment,t),A=!0)},o(t){tt(C),tt(b.$$.fragment,t),tt(q.$$.fragment,t),tt(M.$$.fragment,t),A=!1},d(t){t&&m(e),C&&C.d(),O&&O.d(),it(b),it(q),it(M),N=!1,i(T)}}}function On(t){let e,n,l,i,o,c=t[28].date+"",r=t[28].list,s=[];for(let e=0;e<r.length;e+=1)s[e]=Ln(An(t,r,e));const a=t=>tt(s[t],1,1,(()=>{s[t]=null}));return{c(){e=g("div"),n=v(c),l=w(),i=g("div");for(let t=0;t<s.length;t+=1)s[t].c();x(e,"class","day svelte-6zk4ms"),x(i,"class","notelist")},m(t,c){h(t,e,c),f(e,n),h(t,l,c),h(t,i,c);for(let t=0;t<s.length;t+=1)s[t].m(i,null);o=!0},p(t,e){if((!o||16&e[0])&&c!==(c=t[28].date+"")&&_(n,c),7184&e[0]){let n;for(r=t[28].list,n=0;n<r.length;n+=1){const l=An(t,r,n);s[n]?(s[n].p(l,e),W(s[n],1)):(s[n]=Ln(l),s[n].c(),W(s[n],1),s[n].m(i,null))}for(R(),n=r.length;n<s.length;n+=1)a(n);V()}},i(t){if(!o){for(let t=0;t<r.length;t+=1)W(s[t]);o=!0}},o(t){s=s.filter(Boolean);for(let t=0;t<s.length;t+=1)tt(s[t]);o=!1},d(t){t&&m(e),t&&m(l),t&&m(i),p(s,t)}}}function Sn(t){let e,n,l;return n=new xt({props:{type:"warning",msg:"Задачи не найдены",show:!0,noclose:!0}}),{c(){e=g("div"),nt(n.$$.fragment),D(e,"display","flex"),D(e,"justify-content","center")},m(t,i){h(t,e,i),lt(n,e,null),l=!0},i(t){l||(W(n.$$.fragment,t),l=!0)},o(t){tt(n.$$.fragment,t),l=!1},d(t){t&&m(e),it(n)}}}function Yn(t){let e,n,l,o,c,r,s,a,u,d,p,$,b,k,z,_,q,j,E,M,A,N,T,F,C,L,O,Y,P,B;function H(e){t[14](e)}let I={style:"width: 10em;",placeholder:"Поиск..."};void 0!==t[5]&&(I.value=t[5]),r=new Ot({props:I}),S.push((()=>et(r,"value",H,t[5])));let X=t[5]&&Nn(t),Z=t[4]&&Tn(t);function G(e){t[20](e)}let J={};function K(e){t[21](e)}void 0!==t[3]&&(J.showForm=t[3]),q=new gn({props:J}),S.push((()=>et(q,"showForm",G,t[3])));let Q={lng:t[7]};function ot(e){t[22](e)}void 0!==t[0]&&(Q.err=t[0]),M=new kn({props:Q}),S.push((()=>et(M,"err",K,t[0])));let ct={lng:t[7]};function rt(e){t[23](e)}void 0!==t[1]&&(ct.confirm=t[1]),T=new qn({props:ct}),S.push((()=>et(T,"confirm",ot,t[1])));let st={lng:t[7]};return void 0!==t[2]&&(st.info=t[2]),L=new En({props:st}),S.push((()=>et(L,"info",rt,t[2]))),{c(){e=g("div"),n=g("div"),l=g("button"),l.textContent="Добавить задачу",o=w(),c=g("div"),nt(r.$$.fragment),a=w(),u=g("button"),d=v("Найти"),$=w(),X&&X.c(),b=w(),k=g("div"),z=g("div"),Z&&Z.c(),_=w(),nt(q.$$.fragment),E=w(),nt(M.$$.fragment),N=w(),nt(T.$$.fragment),C=w(),nt(L.$$.fragment),x(l,"class","btn primary"),x(u,"class","btn primary"),u.disabled=p=!t[5],D(c,"display","flex"),D(c,"column-gap","0.5em"),x(n,"class","topnav svelte-6zk4ms"),D(z,"flex-grow","1"),D(z,"overflow","auto"),D(z,"flex-direction","column"),D(z,"padding","1em 2em"),x(k,"class","body svelte-6zk4ms"),x(e,"class","app svelte-6zk4ms")},m(i,s){h(i,e,s),f(e,n),f(n,l),f(n,o),f(n,c),lt(r,c,null),f(c,a),f(c,u),f(u,d),f(c,$),X&&X.m(c,null),f(e,b),f(e,k),f(k,z),Z&&Z.m(z,null),h(i,_,s),lt(q,i,s),h(i,E,s),lt(M,i,s),h(i,N,s),lt(T,i,s),h(i,C,s),lt(L,i,s),Y=!0,P||(B=[y(l,"click",t[9]),y(u,"click",t[13])],P=!0)},p(t,e){const n={};!s&&32&e[0]&&(s=!0,n.value=t[5],U((()=>s=!1))),r.$set(n),(!Y||32&e[0]&&p!==(p=!t[5]))&&(u.disabled=p),t[5]?X?X.p(t,e):(X=Nn(t),X.c(),X.m(c,null)):X&&(X.d(1),X=null),t[4]?Z?(Z.p(t,e),16&e[0]&&W(Z,1)):(Z=Tn(t),Z.c(),W(Z,1),Z.m(z,null)):Z&&(R(),tt(Z,1,1,(()=>{Z=null})),V());const l={};!j&&8&e[0]&&(j=!0,l.showForm=t[3],U((()=>j=!1))),q.$set(l);const i={};!A&&1&e[0]&&(A=!0,i.err=t[0],U((()=>A=!1))),M.$set(i);const o={};!F&&2&e[0]&&(F=!0,o.confirm=t[1],U((()=>F=!1))),T.$set(o);const a={};!O&&4&e[0]&&(O=!0,a.info=t[2],U((()=>O=!1))),L.$set(a)},i(t){Y||(W(r.$$.fragment,t),W(Z),W(q.$$.fragment,t),W(M.$$.fragment,t),W(T.$$.fragment,t),W(L.$$.fragment,t),Y=!0)},o(t){tt(r.$$.fragment,t),tt(Z),tt(q.$$.fragment,t),tt(M.$$.fragment,t),tt(T.$$.fragment,t),tt(L.$$.fragment,t),Y=!1},d(t){t&&m(e),it(r),X&&X.d(),Z&&Z.d(),t&&m(_),it(q,t),t&&m(E),it(M,t),t&&m(N),it(T,t),t&&m(C),it(L,t),P=!1,i(B)}}}function Pn(t,e,n){let l,i,o,c,r,s=Object.assign({close:"Закрыть",confirm:"Подтверждение",ereq:"Заполните поле: ",ereqs:"Заполните поля: ",error:"Ошибка",info:"Информация",no:"Нет",ok:"ОК",qdelete:"Вы действительно хотите удалить задачу?",yes:"Да"},window.uiMessages),a="",u={info:(t,e)=>o.show(t,e),error:t=>{String(t).includes("401")?window.location="/login.html":l.show(t)},confirm:(t,e,n)=>i.show(t,e,n)};C("ctx",{fn:u,lng:s});let d="",f=!1;function h(t){ut("tasks"+(t?"?search="+t:""),(t=>{if(a="",n(4,r=[]),n(6,f=!0),t.tasks)for(let e=0;e<t.tasks.length;e++){let n=t.tasks[e];if(a!=n.date){let t=n.date.substr(6)+"."+n.date.substr(4,2)+"."+n.date.substr(0,4);r.push({date:t,list:[n]}),a=n.date}else r[r.length-1].list.push(n)}}),(t=>{String(t).includes("404")||u.error(t)}))}function m(t,e,n){if("update"===t)h()}function p(t,e){let n=""==t?"Добавить новую задачу":"Карточка задачи";c.show({title:n,param:e&&e.param?e.param:null,id:t,callback:m})}function g(t){st("task/done?id="+t,{},(t=>{h()}),u.error)}function $(t){p(t.id)}function v(t){u.confirm(s.qdelete,(()=>{var e,n,l,i;e="task?id="+t.id,n={},l=t=>{h()},i=u.error,axios.delete("api/"+e,n).then((t=>{if(t.data.error)i(t.data.error);else{let e=l(t.data);e&&i(e)}})).catch((t=>{i(t)}))}))}F((()=>{h()}));return[l,i,o,c,r,d,f,s,h,function(){p("")},g,$,v,function(){h(d)},function(t){d=t,n(5,d)},()=>{n(5,d=""),h()},t=>{g(t.id)},t=>{g(t.id)},t=>{$(t)},(t,e)=>{v(t)},function(t){c=t,n(3,c)},function(t){l=t,n(0,l)},function(t){i=t,n(1,i)},function(t){o=t,n(2,o)}]}function Bn(t){let e,n,l,i,L,I,o,c,r,s,a,u,d,p,$,v,b,k,K;function z(e){t[4](e)}let _={placeholder:"Пароль"};function q(e){t[5](e)}void 0!==t[0]&&(_.value=t[0]),r=new Pt({props:_}),S.push((()=>et(r,"value",z,t[0])));let j={lng:t[2]};return void 0!==t[1]&&(j.err=t[1]),p=new kn({props:j}),S.push((()=>et(p,"err",q,t[1]))),{c(){e=g("div"),n=g("div"),l=g("div"),i=g("div"),i.innerHTML='<div style="display: flex; align-items: center; justify-content: space-between;margin-bottom: 0.5em"><h5>Введите логин и пароль</h5></div>',L=g("div"),I=g("input"),o=w(),c=g("div"),nt(r.$$.fragment),a=w(),u=g("button"),u.textContent="Войти",d=w(),nt(p.$$.fragment),x(i,"class","smaller"),x(I,"class","input"),x(I,"type","text"),x(I,"placeholder",t[2].login||"Логин (пусто для администратора)"),x(I,"autocomplete","username"),D(I,"width","100%"),x(L,"style","margin: 1em 0 0;width: 100%"),x(c,"style","margin: 1em 0;width; 100%"),x(u,"class","btn primary"),x(l,"class","bigger"),D(l,"width","100%"),D(l,"display","flex"),D(l,"flex-direction","column"),D(l,"align-items","stretch"),D(l,"padding-bottom","1em"),x(n,"class","card"),D(n,"width","21em"),D(n,"align-items","center"),D(e,"min-height","100vh"),D(e,"display","flex"),D(e,"align-items","center"),D(e,"justify-content","center"),D(e,"overflow","auto")},m(s,m){h(s,e,m),f(e,n),f(n,l),f(l,i),f(l,L),f(L,I),f(l,o),f(l,c),lt(r,c,null),f(l,a),f(l,u),h(s,d,m),lt(p,s,m),v=!0,b||(k=y(u,"click",t[3]),K=y(I,"input",t[6]),b=!0)},p(t,[e]){const n={};!s&&1&e&&(s=!0,n.value=t[0],U((()=>s=!1))),r.$set(n);const l={};!$&&2&e&&($=!0,l.err=t[1],U((()=>$=!1))),p.$set(l)},i(t){v||(W(r.$$.fragment,t),W(p.$$.fragment,t),v=!0)},o(t){tt(r.$$.fragment,t),tt(p.$$.fragment,t),v=!1},d(t){t&&m(e),it(r),t&&m(d),it(p,t),b=!1,k(),K()}}}function Hn(t,e,n){let l,i="",c="",o=Object.assign({close:"Закрыть",confirm:"Подтверждение",ereq:"Заполните поле: ",ereqs:"Заполните поля: ",error:"Ошибка",info:"Информация",no:"Нет",ok:"ОК",qdelete:"Вы действительно хотите удалить задачу?",yes:"Да"},window.uiMessages);return C("ctx",{lng:o}),[i,l,o,function(){at("signin",{login:c,password:i},(t=>{if(t.token){let e=new Date;e.setTime(e.getTime()+288e5);let n="expires="+e.toUTCString();document.cookie="token="+t.token+";"+n+";path=/",window.location="/"}else t.mfa_required&&window.loginMFA&&window.loginMFA(t.mfa_token)}),(t=>l.show(t)))},function(t){i=t,n(0,i)},function(t){l=t,n(1,l)},function(){c=this.value.trim()}]}return t.App=class extends rt{constructor(t){super(),ct(this,t,Pn,Yn,c,{},null,[-1,-1])}},t.Login=class extends rt{constructor(t){super(),ct(this,t,Hn,Bn,c,{})}},Object.defineProperty(t,"__esModule",{value:!0}),t}({});